@@ -0,0 +1,311 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+)
+
+// ruleState is the state of a Rule for a single key.
+type ruleState struct {
+	pendingSince int64
+	firing       bool
+	alert        Alert
+}
+
+// Engine evaluates Rules against newly written Metric and Inventory data,
+// and sends Alerts to its Notifiers when Rules fire or resolve.
+type Engine struct {
+	db             report.DBI
+	metricRules    []Rule
+	inventoryRules []Rule
+	notifiers      []Notifier
+
+	mu     sync.Mutex
+	states map[string]*ruleState
+	// metricFrom is the timestamp of last evaluated Metric, and
+	// metricSeen are the IDs of evaluated Metrics with that timestamp.
+	metricFrom int64
+	metricSeen map[string]bool
+}
+
+// NewEngine creates an Engine for the specified Rules.
+// An error is returned if any Rule is invalid.
+func NewEngine(db report.DBI, rules []Rule, notifiers ...Notifier) (*Engine, error) {
+	e := &Engine{
+		db:         db,
+		notifiers:  notifiers,
+		states:     map[string]*ruleState{},
+		metricSeen: map[string]bool{},
+	}
+
+	ids := map[string]bool{}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if ids[r.ID] {
+			return nil, errors.New("Duplicate Rule ID: " + r.ID)
+		}
+		ids[r.ID] = true
+
+		if r.Source == SourceMetric {
+			e.metricRules = append(e.metricRules, r)
+		} else {
+			e.inventoryRules = append(e.inventoryRules, r)
+		}
+	}
+	return e, nil
+}
+
+// Run evaluates the Rules every interval until ctx is done.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Evaluate(time.Now()); err != nil {
+			log.Println(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate evaluates the Rules once. Metric-rules are evaluated against the
+// Metrics written since last evaluation, and inventory-rules against the
+// Inventory in their current period.
+func (e *Engine) Evaluate(now time.Time) error {
+	alerts, err := e.evaluate(now)
+	// Alerts are sent after releasing the lock, so slow
+	// Notifiers do not block Active or other evaluations.
+	for _, alert := range alerts {
+		e.notify(alert)
+	}
+	return err
+}
+
+// evaluate evaluates the Rules, and returns the Alerts to send. Alerts
+// from Rules evaluated before an error are returned with the error.
+func (e *Engine) evaluate(now time.Time) ([]Alert, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := []Alert{}
+	if len(e.metricRules) > 0 {
+		if err := e.evaluateMetrics(now, &alerts); err != nil {
+			return alerts, err
+		}
+	}
+	for _, r := range e.inventoryRules {
+		if err := e.evaluateInventory(r, now, &alerts); err != nil {
+			return alerts, err
+		}
+	}
+	return alerts, nil
+}
+
+// Active returns the Alerts which are currently firing.
+func (e *Engine) Active() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := []Alert{}
+	for _, st := range e.states {
+		if st.firing {
+			alerts = append(alerts, st.alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].FiredAt < alerts[j].FiredAt
+	})
+	return alerts
+}
+
+func (e *Engine) evaluateMetrics(now time.Time, alerts *[]Alert) error {
+	if e.metricFrom == 0 {
+		// Look back far enough for the longest Rule-duration to be satisfied
+		var maxFor int64
+		for _, r := range e.metricRules {
+			if r.ForSeconds > maxFor {
+				maxFor = r.ForSeconds
+			}
+		}
+		e.metricFrom = now.Unix() - maxFor
+	}
+
+	locations := []interface{}{}
+	for _, r := range e.metricRules {
+		if r.Location != "" {
+			locations = append(locations, r.Location)
+		}
+	}
+	deviceLocations := map[string]string{}
+	if len(locations) > 0 {
+		// Devices are located through the items stored while the Metrics were
+		// read, which arrived by now and had not expired by metricFrom.
+		inventory, err := e.db.QueryInventory(report.And(
+			report.In("location", locations...),
+			report.TimeRange("date_arrived", 0, now.Unix()),
+			report.TimeRange("expiry_date", e.metricFrom, 0),
+		))
+		if err != nil {
+			err = errors.Wrap(err, "Error loading device-locations for alert-rules")
+			return err
+		}
+		for _, inv := range *inventory {
+			deviceLocations[inv.DeviceID.String()] = inv.Location
+		}
+	}
+
+	metrics, err := e.db.QueryMetrics(report.TimeRange("timestamp", e.metricFrom, now.Unix()))
+	if err != nil {
+		err = errors.Wrap(err, "Error loading metrics for alert-rules")
+		return err
+	}
+	sort.SliceStable(*metrics, func(i, j int) bool {
+		return (*metrics)[i].Timestamp < (*metrics)[j].Timestamp
+	})
+
+	for i := range *metrics {
+		m := &(*metrics)[i]
+		id := m.ID.Hex()
+		if m.Timestamp == e.metricFrom && e.metricSeen[id] {
+			continue
+		}
+		if m.Timestamp > e.metricFrom {
+			e.metricFrom = m.Timestamp
+			e.metricSeen = map[string]bool{}
+		}
+		e.metricSeen[id] = true
+
+		deviceID := m.DeviceID.String()
+		for _, r := range e.metricRules {
+			location := deviceLocations[deviceID]
+			if r.Location != "" && location != r.Location {
+				continue
+			}
+			labels := map[string]string{
+				"device_id": deviceID,
+				"item_id":   m.ItemID.String(),
+			}
+			if location != "" {
+				labels["location"] = location
+			}
+
+			// Readings without the field do not change the alert's state
+			value, ok := metricFields[r.Field](m)
+			if !ok {
+				continue
+			}
+			holds := operators[r.Operator](value, r.Threshold)
+			e.transition(r, deviceID, labels, holds, value, m.Timestamp, alerts)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) evaluateInventory(r Rule, now time.Time, alerts *[]Alert) error {
+	start, err := periodStart(r.period(), now)
+	if err != nil {
+		return err
+	}
+	filter := report.TimeRange("timestamp", start.Unix(), now.Unix())
+	if r.Location != "" {
+		filter = report.And(filter, report.Eq("location", r.Location))
+	}
+
+	inventory, err := e.db.QueryInventory(filter)
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for alert-rules")
+		return err
+	}
+	totals := &inventoryTotals{}
+	for _, inv := range *inventory {
+		totals.total += inv.TotalWeight
+		totals.sold += inv.SoldWeight
+		totals.waste += inv.WasteWeight
+		totals.donate += inv.DonateWeight
+	}
+
+	labels := map[string]string{
+		"period": string(r.period()),
+	}
+	if r.Location != "" {
+		labels["location"] = r.Location
+	}
+	value := inventoryFields[r.Field](totals)
+	holds := operators[r.Operator](value, r.Threshold)
+	e.transition(r, r.Location, labels, holds, value, now.Unix(), alerts)
+	return nil
+}
+
+// transition updates the state of Rule for key, and adds an Alert
+// to alerts if the Rule fires or resolves at timestamp.
+func (e *Engine) transition(
+	r Rule,
+	key string,
+	labels map[string]string,
+	holds bool,
+	value float64,
+	timestamp int64,
+	alerts *[]Alert,
+) {
+	stateKey := r.ID + "|" + key
+	st, ok := e.states[stateKey]
+
+	if !holds {
+		if ok && st.firing {
+			resolved := st.alert
+			resolved.Status = StatusResolved
+			resolved.Value = value
+			resolved.ResolvedAt = timestamp
+			*alerts = append(*alerts, resolved)
+		}
+		delete(e.states, stateKey)
+		return
+	}
+
+	if !ok {
+		st = &ruleState{
+			pendingSince: timestamp,
+		}
+		e.states[stateKey] = st
+	}
+	if st.firing {
+		st.alert.Value = value
+		return
+	}
+	if timestamp-st.pendingSince >= r.ForSeconds {
+		st.firing = true
+		st.alert = Alert{
+			RuleID:    r.ID,
+			RuleName:  r.Name,
+			Condition: r.String(),
+			Key:       key,
+			Labels:    labels,
+			Status:    StatusFiring,
+			Value:     value,
+			StartsAt:  st.pendingSince,
+			FiredAt:   timestamp,
+		}
+		*alerts = append(*alerts, st.alert)
+	}
+}
+
+func (e *Engine) notify(alert Alert) {
+	for _, n := range e.notifiers {
+		if err := n.Notify(alert); err != nil {
+			err = errors.Wrap(err, "Error sending alert")
+			log.Println(err)
+		}
+	}
+}
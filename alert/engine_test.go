@@ -0,0 +1,263 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// fakeDB returns the configured Metrics and Inventory for every query,
+// and records the Inventory-filters.
+type fakeDB struct {
+	report.DBI
+	metrics          []report.Metric
+	inventory        []report.Inventory
+	inventoryFilters []report.Filter
+}
+
+func (db *fakeDB) QueryMetrics(filter report.Filter) (*[]report.Metric, error) {
+	metrics := append([]report.Metric{}, db.metrics...)
+	return &metrics, nil
+}
+
+func (db *fakeDB) QueryInventory(filter report.Filter) (*[]report.Inventory, error) {
+	db.inventoryFilters = append(db.inventoryFilters, filter)
+	inventory := append([]report.Inventory{}, db.inventory...)
+	return &inventory, nil
+}
+
+// recorder records the Alerts it is notified of, along with the
+// Alerts active at the time.
+type recorder struct {
+	engine *Engine
+	alerts []Alert
+	active [][]Alert
+}
+
+func (r *recorder) Notify(alert Alert) error {
+	r.alerts = append(r.alerts, alert)
+	// Notifiers are called without holding the engine's lock
+	r.active = append(r.active, r.engine.Active())
+	return nil
+}
+
+func newTestEngine(t *testing.T, db report.DBI, rule Rule) (*Engine, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	engine, err := NewEngine(db, []Rule{rule}, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.engine = engine
+	return engine, rec
+}
+
+func newMetric(t *testing.T, deviceID uuuid.UUID, timestamp int64, tempIn float64, humidity float64) report.Metric {
+	t.Helper()
+	itemID, err := uuuid.NewV4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report.Metric{
+		ID:        objectid.New(),
+		ItemID:    itemID,
+		DeviceID:  deviceID,
+		Timestamp: timestamp,
+		TempIn:    tempIn,
+		Humidity:  humidity,
+	}
+}
+
+func TestEngineMetricTransitions(t *testing.T) {
+	deviceID, err := uuuid.NewV4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := Rule{
+		ID:         "warm",
+		Source:     SourceMetric,
+		Field:      "temp_in",
+		Operator:   ">",
+		Threshold:  5,
+		ForSeconds: 600,
+	}
+	db := &fakeDB{}
+	engine, rec := newTestEngine(t, db, rule)
+
+	// Pending
+	first := newMetric(t, deviceID, 1000, 6, 0)
+	db.metrics = []report.Metric{first}
+	if err := engine.Evaluate(time.Unix(1000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.alerts) != 0 || len(engine.Active()) != 0 {
+		t.Fatalf("got alerts %+v while pending", rec.alerts)
+	}
+
+	// Firing once the condition holds for ForSeconds. The first
+	// reading is returned again, and is not evaluated twice.
+	db.metrics = []report.Metric{
+		first,
+		newMetric(t, deviceID, 1300, 7, 0),
+		newMetric(t, deviceID, 1600, 8, 0),
+	}
+	if err := engine.Evaluate(time.Unix(1600, 0)); err != nil {
+		t.Fatal(err)
+	}
+	firing := Alert{
+		RuleID:    "warm",
+		Condition: rule.String(),
+		Key:       deviceID.String(),
+		Labels: map[string]string{
+			"device_id": deviceID.String(),
+			"item_id":   db.metrics[2].ItemID.String(),
+		},
+		Status:   StatusFiring,
+		Value:    8,
+		StartsAt: 1000,
+		FiredAt:  1600,
+	}
+	if !reflect.DeepEqual(rec.alerts, []Alert{firing}) {
+		t.Fatalf("got %+v, want %+v", rec.alerts, firing)
+	}
+	if !reflect.DeepEqual(rec.active[0], []Alert{firing}) {
+		t.Errorf("got active %+v, want %+v", rec.active[0], firing)
+	}
+
+	// Resolved
+	db.metrics = []report.Metric{newMetric(t, deviceID, 1900, 4, 0)}
+	if err := engine.Evaluate(time.Unix(1900, 0)); err != nil {
+		t.Fatal(err)
+	}
+	resolved := firing
+	resolved.Status = StatusResolved
+	resolved.Value = 4
+	resolved.ResolvedAt = 1900
+	if len(rec.alerts) != 2 || !reflect.DeepEqual(rec.alerts[1], resolved) {
+		t.Fatalf("got %+v, want resolved %+v", rec.alerts, resolved)
+	}
+	if len(engine.Active()) != 0 {
+		t.Errorf("got active %+v after resolving", engine.Active())
+	}
+}
+
+// Readings without humidity do not resolve a low-humidity alert.
+func TestEngineSkipsMissingFields(t *testing.T) {
+	deviceID, err := uuuid.NewV4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := Rule{
+		ID:        "dry",
+		Source:    SourceMetric,
+		Field:     "humidity",
+		Operator:  "<",
+		Threshold: 85,
+	}
+	db := &fakeDB{}
+	engine, rec := newTestEngine(t, db, rule)
+
+	db.metrics = []report.Metric{
+		newMetric(t, deviceID, 1000, 2, 80),
+		newMetric(t, deviceID, 1100, 2, 0),
+	}
+	if err := engine.Evaluate(time.Unix(1100, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.alerts) != 1 || rec.alerts[0].Status != StatusFiring {
+		t.Fatalf("got %+v, want a single firing alert", rec.alerts)
+	}
+	if active := engine.Active(); len(active) != 1 || active[0].Value != 80 {
+		t.Errorf("got active %+v, want alert with value 80", active)
+	}
+}
+
+func TestEngineDeviceLocations(t *testing.T) {
+	deviceID, err := uuuid.NewV4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := Rule{
+		ID:        "warm",
+		Source:    SourceMetric,
+		Field:     "temp_in",
+		Operator:  ">",
+		Threshold: 5,
+		Location:  "A101",
+	}
+	db := &fakeDB{
+		inventory: []report.Inventory{{Location: "A101", DeviceID: deviceID}},
+		metrics:   []report.Metric{newMetric(t, deviceID, 1000, 6, 0)},
+	}
+	engine, rec := newTestEngine(t, db, rule)
+
+	if err := engine.Evaluate(time.Unix(1000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.alerts) != 1 || rec.alerts[0].Labels["location"] != "A101" {
+		t.Fatalf("got %+v, want a firing alert at A101", rec.alerts)
+	}
+
+	// Only the items stored while the Metrics were read are loaded
+	got := db.inventoryFilters[0].Compile()
+	want := map[string]interface{}{"$and": []interface{}{
+		map[string]interface{}{"location": map[string]interface{}{"$in": []interface{}{"A101"}}},
+		map[string]interface{}{"date_arrived": map[string]interface{}{"$lte": int64(1000)}},
+		map[string]interface{}{"expiry_date": map[string]interface{}{"$gte": int64(1000)}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got inventory-filter %v, want %v", got, want)
+	}
+}
+
+func TestEngineInventoryRule(t *testing.T) {
+	rule := Rule{
+		ID:        "waste",
+		Source:    SourceInventory,
+		Field:     "waste_ratio",
+		Operator:  ">",
+		Threshold: 0.2,
+		Location:  "A101",
+	}
+	db := &fakeDB{
+		inventory: []report.Inventory{
+			{Location: "A101", TotalWeight: 100, WasteWeight: 10},
+			{Location: "A101", TotalWeight: 100, WasteWeight: 30},
+		},
+	}
+	engine, rec := newTestEngine(t, db, rule)
+
+	now := time.Date(2018, 9, 10, 15, 0, 0, 0, time.UTC)
+	if err := engine.Evaluate(now); err != nil {
+		t.Fatal(err)
+	}
+	// 40 of 200 is not above threshold
+	if len(rec.alerts) != 0 {
+		t.Fatalf("got %+v, want no alerts", rec.alerts)
+	}
+
+	db.inventory[0].WasteWeight = 20
+	if err := engine.Evaluate(now); err != nil {
+		t.Fatal(err)
+	}
+	want := Alert{
+		RuleID:    "waste",
+		Condition: rule.String(),
+		Key:       "A101",
+		Labels: map[string]string{
+			"period":   "day",
+			"location": "A101",
+		},
+		Status:   StatusFiring,
+		Value:    0.25,
+		StartsAt: now.Unix(),
+		FiredAt:  now.Unix(),
+	}
+	if !reflect.DeepEqual(rec.alerts, []Alert{want}) {
+		t.Errorf("got %+v, want %+v", rec.alerts, want)
+	}
+}
@@ -0,0 +1,138 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Alert statuses.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Alert is a notification for a Rule changing state.
+type Alert struct {
+	RuleID    string `json:"rule_id"`
+	RuleName  string `json:"rule_name,omitempty"`
+	Condition string `json:"condition"`
+	// Key identifies what the Rule fired for, such as the DeviceID
+	// for metric-rules, or Location for inventory-rules.
+	Key    string            `json:"key,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Status string            `json:"status"`
+	Value  float64           `json:"value"`
+	// StartsAt is when the condition started holding.
+	StartsAt int64 `json:"starts_at"`
+	// FiredAt is when the Rule fired.
+	FiredAt int64 `json:"fired_at"`
+	// ResolvedAt is when condition stopped holding, for resolved Alerts.
+	ResolvedAt int64 `json:"resolved_at,omitempty"`
+}
+
+// Summary is a single-line description of the Alert.
+func (a *Alert) Summary() string {
+	s := fmt.Sprintf("[%s] %s", strings.ToUpper(a.Status), a.RuleID)
+	if a.RuleName != "" {
+		s += " (" + a.RuleName + ")"
+	}
+	s += ": " + a.Condition
+	if a.Key != "" {
+		s += " for " + a.Key
+	}
+	return s + fmt.Sprintf(", value %g", a.Value)
+}
+
+// Notifier sends Alerts.
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+// LogNotifier writes Alerts to the log.
+type LogNotifier struct{}
+
+// Notify logs the Alert.
+func (LogNotifier) Notify(alert Alert) error {
+	log.Println(alert.Summary())
+	return nil
+}
+
+// WebhookNotifier POSTs Alerts as JSON to a URL.
+type WebhookNotifier struct {
+	URL string
+	// Client is used for sending requests.
+	// A client with 10 seconds timeout is used if this is nil.
+	Client *http.Client
+}
+
+// Notify POSTs the Alert to webhook. An error is returned
+// if webhook does not respond with a 2xx status.
+func (n *WebhookNotifier) Notify(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		err = errors.Wrap(err, "Error marshalling alert")
+		return err
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		err = errors.Wrap(err, "Error sending alert to webhook")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier emails Alerts.
+type SMTPNotifier struct {
+	// Addr is the SMTP-server's host:port.
+	Addr string
+	From string
+	To   []string
+	// Auth is optional, such as smtp.PlainAuth.
+	Auth smtp.Auth
+}
+
+// Notify emails the Alert to all recipients.
+func (n *SMTPNotifier) Notify(alert Alert) error {
+	details, err := json.MarshalIndent(alert, "", "  ")
+	if err != nil {
+		err = errors.Wrap(err, "Error marshalling alert")
+		return err
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", alert.Summary())
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(msg, "%s\r\n\r\n", alert.Summary())
+	msg.Write(bytes.Replace(details, []byte("\n"), []byte("\r\n"), -1))
+	msg.WriteString("\r\n")
+
+	err = smtp.SendMail(n.Addr, n.Auth, n.From, n.To, msg.Bytes())
+	if err != nil {
+		err = errors.Wrap(err, "Error sending alert-email")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testAlert() Alert {
+	return Alert{
+		RuleID:    "warm",
+		RuleName:  "Warm storage",
+		Condition: "temp_in > 5",
+		Key:       "A101",
+		Status:    StatusFiring,
+		Value:     8,
+		StartsAt:  1000,
+		FiredAt:   1600,
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s request with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	alert := testAlert()
+	n := &WebhookNotifier{URL: server.URL}
+	if err := n.Notify(alert); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(received, alert) {
+		t.Errorf("got %+v, want %+v", received, alert)
+	}
+}
+
+func TestWebhookNotifierStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	n := &WebhookNotifier{URL: server.URL, Client: server.Client()}
+	if err := n.Notify(testAlert()); err == nil {
+		t.Error("expected error for 500 response")
+	}
+}
+
+// smtpMessage is an email received by serveSMTP.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// serveSMTP accepts a single SMTP session on listener, and
+// sends the received message on the returned channel.
+func serveSMTP(t *testing.T, listener net.Listener) <-chan smtpMessage {
+	received := make(chan smtpMessage, 1)
+	go func() {
+		defer close(received)
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		reply := func(line string) bool {
+			if err := text.PrintfLine("%s", line); err != nil {
+				t.Error(err)
+				return false
+			}
+			return true
+		}
+
+		msg := smtpMessage{}
+		reply("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL":
+				msg.from = line
+				reply("250 OK")
+			case "RCPT":
+				msg.to = append(msg.to, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 Start mail input")
+				lines, err := text.ReadDotLines()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				msg.data = strings.Join(lines, "\n")
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				received <- msg
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return received
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := serveSMTP(t, listener)
+
+	alert := testAlert()
+	n := &SMTPNotifier{
+		Addr: listener.Addr().String(),
+		From: "alerts@example.com",
+		To:   []string{"ops@example.com", "store@example.com"},
+	}
+	if err := n.Notify(alert); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := <-received
+	if !ok {
+		t.Fatal("no message received")
+	}
+	if msg.from != "MAIL FROM:<alerts@example.com>" {
+		t.Errorf("got %q", msg.from)
+	}
+	wantTo := []string{"RCPT TO:<ops@example.com>", "RCPT TO:<store@example.com>"}
+	if !reflect.DeepEqual(msg.to, wantTo) {
+		t.Errorf("got %q, want %q", msg.to, wantTo)
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data + "\n")))
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Subject") != alert.Summary() {
+		t.Errorf("got subject %q, want %q", header.Get("Subject"), alert.Summary())
+	}
+	if header.Get("To") != "ops@example.com, store@example.com" {
+		t.Errorf("got To %q", header.Get("To"))
+	}
+	if !strings.Contains(msg.data, `"rule_id": "warm"`) {
+		t.Errorf("alert details missing from body:\n%s", msg.data)
+	}
+}
@@ -0,0 +1,178 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+)
+
+// Sources of data evaluated by Rules.
+const (
+	SourceMetric    = "metric"
+	SourceInventory = "inventory"
+)
+
+// metricFields are the Metric-fields which can be used in Rules. Each returns
+// the field's value, and whether the reading includes the field.
+var metricFields = map[string]func(m *report.Metric) (float64, bool){
+	"temp_in":   func(m *report.Metric) (float64, bool) { return m.TempIn, true },
+	"humidity":  func(m *report.Metric) (float64, bool) { return m.MeasuredHumidity() },
+	"ethylene":  func(m *report.Metric) (float64, bool) { return m.Ethylene, true },
+	"carbon_di": func(m *report.Metric) (float64, bool) { return m.CarbonDi, true },
+}
+
+// inventoryFields are the Inventory-values, totalled over a Rule's
+// Period, which can be used in Rules.
+var inventoryFields = map[string]func(t *inventoryTotals) float64{
+	"total_weight":  func(t *inventoryTotals) float64 { return t.total },
+	"sold_weight":   func(t *inventoryTotals) float64 { return t.sold },
+	"waste_weight":  func(t *inventoryTotals) float64 { return t.waste },
+	"donate_weight": func(t *inventoryTotals) float64 { return t.donate },
+	"sold_ratio":    func(t *inventoryTotals) float64 { return t.ratio(t.sold) },
+	"waste_ratio":   func(t *inventoryTotals) float64 { return t.ratio(t.waste) },
+	"donate_ratio":  func(t *inventoryTotals) float64 { return t.ratio(t.donate) },
+}
+
+// operators are the comparisons which can be used in Rules.
+var operators = map[string]func(v float64, threshold float64) bool{
+	">":  func(v float64, t float64) bool { return v > t },
+	">=": func(v float64, t float64) bool { return v >= t },
+	"<":  func(v float64, t float64) bool { return v < t },
+	"<=": func(v float64, t float64) bool { return v <= t },
+	"==": func(v float64, t float64) bool { return v == t },
+	"!=": func(v float64, t float64) bool { return v != t },
+}
+
+// Rule is a threshold-condition on Metric or Inventory data.
+// Metric-rules are evaluated per device against each new reading, such as
+// "carbon_di > 1500 for 600 seconds at location B201". Inventory-rules are
+// evaluated against the totals for current Period, such as
+// "waste_ratio > 0.2 per day".
+type Rule struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Source is "metric" or "inventory".
+	Source string `json:"source"`
+	// Field is a Metric-field (temp_in, humidity, ethylene, carbon_di) for
+	// metric-rules, or a total (total_weight, sold_weight, waste_weight,
+	// donate_weight, sold_ratio, waste_ratio, donate_ratio) for inventory-rules.
+	Field     string  `json:"field"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	// ForSeconds is the duration for which condition must hold before
+	// the Rule fires. The Rule fires immediately if this is 0.
+	ForSeconds int64 `json:"for_seconds,omitempty"`
+	// Location optionally restricts the Rule to an Inventory Location.
+	// Metrics are matched to Locations using their DeviceID.
+	Location string `json:"location,omitempty"`
+	// Period is the time-period totalled by inventory-rules
+	// (hour, day, week or month). Defaults to day.
+	Period report.Interval `json:"period,omitempty"`
+}
+
+// Validate checks if the Rule is valid.
+func (r *Rule) Validate() error {
+	if r.ID == "" {
+		return errors.New("Rule ID is required")
+	}
+	if _, ok := operators[r.Operator]; !ok {
+		return fmt.Errorf("Rule %s: unsupported operator: %s", r.ID, r.Operator)
+	}
+	if r.ForSeconds < 0 {
+		return fmt.Errorf("Rule %s: for_seconds cannot be negative", r.ID)
+	}
+
+	switch r.Source {
+	case SourceMetric:
+		if _, ok := metricFields[r.Field]; !ok {
+			return fmt.Errorf("Rule %s: unsupported metric-field: %s", r.ID, r.Field)
+		}
+	case SourceInventory:
+		if _, ok := inventoryFields[r.Field]; !ok {
+			return fmt.Errorf("Rule %s: unsupported inventory-field: %s", r.ID, r.Field)
+		}
+		if _, err := periodStart(r.period(), time.Now()); err != nil {
+			return errors.Wrapf(err, "Rule %s", r.ID)
+		}
+	default:
+		return fmt.Errorf("Rule %s: unsupported source: %s", r.ID, r.Source)
+	}
+	return nil
+}
+
+// String describes the Rule's condition.
+func (r *Rule) String() string {
+	s := fmt.Sprintf("%s %s %g", r.Field, r.Operator, r.Threshold)
+	if r.Source == SourceInventory {
+		s += " per " + string(r.period())
+	}
+	if r.ForSeconds > 0 {
+		s += fmt.Sprintf(" for %s", time.Duration(r.ForSeconds)*time.Second)
+	}
+	if r.Location != "" {
+		s += " at location " + r.Location
+	}
+	return s
+}
+
+func (r *Rule) period() report.Interval {
+	if r.Period == "" {
+		return report.IntervalDay
+	}
+	return r.Period
+}
+
+// LoadRules reads the Rules from a JSON-file containing an array of Rules.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = errors.Wrap(err, "Error reading rules-file")
+		return nil, err
+	}
+
+	rules := []Rule{}
+	err = json.Unmarshal(data, &rules)
+	if err != nil {
+		err = errors.Wrap(err, "Error parsing rules-file")
+		return nil, err
+	}
+	return rules, nil
+}
+
+// inventoryTotals are the Inventory weights totalled over a period.
+type inventoryTotals struct {
+	total  float64
+	sold   float64
+	waste  float64
+	donate float64
+}
+
+func (t *inventoryTotals) ratio(weight float64) float64 {
+	if t.total <= 0 {
+		return 0
+	}
+	return weight / t.total
+}
+
+// periodStart returns the start of the period containing t, in UTC.
+// Weeks start on Monday, as in report-aggregations.
+func periodStart(period report.Interval, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case report.IntervalHour:
+		return t.Truncate(time.Hour), nil
+	case report.IntervalDay:
+		return day, nil
+	case report.IntervalWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset), nil
+	case report.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("Unsupported period: %s", period)
+}
@@ -0,0 +1,245 @@
+// Command parquet-export exports the Reports, Metrics and Inventory with
+// timestamps in a time-range to Parquet-files, for loading into analytics-tools
+// such as DuckDB or Spark. The schemas of the files are the row-types in the
+// export package (export.ReportRow, export.MetricRow and export.InventoryRow).
+//
+// The DB is configured using the same environment-variables as the server:
+//
+//	parquet-export -start 2018-07-01 -end 2018-08-01 -out ./lake
+//
+// writes reports.parquet, metrics.parquet and inventory.parquet to "./lake".
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TerrexTech/go-commonutils/commonutil"
+	"github.com/bhupeshbhatia/go-report-query/export"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+)
+
+// documentTypes are the document-types which can be exported, in export-order.
+var documentTypes = []string{"reports", "metrics", "inventory"}
+
+func main() {
+	start := flag.String("start", "", "Start of time-range (inclusive), as YYYY-MM-DD or RFC3339")
+	end := flag.String("end", "", "End of time-range (inclusive), as YYYY-MM-DD or RFC3339; defaults to now")
+	out := flag.String("out", ".", "Directory to write the Parquet-files to")
+	types := flag.String("types", strings.Join(documentTypes, ","), "Comma-separated document-types to export")
+	batchSize := flag.Int64("batch-size", 0, "Number of documents fetched from DB at a time")
+	flag.Parse()
+
+	window, err := parseWindow(*start, *end)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, t := range strings.Split(*types, ",") {
+		if !isDocumentType(t) {
+			log.Fatalf("Error: Unknown document-type %s, must be one of %s", t, strings.Join(documentTypes, ", "))
+		}
+	}
+	if err = os.MkdirAll(*out, 0755); err != nil {
+		err = errors.Wrap(err, "Error creating output-directory")
+		log.Fatalln(err)
+	}
+
+	db, err := connectDB()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	filter := report.TimeRange("timestamp", window.StartDate, window.EndDate)
+	for _, t := range strings.Split(*types, ",") {
+		path := filepath.Join(*out, t+".parquet")
+		rows, err := exportDocuments(db, t, filter, *batchSize, path)
+		if err != nil {
+			err = errors.Wrapf(err, "Error exporting %s", t)
+			log.Fatalln(err)
+		}
+		log.Printf("Exported %d %s to %s", rows, t, path)
+	}
+}
+
+func isDocumentType(t string) bool {
+	for _, dt := range documentTypes {
+		if t == dt {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWindow parses the time-range flags as Unix-timestamps.
+// Dates are in UTC, and an end-date includes the whole day.
+func parseWindow(start string, end string) (report.SearchByDate, error) {
+	window := report.SearchByDate{
+		EndDate: time.Now().Unix(),
+	}
+	if start == "" {
+		return window, errors.New("Error: -start is required")
+	}
+	startTime, _, err := parseTime(start)
+	if err != nil {
+		return window, errors.Wrap(err, "Error parsing -start")
+	}
+	window.StartDate = startTime.Unix()
+
+	if end != "" {
+		endTime, isDate, err := parseTime(end)
+		if err != nil {
+			return window, errors.Wrap(err, "Error parsing -end")
+		}
+		if isDate {
+			endTime = endTime.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		window.EndDate = endTime.Unix()
+	}
+	return window, window.Validate()
+}
+
+func parseTime(value string) (t time.Time, isDate bool, err error) {
+	t, err = time.Parse("2006-01-02", value)
+	if err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// connectDB connects to the report-DB configured by environment-variables.
+func connectDB() (*report.DB, error) {
+	err := godotenv.Load()
+	if err != nil {
+		err = errors.Wrap(err,
+			".env file not found, env-vars will be read as set in environment",
+		)
+		log.Println(err)
+	}
+
+	missingVar, err := commonutil.ValidateEnv(
+		"MONGO_HOSTS",
+		"MONGO_DATABASE",
+		"MONGO_COLLECTION",
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Error: Environment variable %s is required but was not found", missingVar,
+		)
+	}
+
+	reportCollection := os.Getenv("MONGO_REPORT_COLLECTION")
+	if reportCollection == "" {
+		reportCollection = "report"
+	}
+	metricCollection := os.Getenv("MONGO_METRIC_COLLECTION")
+	if metricCollection == "" {
+		metricCollection = "metric"
+	}
+
+	db, err := report.NewDB(report.DBIConfig{
+		Hosts:               *commonutil.ParseHosts(os.Getenv("MONGO_HOSTS")),
+		Username:            os.Getenv("MONGO_USERNAME"),
+		Password:            os.Getenv("MONGO_PASSWORD"),
+		TimeoutMilliseconds: 3000,
+		Database:            os.Getenv("MONGO_DATABASE"),
+		Collection:          reportCollection,
+		MetricCollection:    metricCollection,
+		InventoryCollection: os.Getenv("MONGO_COLLECTION"),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Error connecting to Report DB")
+		return nil, err
+	}
+	return db, nil
+}
+
+// exportDocuments streams the documents of documentType matching filter to
+// a Parquet-file at path, and returns the number of rows written. The file is
+// written to a temporary file first, so a failed export doesn't leave
+// a partial file at path.
+func exportDocuments(
+	db report.DBI,
+	documentType string,
+	filter report.Filter,
+	batchSize int64,
+	path string,
+) (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		err = errors.Wrap(err, "Error creating file")
+		return 0, err
+	}
+	defer os.Remove(tmpPath)
+	defer f.Close()
+
+	var pw *export.ParquetWriter
+	switch documentType {
+	case "reports":
+		pw, err = export.NewParquetWriter(f, new(export.ReportRow))
+		if err != nil {
+			return 0, err
+		}
+		reports, errChan := db.StreamReports(ctx, filter, batchSize)
+		for r := range reports {
+			if err = pw.Write(export.NewReportRow(r)); err != nil {
+				return pw.Rows, err
+			}
+		}
+		err = <-errChan
+
+	case "metrics":
+		pw, err = export.NewParquetWriter(f, new(export.MetricRow))
+		if err != nil {
+			return 0, err
+		}
+		metrics, errChan := db.StreamMetrics(ctx, filter, batchSize)
+		for m := range metrics {
+			if err = pw.Write(export.NewMetricRow(m)); err != nil {
+				return pw.Rows, err
+			}
+		}
+		err = <-errChan
+
+	case "inventory":
+		pw, err = export.NewParquetWriter(f, new(export.InventoryRow))
+		if err != nil {
+			return 0, err
+		}
+		inventory, errChan := db.StreamInventory(ctx, filter, batchSize)
+		for item := range inventory {
+			if err = pw.Write(export.NewInventoryRow(item)); err != nil {
+				return pw.Rows, err
+			}
+		}
+		err = <-errChan
+	}
+	if err != nil {
+		return pw.Rows, err
+	}
+
+	if err = pw.Close(); err != nil {
+		return pw.Rows, err
+	}
+	if err = f.Close(); err != nil {
+		err = errors.Wrap(err, "Error closing file")
+		return pw.Rows, err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		err = errors.Wrap(err, "Error moving file into place")
+		return pw.Rows, err
+	}
+	return pw.Rows, nil
+}
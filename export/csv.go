@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+)
+
+// WriteCSV writes tables as CSV, with a header-row of column-names.
+// If there are multiple tables, each is preceded by a row with its name,
+// and tables are separated by an empty row.
+func WriteCSV(w io.Writer, tables []report.Table) error {
+	writer := csv.NewWriter(w)
+	for i, table := range tables {
+		if len(tables) > 1 {
+			if i > 0 {
+				writer.Write([]string{})
+			}
+			writer.Write([]string{table.Name})
+		}
+
+		header := make([]string, len(table.Columns))
+		for j, c := range table.Columns {
+			header[j] = c.Name
+		}
+		writer.Write(header)
+
+		record := make([]string, len(table.Columns))
+		for _, row := range table.Rows {
+			for j, c := range table.Columns {
+				record[j] = csvValue(c, row[j])
+			}
+			writer.Write(record)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		err = errors.Wrap(err, "Error writing CSV")
+		return err
+	}
+	return nil
+}
+
+// csvValue formats a Table-value of column c. Unix-timestamps are
+// formatted as UTC date-times, and zero-timestamps are empty.
+func csvValue(c report.Column, v interface{}) string {
+	if c.Type == "time" {
+		t := unixTime(v)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(timeLayout)
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case bool:
+		return strconv.FormatBool(value)
+	case string:
+		return value
+	}
+	return fmt.Sprint(v)
+}
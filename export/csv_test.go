@@ -0,0 +1,58 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+// testTable is a per-product table with a time-column and a chart.
+func testTable() report.Table {
+	return report.Table{
+		Name: "by_name",
+		Columns: []report.Column{
+			{Name: "name", Type: "string", Key: true},
+			{Name: "items", Type: "integer"},
+			{Name: "waste_weight", Type: "number"},
+			{Name: "expiry_date", Type: "time"},
+		},
+		Rows: [][]interface{}{
+			{"Banana", int64(4), 12.5, int64(1536600000)},
+			{"Apple <Gala>", int64(2), 30.25, int64(0)},
+			{"Banana", int64(1), 0.5, nil},
+		},
+		Chart: "waste_weight",
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, []report.Table{testTable()}); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,items,waste_weight,expiry_date\n" +
+		"Banana,4,12.5,2018-09-10 17:20:00\n" +
+		"Apple <Gala>,2,30.25,\n" +
+		"Banana,1,0.5,\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// Multiple tables are each preceded by their name.
+func TestWriteCSVTables(t *testing.T) {
+	totals := report.Table{
+		Name:    "totals",
+		Columns: []report.Column{{Name: "total", Type: "number"}, {Name: "note", Type: "string"}},
+		Rows:    [][]interface{}{{1.5, "a, b"}},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, []report.Table{totals, totals}); err != nil {
+		t.Fatal(err)
+	}
+	table := "totals\ntotal,note\n1.5,\"a, b\"\n"
+	if want := table + "\n" + table; buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
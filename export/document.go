@@ -0,0 +1,178 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+// maxDocumentRows is the maximum number of rows of each table in a Document,
+// which summarizes the tables. CSV and XLSX exports contain all rows.
+const maxDocumentRows = 50
+
+// maxChartBars is the maximum number of bars in bar-charts,
+// which show the rows with largest values.
+const maxChartBars = 12
+
+// Document is a report and its metadata, exported in any Format.
+// Title, Customer and Period are only shown in HTML and PDF documents.
+type Document struct {
+	Title    string
+	Customer string
+	// Period is the report's date-range. Reports calculated
+	// at a point in time only have an EndDate.
+	Period report.SearchByDate
+	// PreviousPeriod is the date-range compared against, for comparisons.
+	PreviousPeriod report.SearchByDate
+	GeneratedAt    time.Time
+	Tables         []report.Table
+}
+
+// periodText describes a date-range, such as "2018-07-01 to 2018-07-07".
+func periodText(period report.SearchByDate) string {
+	end := unixTime(period.EndDate)
+	if end.IsZero() {
+		return ""
+	}
+	start := unixTime(period.StartDate)
+	if start.IsZero() {
+		return "As of " + end.Format(timeLayout) + " UTC"
+	}
+	return start.Format(timeLayout) + " to " + end.Format(timeLayout) + " UTC"
+}
+
+// displayValue formats a Table-value of column c for documents.
+// Numbers are rounded to two decimals.
+func displayValue(c report.Column, v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', 2, 64)
+	}
+	return csvValue(c, v)
+}
+
+// documentRows returns the rows of table shown in documents.
+func documentRows(table report.Table) [][]string {
+	rows := [][]string{}
+	for i, row := range table.Rows {
+		if i == maxDocumentRows {
+			break
+		}
+		values := make([]string, len(table.Columns))
+		for j, c := range table.Columns {
+			values[j] = displayValue(c, row[j])
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+// chart is a Table's Chart-column plotted by its first key-column. Time-series
+// are line-charts, while other tables are bar-charts of their largest values.
+type chart struct {
+	Title  string
+	Line   bool
+	Labels []string
+	Values []float64
+}
+
+// max returns the largest value, and min returns the smallest value or
+// zero, which are the limits of chart's value-axis.
+func (c *chart) max() float64 {
+	max := 0.0
+	for _, v := range c.Values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+func (c *chart) min() float64 {
+	min := 0.0
+	for _, v := range c.Values {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// tableChart creates the chart for table, or returns nil if
+// table has no Chart-column or no values to chart. Rows are labelled by
+// their first time key-column, such as a trend's bucket, or else by their
+// first key-column. Values of rows with same label are summed.
+func tableChart(table report.Table) *chart {
+	if table.Chart == "" {
+		return nil
+	}
+	labelCol, valueCol := -1, -1
+	for i, c := range table.Columns {
+		if c.Key && (labelCol == -1 || (c.Type == "time" && table.Columns[labelCol].Type != "time")) {
+			labelCol = i
+		}
+		if c.Name == table.Chart && (c.Type == "number" || c.Type == "integer") {
+			valueCol = i
+		}
+	}
+	if labelCol == -1 || valueCol == -1 {
+		return nil
+	}
+
+	labelType := table.Columns[labelCol].Type
+	totals := map[interface{}]float64{}
+	keys := []interface{}{}
+	for _, row := range table.Rows {
+		key := row[labelCol]
+		if _, ok := totals[key]; !ok {
+			keys = append(keys, key)
+		}
+		switch v := row[valueCol].(type) {
+		case float64:
+			totals[key] += v
+		case int64:
+			totals[key] += float64(v)
+		}
+	}
+
+	c := &chart{
+		Title: table.Chart + " by " + table.Columns[labelCol].Name,
+		Line:  labelType == "time",
+	}
+	if c.Line {
+		sort.Slice(keys, func(i, j int) bool {
+			return unixTime(keys[i]).Before(unixTime(keys[j]))
+		})
+	} else {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return totals[keys[i]] > totals[keys[j]]
+		})
+		if len(keys) > maxChartBars {
+			keys = keys[:maxChartBars]
+		}
+	}
+
+	layout := "2006-01-02"
+	for _, key := range keys {
+		if t := unixTime(key); c.Line && (t.Hour() != 0 || t.Minute() != 0) {
+			layout = "01-02 15:04"
+		}
+	}
+	for _, key := range keys {
+		label := fmt.Sprint(key)
+		if c.Line {
+			label = unixTime(key).Format(layout)
+		} else if key == nil || label == "" {
+			label = "(none)"
+		}
+		c.Labels = append(c.Labels, label)
+		c.Values = append(c.Values, totals[key])
+	}
+
+	if len(c.Values) == 0 || (c.Line && len(c.Values) < 2) {
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+func TestPeriodText(t *testing.T) {
+	tests := []struct {
+		period report.SearchByDate
+		want   string
+	}{
+		{report.SearchByDate{}, ""},
+		{report.SearchByDate{EndDate: 1536600000}, "As of 2018-09-10 17:20:00 UTC"},
+		{
+			report.SearchByDate{StartDate: 1536000000, EndDate: 1536600000},
+			"2018-09-03 18:40:00 to 2018-09-10 17:20:00 UTC",
+		},
+	}
+	for _, test := range tests {
+		if got := periodText(test.period); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.period, got, test.want)
+		}
+	}
+}
+
+func TestDocumentRows(t *testing.T) {
+	got := documentRows(testTable())
+	want := [][]string{
+		{"Banana", "4", "12.50", "2018-09-10 17:20:00"},
+		{"Apple <Gala>", "2", "30.25", ""},
+		{"Banana", "1", "0.50", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	table := testTable()
+	for len(table.Rows) <= maxDocumentRows {
+		table.Rows = append(table.Rows, table.Rows[0])
+	}
+	if got := documentRows(table); len(got) != maxDocumentRows {
+		t.Errorf("got %d rows, want %d", len(got), maxDocumentRows)
+	}
+}
+
+func TestTableChart(t *testing.T) {
+	// Bars are summed by label, largest first
+	got := tableChart(testTable())
+	want := &chart{
+		Title:  "waste_weight by name",
+		Labels: []string{"Apple <Gala>", "Banana"},
+		Values: []float64{30.25, 13},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Time-series are labelled by their time-column, in time-order
+	trend := report.Table{
+		Columns: []report.Column{
+			{Name: "name", Type: "string", Key: true},
+			{Name: "bucket", Type: "time", Key: true},
+			{Name: "total_weight", Type: "number"},
+		},
+		Rows: [][]interface{}{
+			{"Banana", int64(1536624000), 3.0},
+			{"Banana", int64(1536537600), 2.0},
+			{"Apple", int64(1536537600), 1.0},
+		},
+		Chart: "total_weight",
+	}
+	got = tableChart(trend)
+	want = &chart{
+		Title:  "total_weight by bucket",
+		Line:   true,
+		Labels: []string{"2018-09-10", "2018-09-11"},
+		Values: []float64{3, 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Nothing to chart
+	table := testTable()
+	table.Chart = "name"
+	if c := tableChart(table); c != nil {
+		t.Errorf("got %+v for a non-numeric chart-column", c)
+	}
+	trend.Rows = trend.Rows[:1]
+	if c := tableChart(trend); c != nil {
+		t.Errorf("got %+v for a single point", c)
+	}
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Formats which results can be exported as.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+	// FormatNDJSON is newline-delimited JSON, with a JSON-object per line.
+	FormatNDJSON = "ndjson"
+)
+
+// timeLayout is the layout for Unix-timestamps in CSV-exports. Times are in UTC.
+const timeLayout = "2006-01-02 15:04:05"
+
+// mediaTypes are the media-types for each Format.
+var mediaTypes = map[string]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatHTML:   "text/html",
+	FormatPDF:    "application/pdf",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// Negotiate returns the Format requested by the "format" query-param, or else
+// by the Accept-header. JSON is returned if neither requests a supported Format.
+// An error is returned if the "format" query-param is not a supported Format.
+func Negotiate(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, ok := mediaTypes[format]; !ok {
+			return "", fmt.Errorf("Unsupported format: %s", format)
+		}
+		return format, nil
+	}
+
+	type accepted struct {
+		format string
+		q      float64
+	}
+	formats := []accepted{}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		for format, t := range mediaTypes {
+			if t == mediaType && q > 0 {
+				formats = append(formats, accepted{format, q})
+			}
+		}
+	}
+	if len(formats) == 0 {
+		return FormatJSON, nil
+	}
+	sort.SliceStable(formats, func(i, j int) bool {
+		return formats[i].q > formats[j].q
+	})
+	return formats[0].format, nil
+}
+
+// Write writes doc in format as a file named filename, with the extension for
+// format added. CSV and XLSX are downloaded, while HTML and PDF are displayed inline.
+func Write(w http.ResponseWriter, format string, filename string, doc Document) error {
+	disposition := "attachment"
+	if format == FormatHTML || format == FormatPDF {
+		disposition = "inline"
+	}
+	contentType := mediaTypes[format]
+	if format == FormatCSV || format == FormatHTML {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf(`%s; filename="%s.%s"`, disposition, filename, format),
+	)
+
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, doc.Tables)
+	case FormatXLSX:
+		return WriteXLSX(w, doc.Tables)
+	case FormatHTML:
+		return WriteHTML(w, doc)
+	case FormatPDF:
+		return WritePDF(w, doc)
+	case FormatNDJSON:
+		return WriteNDJSON(w, doc.Tables)
+	}
+	return errors.New("Unsupported export-format: " + format)
+}
+
+// unixTime converts a Unix-timestamp value to UTC time.
+// A zero-time is returned for zero or non-integer values.
+func unixTime(v interface{}) time.Time {
+	seconds, ok := v.(int64)
+	if !ok || seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		query  string
+		accept string
+		want   string
+	}{
+		{"", "", FormatJSON},
+		{"?format=CSV", "application/pdf", FormatCSV},
+		{"", "text/html", FormatHTML},
+		{"", "text/csv;q=0.5, application/pdf", FormatPDF},
+		{"", "application/pdf;q=0, text/csv", FormatCSV},
+		{"", "image/png", FormatJSON},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/load-table"+test.query, nil)
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		got, err := Negotiate(r)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.query, test.accept, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s %s: got %s, want %s", test.query, test.accept, got, test.want)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/load-table?format=docx", nil)
+	if _, err := Negotiate(r); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	doc := Document{
+		Title:       "Inventory",
+		GeneratedAt: time.Unix(1536600000, 0),
+		Tables:      []report.Table{testTable()},
+	}
+	tests := []struct {
+		format      string
+		contentType string
+		disposition string
+	}{
+		{FormatCSV, "text/csv; charset=utf-8", `attachment; filename="inventory.csv"`},
+		{FormatXLSX, mediaTypes[FormatXLSX], `attachment; filename="inventory.xlsx"`},
+		{FormatHTML, "text/html; charset=utf-8", `inline; filename="inventory.html"`},
+		{FormatPDF, "application/pdf", `inline; filename="inventory.pdf"`},
+		{FormatNDJSON, "application/x-ndjson", `attachment; filename="inventory.ndjson"`},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		if err := Write(w, test.format, "inventory", doc); err != nil {
+			t.Errorf("%s: %v", test.format, err)
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("%s: got Content-Type %q, want %q", test.format, got, test.contentType)
+		}
+		if got := w.Header().Get("Content-Disposition"); got != test.disposition {
+			t.Errorf("%s: got Content-Disposition %q, want %q", test.format, got, test.disposition)
+		}
+		if w.Body.Len() == 0 {
+			t.Errorf("%s: empty body", test.format)
+		}
+	}
+
+	if err := Write(httptest.NewRecorder(), "docx", "inventory", doc); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,237 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+)
+
+// Dimensions of SVG-charts, in pixels.
+const (
+	svgWidth      = 640
+	svgBarHeight  = 20
+	svgLineHeight = 220
+	svgLabelWidth = 150
+	svgPadding    = 30
+)
+
+// documentTemplate is the HTML-template for Documents.
+// It is styled for printing, with each table starting on a new page.
+var documentTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: Helvetica, Arial, sans-serif; font-size: 12px; color: #222; margin: 24px; }
+  header { border-bottom: 2px solid #2e7d32; margin-bottom: 16px; }
+  header h1 { margin: 0 0 8px; font-size: 22px; }
+  header dl { display: grid; grid-template-columns: max-content auto; gap: 2px 12px; margin: 0 0 12px; }
+  header dt { font-weight: bold; }
+  header dd { margin: 0; }
+  section { margin-bottom: 24px; }
+  section + section { page-break-before: always; }
+  h2 { font-size: 16px; margin: 0 0 8px; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { border: 1px solid #ccc; padding: 3px 6px; text-align: left; white-space: nowrap; }
+  th { background: #eee; }
+  td.number { text-align: right; }
+  tr:nth-child(even) td { background: #fafafa; }
+  .chart { margin: 8px 0 12px; }
+  .note { color: #666; font-style: italic; margin: 4px 0; }
+</style>
+</head>
+<body>
+<header>
+  <h1>{{.Title}}</h1>
+  <dl>
+    {{if .Customer}}<dt>Customer</dt><dd>{{.Customer}}</dd>{{end}}
+    {{if .Period}}<dt>Period</dt><dd>{{.Period}}</dd>{{end}}
+    {{if .PreviousPeriod}}<dt>Compared to</dt><dd>{{.PreviousPeriod}}</dd>{{end}}
+    <dt>Generated</dt><dd>{{.GeneratedAt}}</dd>
+  </dl>
+</header>
+{{range .Tables}}
+<section>
+  <h2>{{.Name}}</h2>
+  {{if .Chart}}<div class="chart">{{.Chart}}</div>{{end}}
+  <table>
+    <thead><tr>{{range .Columns}}<th>{{.Name}}</th>{{end}}</tr></thead>
+    <tbody>
+    {{range .Rows}}<tr>{{range .}}<td{{if .Number}} class="number"{{end}}>{{.Text}}</td>{{end}}</tr>
+    {{else}}<tr><td colspan="{{len .Columns}}">No data</td></tr>
+    {{end}}
+    </tbody>
+  </table>
+  {{if .Truncated}}<p class="note">Showing first {{len .Rows}} of {{.TotalRows}} rows.</p>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+// htmlDocument is the data for documentTemplate.
+type htmlDocument struct {
+	Title          string
+	Customer       string
+	Period         string
+	PreviousPeriod string
+	GeneratedAt    string
+	Tables         []htmlTable
+}
+
+type htmlTable struct {
+	Name      string
+	Chart     template.HTML
+	Columns   []report.Column
+	Rows      [][]htmlCell
+	TotalRows int
+	Truncated bool
+}
+
+type htmlCell struct {
+	Text   string
+	Number bool
+}
+
+// WriteHTML writes doc as a printable HTML-document,
+// with a section for each table and SVG-charts.
+func WriteHTML(w io.Writer, doc Document) error {
+	data := htmlDocument{
+		Title:          doc.Title,
+		Customer:       doc.Customer,
+		Period:         periodText(doc.Period),
+		PreviousPeriod: periodText(doc.PreviousPeriod),
+		GeneratedAt:    doc.GeneratedAt.UTC().Format(timeLayout) + " UTC",
+	}
+	for _, table := range doc.Tables {
+		t := htmlTable{
+			Name:      table.Name,
+			Columns:   table.Columns,
+			TotalRows: len(table.Rows),
+			Truncated: len(table.Rows) > maxDocumentRows,
+		}
+		if c := tableChart(table); c != nil {
+			t.Chart = svgChart(c)
+		}
+		for _, row := range documentRows(table) {
+			cells := make([]htmlCell, len(row))
+			for i, text := range row {
+				colType := table.Columns[i].Type
+				cells[i] = htmlCell{
+					Text:   text,
+					Number: colType == "number" || colType == "integer",
+				}
+			}
+			t.Rows = append(t.Rows, cells)
+		}
+		data.Tables = append(data.Tables, t)
+	}
+
+	err := documentTemplate.Execute(w, data)
+	if err != nil {
+		err = errors.Wrap(err, "Error writing HTML-document")
+		return err
+	}
+	return nil
+}
+
+// svgChart draws chart as inline SVG. Labels are escaped,
+// so the result is safe to include in HTML.
+func svgChart(c *chart) template.HTML {
+	s := &strings.Builder{}
+	if c.Line {
+		svgLineChart(s, c)
+	} else {
+		svgBarChart(s, c)
+	}
+	return template.HTML(s.String())
+}
+
+func svgBarChart(s *strings.Builder, c *chart) {
+	height := len(c.Values)*svgBarHeight + svgPadding
+	fmt.Fprintf(
+		s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-size="11">`,
+		svgWidth, height,
+	)
+	fmt.Fprintf(s, `<text x="0" y="14" font-weight="bold">%s</text>`, template.HTMLEscapeString(c.Title))
+
+	// Bars start at zero, which is offset for negative values
+	max, min := c.max(), c.min()
+	scale := 0.0
+	if max-min > 0 {
+		scale = float64(svgWidth-svgLabelWidth-80) / (max - min)
+	}
+	zero := float64(svgLabelWidth) - min*scale
+	for i, v := range c.Values {
+		y := svgPadding - 6 + i*svgBarHeight
+		x, width := zero, v*scale
+		if v < 0 {
+			x, width = zero+width, -width
+		}
+		fmt.Fprintf(
+			s, `<text x="%d" y="%d" text-anchor="end">%s</text>`,
+			svgLabelWidth-6, y+13, template.HTMLEscapeString(c.Labels[i]),
+		)
+		fmt.Fprintf(
+			s, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="#2e7d32"></rect>`,
+			x, y+2, width, svgBarHeight-6,
+		)
+		fmt.Fprintf(s, `<text x="%.1f" y="%d">%.2f</text>`, x+width+4, y+13, v)
+	}
+	s.WriteString(`</svg>`)
+}
+
+func svgLineChart(s *strings.Builder, c *chart) {
+	fmt.Fprintf(
+		s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-size="11">`,
+		svgWidth, svgLineHeight,
+	)
+	fmt.Fprintf(s, `<text x="0" y="14" font-weight="bold">%s</text>`, template.HTMLEscapeString(c.Title))
+
+	left, right := 60.0, float64(svgWidth-20)
+	top, bottom := float64(svgPadding), float64(svgLineHeight-svgPadding)
+	max, min := c.max(), c.min()
+	if max == min {
+		max = min + 1
+	}
+	x := func(i int) float64 {
+		return left + float64(i)*(right-left)/float64(len(c.Values)-1)
+	}
+	y := func(v float64) float64 {
+		return bottom - (v-min)/(max-min)*(bottom-top)
+	}
+
+	// Axes, with value-labels at top and bottom
+	fmt.Fprintf(
+		s, `<path d="M%.1f %.1f V%.1f H%.1f" fill="none" stroke="#999"></path>`,
+		left, top, bottom, right,
+	)
+	fmt.Fprintf(s, `<text x="%.1f" y="%.1f" text-anchor="end">%.2f</text>`, left-4, top+4, max)
+	fmt.Fprintf(s, `<text x="%.1f" y="%.1f" text-anchor="end">%.2f</text>`, left-4, bottom, min)
+	fmt.Fprintf(s, `<text x="%.1f" y="%.1f">%s</text>`, left, bottom+16, template.HTMLEscapeString(c.Labels[0]))
+	fmt.Fprintf(
+		s, `<text x="%.1f" y="%.1f" text-anchor="end">%s</text>`,
+		right, bottom+16, template.HTMLEscapeString(c.Labels[len(c.Labels)-1]),
+	)
+
+	points := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(v))
+	}
+	fmt.Fprintf(
+		s, `<polyline points="%s" fill="none" stroke="#2e7d32" stroke-width="2"></polyline>`,
+		strings.Join(points, " "),
+	)
+	for i, v := range c.Values {
+		fmt.Fprintf(
+			s, `<circle cx="%.1f" cy="%.1f" r="2.5" fill="#2e7d32"><title>%s: %.2f</title></circle>`,
+			x(i), y(v), template.HTMLEscapeString(c.Labels[i]), v,
+		)
+	}
+	s.WriteString(`</svg>`)
+}
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+func TestWriteHTML(t *testing.T) {
+	doc := Document{
+		Title:       "Waste <report>",
+		Customer:    "Fresh Foods",
+		Period:      report.SearchByDate{StartDate: 1536000000, EndDate: 1536600000},
+		GeneratedAt: time.Unix(1536600000, 0),
+		Tables:      []report.Table{testTable(), {Name: "empty", Columns: testTable().Columns}},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteHTML(buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+
+	for _, want := range []string{
+		"<h1>Waste &lt;report&gt;</h1>",
+		"<dd>Fresh Foods</dd>",
+		"<dd>2018-09-03 18:40:00 to 2018-09-10 17:20:00 UTC</dd>",
+		"<td>Apple &lt;Gala&gt;</td>",
+		`<td class="number">30.25</td>`,
+		"<svg",
+		`<td colspan="4">No data</td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(html, "Compared to") {
+		t.Error("unexpected comparison-period")
+	}
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+)
+
+// WriteNDJSON writes the rows of tables as newline-delimited JSON-objects,
+// keyed by column-name. If there are multiple tables, each object has a
+// "table" key with its table's name.
+func WriteNDJSON(w io.Writer, tables []report.Table) error {
+	encoder := json.NewEncoder(w)
+	for _, table := range tables {
+		for _, row := range table.Rows {
+			object := make(map[string]interface{}, len(table.Columns)+1)
+			if len(tables) > 1 {
+				object["table"] = table.Name
+			}
+			for i, c := range table.Columns {
+				object[c.Name] = row[i]
+			}
+			err := encoder.Encode(object)
+			if err != nil {
+				err = errors.Wrap(err, "Error writing NDJSON")
+				return err
+			}
+		}
+	}
+	return nil
+}
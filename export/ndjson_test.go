@@ -0,0 +1,37 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	table := testTable()
+	table.Rows = table.Rows[:1]
+	totals := report.Table{
+		Name:    "totals",
+		Columns: []report.Column{{Name: "total", Type: "number"}},
+		Rows:    [][]interface{}{{1.5}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteNDJSON(buf, []report.Table{table}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"expiry_date":1536600000,"items":4,"name":"Banana","waste_weight":12.5}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteNDJSON(buf, []report.Table{table, totals}); err != nil {
+		t.Fatal(err)
+	}
+	want = `{"expiry_date":1536600000,"items":4,"name":"Banana","table":"by_name","waste_weight":12.5}` + "\n" +
+		`{"table":"totals","total":1.5}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"io"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetParallelism is the number of goroutines used to encode each row-group.
+const parquetParallelism = 4
+
+// The row-types below are the schemas of Parquet-exports, one per document-type.
+// Column-names are the documents' BSON-field names, and the schemas only change
+// by adding columns. IDs and UUIDs are UTF8-strings, and Unix-timestamps are
+// TIMESTAMP(MILLIS, UTC) columns. Fields which are not set in a document,
+// including zero-timestamps and UUIDs, are nulls.
+
+// ReportRow is the Parquet-schema of Reports. Params and Result of
+// report-snapshots are JSON-columns.
+type ReportRow struct {
+	ID               *string `parquet:"name=_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	ReportID         *string `parquet:"name=report_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	ItemID           *string `parquet:"name=item_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	RsCustomerID     *string `parquet:"name=rs_customer_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Timestamp        *int64  `parquet:"name=timestamp, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	ReportType       *string `parquet:"name=report_type, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Version          int32   `parquet:"name=version, type=INT32"`
+	AggregateID      int32   `parquet:"name=aggregate_id, type=INT32"`
+	AggregateVersion int64   `parquet:"name=aggregate_version, type=INT64"`
+	Name             *string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	StartDate        *int64  `parquet:"name=start_date, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	EndDate          *int64  `parquet:"name=end_date, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	Params           *string `parquet:"name=params, type=BYTE_ARRAY, convertedtype=JSON, repetitiontype=OPTIONAL"`
+	Result           *string `parquet:"name=result, type=BYTE_ARRAY, convertedtype=JSON, repetitiontype=OPTIONAL"`
+}
+
+// MetricRow is the Parquet-schema of Metrics.
+type MetricRow struct {
+	ID               *string `parquet:"name=_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	ItemID           *string `parquet:"name=item_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	DeviceID         *string `parquet:"name=device_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Timestamp        *int64  `parquet:"name=timestamp, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	TempIn           float64 `parquet:"name=temp_in, type=DOUBLE"`
+	Humidity         float64 `parquet:"name=humidity, type=DOUBLE"`
+	Ethylene         float64 `parquet:"name=ethylene, type=DOUBLE"`
+	CarbonDi         float64 `parquet:"name=carbon_di, type=DOUBLE"`
+	Version          int32   `parquet:"name=version, type=INT32"`
+	AggregateID      int32   `parquet:"name=aggregate_id, type=INT32"`
+	AggregateVersion int64   `parquet:"name=aggregate_version, type=INT64"`
+}
+
+// InventoryRow is the Parquet-schema of Inventory.
+type InventoryRow struct {
+	ID               *string `parquet:"name=_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	ItemID           *string `parquet:"name=item_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	UPC              int64   `parquet:"name=upc, type=INT64"`
+	SKU              int64   `parquet:"name=sku, type=INT64"`
+	Name             *string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Origin           *string `parquet:"name=origin, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	DeviceID         *string `parquet:"name=device_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	TotalWeight      float64 `parquet:"name=total_weight, type=DOUBLE"`
+	Price            float64 `parquet:"name=price, type=DOUBLE"`
+	Location         *string `parquet:"name=location, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	DateArrived      *int64  `parquet:"name=date_arrived, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	ExpiryDate       *int64  `parquet:"name=expiry_date, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	Timestamp        *int64  `parquet:"name=timestamp, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	RsCustomerID     *string `parquet:"name=rs_customer_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	WasteWeight      float64 `parquet:"name=waste_weight, type=DOUBLE"`
+	DonateWeight     float64 `parquet:"name=donate_weight, type=DOUBLE"`
+	AggregateVersion int64   `parquet:"name=aggregate_version, type=INT64"`
+	AggregateID      int32   `parquet:"name=aggregate_id, type=INT32"`
+	DateSold         *int64  `parquet:"name=date_sold, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+	SalePrice        float64 `parquet:"name=sale_price, type=DOUBLE"`
+	SoldWeight       float64 `parquet:"name=sold_weight, type=DOUBLE"`
+	ProdQuantity     int64   `parquet:"name=prod_quantity, type=INT64"`
+}
+
+// NewReportRow converts r to its Parquet-row.
+func NewReportRow(r report.Report) ReportRow {
+	return ReportRow{
+		ID:               parquetObjectID(r.ID),
+		ReportID:         parquetUUID(r.ReportID),
+		ItemID:           parquetUUID(r.ItemID),
+		RsCustomerID:     parquetUUID(r.RsCustomerID),
+		Timestamp:        parquetTimestamp(r.Timestamp),
+		ReportType:       parquetString(r.ReportType),
+		Version:          int32(r.Version),
+		AggregateID:      int32(r.AggregateID),
+		AggregateVersion: r.AggregateVersion,
+		Name:             parquetString(r.Name),
+		StartDate:        parquetTimestamp(r.StartDate),
+		EndDate:          parquetTimestamp(r.EndDate),
+		Params:           parquetString(string(r.Params)),
+		Result:           parquetString(string(r.Result)),
+	}
+}
+
+// NewMetricRow converts m to its Parquet-row.
+func NewMetricRow(m report.Metric) MetricRow {
+	return MetricRow{
+		ID:               parquetObjectID(m.ID),
+		ItemID:           parquetUUID(m.ItemID),
+		DeviceID:         parquetUUID(m.DeviceID),
+		Timestamp:        parquetTimestamp(m.Timestamp),
+		TempIn:           m.TempIn,
+		Humidity:         m.Humidity,
+		Ethylene:         m.Ethylene,
+		CarbonDi:         m.CarbonDi,
+		Version:          int32(m.Version),
+		AggregateID:      int32(m.AggregateID),
+		AggregateVersion: m.AggregateVersion,
+	}
+}
+
+// NewInventoryRow converts item to its Parquet-row.
+func NewInventoryRow(item report.Inventory) InventoryRow {
+	return InventoryRow{
+		ID:               parquetObjectID(item.ID),
+		ItemID:           parquetUUID(item.ItemID),
+		UPC:              item.UPC,
+		SKU:              item.SKU,
+		Name:             parquetString(item.Name),
+		Origin:           parquetString(item.Origin),
+		DeviceID:         parquetUUID(item.DeviceID),
+		TotalWeight:      item.TotalWeight,
+		Price:            item.Price,
+		Location:         parquetString(item.Location),
+		DateArrived:      parquetTimestamp(item.DateArrived),
+		ExpiryDate:       parquetTimestamp(item.ExpiryDate),
+		Timestamp:        parquetTimestamp(item.Timestamp),
+		RsCustomerID:     parquetUUID(item.RsCustomerID),
+		WasteWeight:      item.WasteWeight,
+		DonateWeight:     item.DonateWeight,
+		AggregateVersion: item.AggregateVersion,
+		AggregateID:      int32(item.AggregateID),
+		DateSold:         parquetTimestamp(item.DateSold),
+		SalePrice:        item.SalePrice,
+		SoldWeight:       item.SoldWeight,
+		ProdQuantity:     item.ProdQuantity,
+	}
+}
+
+func parquetString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func parquetUUID(id uuuid.UUID) *string {
+	if id.String() == (uuuid.UUID{}).String() {
+		return nil
+	}
+	return parquetString(id.String())
+}
+
+func parquetObjectID(id objectid.ObjectID) *string {
+	if id.IsZero() {
+		return nil
+	}
+	return parquetString(id.Hex())
+}
+
+// parquetTimestamp converts a Unix-timestamp in seconds to milliseconds.
+func parquetTimestamp(seconds int64) *int64 {
+	if seconds == 0 {
+		return nil
+	}
+	millis := seconds * 1000
+	return &millis
+}
+
+// ParquetWriter writes rows to a Snappy-compressed Parquet-file.
+// All rows must be of the row-type the ParquetWriter was created with.
+type ParquetWriter struct {
+	pw *writer.ParquetWriter
+	// Rows is the number of rows written.
+	Rows int64
+}
+
+// NewParquetWriter creates a ParquetWriter writing to w, with the schema of
+// row, which is a pointer to ReportRow, MetricRow or InventoryRow.
+func NewParquetWriter(w io.Writer, row interface{}) (*ParquetWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, row, parquetParallelism)
+	if err != nil {
+		err = errors.Wrap(err, "Error creating Parquet-writer")
+		return nil, err
+	}
+	return &ParquetWriter{
+		pw: pw,
+	}, nil
+}
+
+// Write writes a row.
+func (p *ParquetWriter) Write(row interface{}) error {
+	err := p.pw.Write(row)
+	if err != nil {
+		err = errors.Wrap(err, "Error writing Parquet-row")
+		return err
+	}
+	p.Rows++
+	return nil
+}
+
+// Close writes the remaining rows and the file-footer.
+// This does not close the underlying writer.
+func (p *ParquetWriter) Close() error {
+	err := p.pw.WriteStop()
+	if err != nil {
+		err = errors.Wrap(err, "Error writing Parquet-footer")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+// parquetColumns describes the columns of a Parquet row-type, in order,
+// as "name TYPE [annotation] [OPTIONAL]".
+func parquetColumns(t *testing.T, row interface{}) []string {
+	rowType := reflect.TypeOf(row)
+	columns := []string{}
+	for i := 0; i < rowType.NumField(); i++ {
+		tags := map[string]string{}
+		for _, tag := range strings.Split(rowType.Field(i).Tag.Get("parquet"), ",") {
+			kv := strings.SplitN(strings.TrimSpace(tag), "=", 2)
+			if len(kv) != 2 {
+				t.Fatalf("%s.%s: invalid parquet-tag %q", rowType.Name(), rowType.Field(i).Name, tag)
+			}
+			tags[kv[0]] = kv[1]
+		}
+
+		column := []string{tags["name"], tags["type"]}
+		if tags["convertedtype"] != "" {
+			column = append(column, tags["convertedtype"])
+		}
+		if tags["logicaltype"] != "" {
+			column = append(column, tags["logicaltype"]+"("+tags["logicaltype.unit"]+")")
+		}
+		if tags["repetitiontype"] != "" {
+			column = append(column, tags["repetitiontype"])
+		}
+		columns = append(columns, strings.Join(column, " "))
+	}
+	return columns
+}
+
+// The schemas of Parquet-exports are pinned, since they are read by
+// other tools. Columns may only be added, at the end of a schema.
+func TestParquetSchemas(t *testing.T) {
+	testCases := []struct {
+		row  interface{}
+		want []string
+	}{
+		{
+			row: ReportRow{},
+			want: []string{
+				"_id BYTE_ARRAY UTF8 OPTIONAL",
+				"report_id BYTE_ARRAY UTF8 OPTIONAL",
+				"item_id BYTE_ARRAY UTF8 OPTIONAL",
+				"rs_customer_id BYTE_ARRAY UTF8 OPTIONAL",
+				"timestamp INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"report_type BYTE_ARRAY UTF8 OPTIONAL",
+				"version INT32",
+				"aggregate_id INT32",
+				"aggregate_version INT64",
+				"name BYTE_ARRAY UTF8 OPTIONAL",
+				"start_date INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"end_date INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"params BYTE_ARRAY JSON OPTIONAL",
+				"result BYTE_ARRAY JSON OPTIONAL",
+			},
+		},
+		{
+			row: MetricRow{},
+			want: []string{
+				"_id BYTE_ARRAY UTF8 OPTIONAL",
+				"item_id BYTE_ARRAY UTF8 OPTIONAL",
+				"device_id BYTE_ARRAY UTF8 OPTIONAL",
+				"timestamp INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"temp_in DOUBLE",
+				"humidity DOUBLE",
+				"ethylene DOUBLE",
+				"carbon_di DOUBLE",
+				"version INT32",
+				"aggregate_id INT32",
+				"aggregate_version INT64",
+			},
+		},
+		{
+			row: InventoryRow{},
+			want: []string{
+				"_id BYTE_ARRAY UTF8 OPTIONAL",
+				"item_id BYTE_ARRAY UTF8 OPTIONAL",
+				"upc INT64",
+				"sku INT64",
+				"name BYTE_ARRAY UTF8 OPTIONAL",
+				"origin BYTE_ARRAY UTF8 OPTIONAL",
+				"device_id BYTE_ARRAY UTF8 OPTIONAL",
+				"total_weight DOUBLE",
+				"price DOUBLE",
+				"location BYTE_ARRAY UTF8 OPTIONAL",
+				"date_arrived INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"expiry_date INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"timestamp INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"rs_customer_id BYTE_ARRAY UTF8 OPTIONAL",
+				"waste_weight DOUBLE",
+				"donate_weight DOUBLE",
+				"aggregate_version INT64",
+				"aggregate_id INT32",
+				"date_sold INT64 TIMESTAMP(MILLIS) OPTIONAL",
+				"sale_price DOUBLE",
+				"sold_weight DOUBLE",
+				"prod_quantity INT64",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		got := parquetColumns(t, tc.row)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%T: got columns\n%s\nwant\n%s",
+				tc.row, strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+	}
+}
+
+func TestNewMetricRow(t *testing.T) {
+	row := NewMetricRow(report.Metric{Timestamp: 1536600000, Humidity: 90})
+	if row.Timestamp == nil || *row.Timestamp != 1536600000000 {
+		t.Errorf("got timestamp %v, want milliseconds", row.Timestamp)
+	}
+	// Unset IDs are nulls
+	if row.ID != nil || row.ItemID != nil || row.DeviceID != nil {
+		t.Errorf("got IDs %v, %v, %v, want nulls", row.ID, row.ItemID, row.DeviceID)
+	}
+	if row.Humidity != 90 {
+		t.Errorf("got humidity %v, want 90", row.Humidity)
+	}
+}
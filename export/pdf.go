@@ -0,0 +1,226 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/pkg/errors"
+)
+
+// Layout of PDF-documents, in millimetres on landscape A4-pages.
+const (
+	pdfMargin      = 12.0
+	pdfRowHeight   = 5.0
+	pdfBarHeight   = 5.0
+	pdfLineHeight  = 50.0
+	pdfLabelWidth  = 40.0
+	pdfChartWidth  = 180.0
+	pdfMinColWidth = 12.0
+)
+
+// pdfGreen is the color of chart-bars and lines, same as in HTML-documents.
+var pdfGreen = [3]int{46, 125, 50}
+
+// WritePDF writes doc as a PDF-document, with a section for each table and charts.
+func WritePDF(w io.Writer, doc Document) error {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AliasNbPages("")
+	// Core fonts use cp1252, so UTF-8 text (such as "°C") is translated
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(doc.Title, true)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 2)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(
+			0, 4, tr(fmt.Sprintf("%s - page %d of {nb}", doc.Title, pdf.PageNo())),
+			"", 0, "C", false, 0, "",
+		)
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, tr(doc.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	header := [][2]string{
+		{"Customer", doc.Customer},
+		{"Period", periodText(doc.Period)},
+		{"Compared to", periodText(doc.PreviousPeriod)},
+		{"Generated", doc.GeneratedAt.UTC().Format(timeLayout) + " UTC"},
+	}
+	for _, h := range header {
+		if h[1] == "" {
+			continue
+		}
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(30, 6, h[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, tr(h[1]), "", 1, "L", false, 0, "")
+	}
+	pageWidth, pageHeight := pdf.GetPageSize()
+	pdf.SetDrawColor(pdfGreen[0], pdfGreen[1], pdfGreen[2])
+	pdf.SetLineWidth(0.6)
+	pdf.Line(pdfMargin, pdf.GetY()+2, pageWidth-pdfMargin, pdf.GetY()+2)
+	pdf.SetLineWidth(0.2)
+	pdf.Ln(6)
+
+	for i, table := range doc.Tables {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.CellFormat(0, 8, tr(table.Name), "", 1, "L", false, 0, "")
+
+		if c := tableChart(table); c != nil {
+			if c.Line {
+				pdfLineChart(pdf, c, tr)
+			} else {
+				pdfBarChart(pdf, c, tr)
+			}
+			pdf.Ln(4)
+		}
+
+		// Columns share page-width, with smaller text for wide tables
+		width := pageWidth - 2*pdfMargin
+		colWidth := width / float64(len(table.Columns))
+		fontSize := 8.0
+		if colWidth < 20 {
+			fontSize = 6
+		}
+		if colWidth < pdfMinColWidth {
+			colWidth = pdfMinColWidth
+		}
+		drawHeader := func() {
+			pdf.SetFont("Helvetica", "B", fontSize)
+			pdf.SetFillColor(238, 238, 238)
+			pdf.SetDrawColor(204, 204, 204)
+			for _, c := range table.Columns {
+				pdf.CellFormat(colWidth, pdfRowHeight, fitText(pdf, tr(c.Name), colWidth), "1", 0, "L", true, 0, "")
+			}
+			pdf.Ln(-1)
+			pdf.SetFont("Helvetica", "", fontSize)
+		}
+
+		drawHeader()
+		rows := documentRows(table)
+		for _, row := range rows {
+			if pdf.GetY()+pdfRowHeight > pageHeight-pdfMargin-4 {
+				pdf.AddPage()
+				drawHeader()
+			}
+			for j, text := range row {
+				align := "L"
+				if t := table.Columns[j].Type; t == "number" || t == "integer" {
+					align = "R"
+				}
+				pdf.CellFormat(colWidth, pdfRowHeight, fitText(pdf, tr(text), colWidth), "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		if len(rows) == 0 {
+			pdf.CellFormat(0, pdfRowHeight, "No data", "1", 1, "L", false, 0, "")
+		}
+		if len(table.Rows) > len(rows) {
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.CellFormat(
+				0, 6, fmt.Sprintf("Showing first %d of %d rows.", len(rows), len(table.Rows)),
+				"", 1, "L", false, 0, "",
+			)
+		}
+	}
+
+	err := pdf.Output(w)
+	if err != nil {
+		err = errors.Wrap(err, "Error writing PDF-document")
+		return err
+	}
+	return nil
+}
+
+// fitText truncates text to fit in a cell of width.
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	// Allow for cell-padding
+	width -= 2
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"..") > width {
+		text = text[:len(text)-1]
+	}
+	return text + ".."
+}
+
+func pdfBarChart(pdf *gofpdf.Fpdf, c *chart, tr func(string) string) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(0, 6, tr(c.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+
+	x0, y0 := pdf.GetXY()
+	max, min := c.max(), c.min()
+	scale := 0.0
+	if max-min > 0 {
+		scale = (pdfChartWidth - pdfLabelWidth - 20) / (max - min)
+	}
+	zero := x0 + pdfLabelWidth - min*scale
+	pdf.SetFillColor(pdfGreen[0], pdfGreen[1], pdfGreen[2])
+	for i, v := range c.Values {
+		y := y0 + float64(i)*pdfBarHeight
+		x, width := zero, v*scale
+		if v < 0 {
+			x, width = zero+width, -width
+		}
+		pdf.SetXY(x0, y)
+		pdf.CellFormat(pdfLabelWidth-2, pdfBarHeight, fitText(pdf, tr(c.Labels[i]), pdfLabelWidth-2), "", 0, "R", false, 0, "")
+		pdf.Rect(x, y+0.8, width, pdfBarHeight-1.6, "F")
+		pdf.Text(x+width+1.5, y+pdfBarHeight-1.3, fmt.Sprintf("%.2f", v))
+	}
+	pdf.SetXY(x0, y0+float64(len(c.Values))*pdfBarHeight)
+}
+
+func pdfLineChart(pdf *gofpdf.Fpdf, c *chart, tr func(string) string) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(0, 6, tr(c.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+
+	x0, y0 := pdf.GetXY()
+	left, right := x0+20, x0+pdfChartWidth
+	top, bottom := y0+2, y0+pdfLineHeight-8
+	max, min := c.max(), c.min()
+	if max == min {
+		max = min + 1
+	}
+	x := func(i int) float64 {
+		return left + float64(i)*(right-left)/float64(len(c.Values)-1)
+	}
+	y := func(v float64) float64 {
+		return bottom - (v-min)/(max-min)*(bottom-top)
+	}
+
+	// Axes, with value-labels at top and bottom
+	pdf.SetDrawColor(153, 153, 153)
+	pdf.Line(left, top, left, bottom)
+	pdf.Line(left, bottom, right, bottom)
+	maxLabel, minLabel := fmt.Sprintf("%.2f", max), fmt.Sprintf("%.2f", min)
+	pdf.Text(left-2-pdf.GetStringWidth(maxLabel), top+2, maxLabel)
+	pdf.Text(left-2-pdf.GetStringWidth(minLabel), bottom, minLabel)
+	pdf.Text(left, bottom+4, tr(c.Labels[0]))
+	last := tr(c.Labels[len(c.Labels)-1])
+	pdf.Text(right-pdf.GetStringWidth(last), bottom+4, last)
+
+	pdf.SetDrawColor(pdfGreen[0], pdfGreen[1], pdfGreen[2])
+	pdf.SetFillColor(pdfGreen[0], pdfGreen[1], pdfGreen[2])
+	pdf.SetLineWidth(0.5)
+	for i := 1; i < len(c.Values); i++ {
+		pdf.Line(x(i-1), y(c.Values[i-1]), x(i), y(c.Values[i]))
+	}
+	for i, v := range c.Values {
+		pdf.Circle(x(i), y(v), 0.7, "F")
+	}
+	pdf.SetLineWidth(0.2)
+	pdf.SetXY(x0, y0+pdfLineHeight)
+}
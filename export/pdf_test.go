@@ -0,0 +1,29 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+func TestWritePDF(t *testing.T) {
+	table := testTable()
+	for len(table.Rows) <= maxDocumentRows {
+		table.Rows = append(table.Rows, table.Rows[0])
+	}
+	doc := Document{
+		Title:       "Inventory at 4°C",
+		GeneratedAt: time.Unix(1536600000, 0),
+		Tables:      []report.Table{table, {Name: "empty", Columns: table.Columns}},
+	}
+	buf := &bytes.Buffer{}
+	if err := WritePDF(buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) ||
+		!bytes.HasSuffix(bytes.TrimSpace(buf.Bytes()), []byte("%%EOF")) {
+		t.Errorf("not a PDF-document: %q...", buf.Bytes()[:20])
+	}
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxSheetName is the maximum length of worksheet-names in Excel.
+const maxSheetName = 31
+
+// WriteXLSX writes tables as an Excel-workbook, with a worksheet for each table.
+// Cells are typed by their Column's Type, with Unix-timestamps as date-times.
+func WriteXLSX(w io.Writer, tables []report.Table) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Error creating header-style")
+		return err
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	timeStyle, err := f.NewStyle(&excelize.Style{
+		CustomNumFmt: &dateFormat,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Error creating date-style")
+		return err
+	}
+
+	names := map[string]bool{}
+	for i, table := range tables {
+		sheet := sheetName(table.Name, names)
+		if i == 0 {
+			err = f.SetSheetName("Sheet1", sheet)
+		} else {
+			_, err = f.NewSheet(sheet)
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "Error creating worksheet %s", sheet)
+			return err
+		}
+
+		err = writeSheet(f, sheet, table, headerStyle, timeStyle)
+		if err != nil {
+			err = errors.Wrapf(err, "Error writing worksheet %s", sheet)
+			return err
+		}
+	}
+
+	err = f.Write(w)
+	if err != nil {
+		err = errors.Wrap(err, "Error writing XLSX")
+		return err
+	}
+	return nil
+}
+
+func writeSheet(f *excelize.File, sheet string, table report.Table, headerStyle int, timeStyle int) error {
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	// Header-row stays visible when scrolling
+	err = sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+	if err != nil {
+		return err
+	}
+	for i, c := range table.Columns {
+		width := float64(len(c.Name) + 2)
+		if c.Type == "time" && width < 20 {
+			width = 20
+		} else if width < 12 {
+			width = 12
+		}
+		if err = sw.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+	}
+
+	header := make([]interface{}, len(table.Columns))
+	for i, c := range table.Columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: c.Name}
+	}
+	if err = sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for r, row := range table.Rows {
+		cells := make([]interface{}, len(table.Columns))
+		for i, c := range table.Columns {
+			cells[i] = xlsxValue(c, row[i], timeStyle)
+		}
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return err
+		}
+		if err = sw.SetRow(cell, cells); err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}
+
+// xlsxValue converts a Table-value of column c to a cell.
+// Zero-timestamps are empty cells.
+func xlsxValue(c report.Column, v interface{}, timeStyle int) interface{} {
+	if c.Type == "time" {
+		t := unixTime(v)
+		if t.IsZero() {
+			return nil
+		}
+		return excelize.Cell{StyleID: timeStyle, Value: t}
+	}
+	return v
+}
+
+// sheetName returns a unique, valid worksheet-name for a table.
+// Excel limits names to 31 characters, and some characters are not allowed.
+func sheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet"
+	}
+	if len(name) > maxSheetName {
+		name = name[:maxSheetName]
+	}
+
+	unique := name
+	for i := 2; used[unique]; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		base := name
+		if len(base)+len(suffix) > maxSheetName {
+			base = base[:maxSheetName-len(suffix)]
+		}
+		unique = base + suffix
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	totals := report.Table{
+		Name:    "totals/by:name",
+		Columns: []report.Column{{Name: "total", Type: "number"}},
+		Rows:    [][]interface{}{{42.5}},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteXLSX(buf, []report.Table{testTable(), totals, totals}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	wantSheets := []string{"by_name", "totals_by_name", "totals_by_name_2"}
+	if !reflect.DeepEqual(sheets, wantSheets) {
+		t.Errorf("got sheets %q, want %q", sheets, wantSheets)
+	}
+
+	rows, err := f.GetRows("by_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"name", "items", "waste_weight", "expiry_date"},
+		{"Banana", "4", "12.5", "2018-09-10 17:20:00"},
+		{"Apple <Gala>", "2", "30.25"},
+		{"Banana", "1", "0.5"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q, want %q", rows, want)
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	used := map[string]bool{}
+	long := strings.Repeat("x", 40)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", "Sheet"},
+		{"a[1]?", "a_1__"},
+		{long, long[:maxSheetName]},
+		{long, long[:maxSheetName-2] + "_2"},
+	}
+	for _, test := range tests {
+		if got := sheetName(test.name, used); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,754 @@
+package gql
+
+import (
+	"log"
+	"sync"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/pkg/errors"
+)
+
+// Resolver is the root-resolver of the schema.
+type Resolver struct {
+	db report.DBI
+}
+
+type filterInput struct {
+	ByDate *[]dateRangeInput
+	Fields *[]fieldInput
+}
+
+type dateRangeInput struct {
+	StartDate *Long
+	EndDate   Long
+}
+
+type fieldInput struct {
+	Field string
+	Value FieldValue
+}
+
+type pageInput struct {
+	Limit     *int32
+	Skip      *int32
+	PageToken *string
+	Sort      *[]sortInput
+	WithCount *bool
+}
+
+type sortInput struct {
+	Field string
+	Desc  *bool
+}
+
+type searchArgs struct {
+	Filter *filterInput
+	Page   *pageInput
+}
+
+// searchFilter creates the Filter for args, and'ed with the relationship-filters.
+// A nil Filter, which matches all documents, is returned if there are no filters.
+func searchFilter(args searchArgs, filters ...report.Filter) (report.Filter, error) {
+	if f := args.Filter; f != nil {
+		if f.ByDate != nil && len(*f.ByDate) > 0 {
+			search := []report.SearchByDate{}
+			for _, d := range *f.ByDate {
+				search = append(search, report.SearchByDate{
+					StartDate: int64(longValue(d.StartDate)),
+					EndDate:   int64(d.EndDate),
+				})
+			}
+			filter, err := report.TimestampFilter(search)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+		if f.Fields != nil && len(*f.Fields) > 0 {
+			search := []report.SearchByFieldVal{}
+			for _, v := range *f.Fields {
+				search = append(search, report.SearchByFieldVal{
+					SearchField: v.Field,
+					SearchVal:   v.Value.Value,
+				})
+			}
+			filter, err := report.FieldValFilter(search)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+	}
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	return report.And(filters...), nil
+}
+
+// pageOptions converts the page-argument of args.
+func pageOptions(args searchArgs) report.PageOptions {
+	opts := report.PageOptions{}
+	p := args.Page
+	if p == nil {
+		return opts
+	}
+	if p.Limit != nil {
+		opts.Limit = int64(*p.Limit)
+	}
+	if p.Skip != nil {
+		opts.Skip = int64(*p.Skip)
+	}
+	if p.PageToken != nil {
+		opts.PageToken = *p.PageToken
+	}
+	if p.Sort != nil {
+		for _, s := range *p.Sort {
+			opts.Sort = append(opts.Sort, report.SortField{
+				Field: s.Field,
+				Desc:  s.Desc != nil && *s.Desc,
+			})
+		}
+	}
+	opts.WithCount = p.WithCount != nil && *p.WithCount
+	return opts
+}
+
+// maxNestedPageLimit caps the pages of an Inventory-item's Metrics and Reports,
+// and of a Customer's Inventory and Reports, which are queried separately
+// for every item of the parent page.
+const maxNestedPageLimit = 20
+
+// nestedArgs returns args with a page-limit which defaults to, and is at most,
+// maxNestedPageLimit.
+func nestedArgs(args searchArgs) searchArgs {
+	page := pageInput{}
+	if args.Page != nil {
+		page = *args.Page
+	}
+	limit := int32(maxNestedPageLimit)
+	if page.Limit != nil && *page.Limit > 0 && *page.Limit < limit {
+		limit = *page.Limit
+	}
+	page.Limit = &limit
+	args.Page = &page
+	return args
+}
+
+// resolverError logs err and returns it with msg, which is included in the response.
+func resolverError(err error, msg string) error {
+	err = errors.Wrap(err, msg)
+	log.Println(err)
+	return err
+}
+
+// ================ Root-queries ================
+
+// Reports resolves Query.reports.
+func (r *Resolver) Reports(args searchArgs) (*reportPageResolver, error) {
+	return searchReports(r.db, args)
+}
+
+// Metrics resolves Query.metrics.
+func (r *Resolver) Metrics(args searchArgs) (*metricPageResolver, error) {
+	return searchMetrics(r.db, args)
+}
+
+// Inventory resolves Query.inventory.
+func (r *Resolver) Inventory(args searchArgs) (*inventoryPageResolver, error) {
+	return searchInventory(r.db, args)
+}
+
+// Item resolves Query.item.
+func (r *Resolver) Item(args struct{ ItemID graphql.ID }) (*inventoryResolver, error) {
+	items, err := r.db.QueryInventory(report.Eq("item_id", string(args.ItemID)))
+	if err != nil {
+		return nil, resolverError(err, "Unable to get item")
+	}
+	if len(*items) == 0 {
+		return nil, nil
+	}
+	return &inventoryResolver{
+		db:   r.db,
+		item: (*items)[0],
+	}, nil
+}
+
+// Customer resolves Query.customer.
+func (r *Resolver) Customer(args struct{ ID graphql.ID }) *customerResolver {
+	return &customerResolver{
+		db: r.db,
+		id: string(args.ID),
+	}
+}
+
+func searchReports(db report.DBI, args searchArgs, filters ...report.Filter) (*reportPageResolver, error) {
+	filter, err := searchFilter(args, filters...)
+	if err != nil {
+		return nil, err
+	}
+	page, err := db.QueryPage(filter, pageOptions(args))
+	if err != nil {
+		return nil, resolverError(err, "Unable to get report-page")
+	}
+	return &reportPageResolver{
+		db:   db,
+		page: page,
+	}, nil
+}
+
+func searchMetrics(db report.DBI, args searchArgs, filters ...report.Filter) (*metricPageResolver, error) {
+	filter, err := searchFilter(args, filters...)
+	if err != nil {
+		return nil, err
+	}
+	page, err := db.QueryMetricPage(filter, pageOptions(args))
+	if err != nil {
+		return nil, resolverError(err, "Unable to get metric-page")
+	}
+	return &metricPageResolver{
+		db:   db,
+		page: page,
+	}, nil
+}
+
+func searchInventory(db report.DBI, args searchArgs, filters ...report.Filter) (*inventoryPageResolver, error) {
+	filter, err := searchFilter(args, filters...)
+	if err != nil {
+		return nil, err
+	}
+	page, err := db.QueryInventoryPage(filter, pageOptions(args))
+	if err != nil {
+		return nil, resolverError(err, "Unable to get inventory-page")
+	}
+	return &inventoryPageResolver{
+		db:   db,
+		page: page,
+	}, nil
+}
+
+// ================ Relationship-batches ================
+
+// inventoryBatch loads the Inventory related to a page of results, such as the
+// items of a page of Reports. The Inventory for all results is loaded using a
+// single query the first time any result's items are resolved.
+type inventoryBatch struct {
+	db report.DBI
+	// field is the Inventory-field matched against ids, such as "item_id".
+	field string
+	ids   []interface{}
+
+	once  sync.Once
+	items map[string][]*report.Inventory
+	err   error
+}
+
+func newInventoryBatch(db report.DBI, field string, ids []string) *inventoryBatch {
+	b := &inventoryBatch{
+		db:    db,
+		field: field,
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			b.ids = append(b.ids, id)
+		}
+	}
+	return b
+}
+
+// get returns the items related to id.
+func (b *inventoryBatch) get(id string) ([]*inventoryResolver, error) {
+	if id == "" {
+		return []*inventoryResolver{}, nil
+	}
+	b.once.Do(func() {
+		b.items = map[string][]*report.Inventory{}
+		items, err := b.db.QueryInventory(report.In(b.field, b.ids...))
+		if err != nil {
+			b.err = resolverError(err, "Unable to get related inventory")
+			return
+		}
+		for i := range *items {
+			item := &(*items)[i]
+			key := item.ItemID.String()
+			if b.field == "device_id" {
+				key = item.DeviceID.String()
+			}
+			b.items[key] = append(b.items[key], item)
+		}
+	})
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	resolvers := []*inventoryResolver{}
+	for _, item := range b.items[id] {
+		resolvers = append(resolvers, &inventoryResolver{
+			db:   b.db,
+			item: *item,
+		})
+	}
+	return resolvers, nil
+}
+
+// getOne returns the first item related to id, or nil if there is none.
+func (b *inventoryBatch) getOne(id string) (*inventoryResolver, error) {
+	items, err := b.get(id)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+// ================ Pages ================
+
+type reportPageResolver struct {
+	db   report.DBI
+	page *report.ReportPage
+}
+
+func (p *reportPageResolver) Reports() []*reportResolver {
+	ids := []string{}
+	for _, r := range p.page.Reports {
+		ids = append(ids, uuidString(r.ItemID))
+	}
+	items := newInventoryBatch(p.db, "item_id", ids)
+
+	reports := []*reportResolver{}
+	for _, r := range p.page.Reports {
+		reports = append(reports, &reportResolver{
+			db:     p.db,
+			report: r,
+			items:  items,
+		})
+	}
+	return reports
+}
+
+func (p *reportPageResolver) NextPageToken() *string {
+	return optionalString(p.page.NextPageToken)
+}
+
+func (p *reportPageResolver) TotalCount() *Long {
+	return optionalLong(p.page.TotalCount)
+}
+
+type metricPageResolver struct {
+	db   report.DBI
+	page *report.MetricPage
+}
+
+func (p *metricPageResolver) Metrics() []*metricResolver {
+	itemIDs := []string{}
+	deviceIDs := []string{}
+	for _, m := range p.page.Metrics {
+		itemIDs = append(itemIDs, uuidString(m.ItemID))
+		deviceIDs = append(deviceIDs, uuidString(m.DeviceID))
+	}
+	items := newInventoryBatch(p.db, "item_id", itemIDs)
+	devices := newInventoryBatch(p.db, "device_id", deviceIDs)
+
+	metrics := []*metricResolver{}
+	for _, m := range p.page.Metrics {
+		metrics = append(metrics, &metricResolver{
+			metric:  m,
+			items:   items,
+			devices: devices,
+		})
+	}
+	return metrics
+}
+
+func (p *metricPageResolver) NextPageToken() *string {
+	return optionalString(p.page.NextPageToken)
+}
+
+func (p *metricPageResolver) TotalCount() *Long {
+	return optionalLong(p.page.TotalCount)
+}
+
+type inventoryPageResolver struct {
+	db   report.DBI
+	page *report.InventoryPage
+}
+
+func (p *inventoryPageResolver) Inventory() []*inventoryResolver {
+	items := []*inventoryResolver{}
+	for _, item := range p.page.Inventory {
+		items = append(items, &inventoryResolver{
+			db:   p.db,
+			item: item,
+		})
+	}
+	return items
+}
+
+func (p *inventoryPageResolver) NextPageToken() *string {
+	return optionalString(p.page.NextPageToken)
+}
+
+func (p *inventoryPageResolver) TotalCount() *Long {
+	return optionalLong(p.page.TotalCount)
+}
+
+// ================ Reports ================
+
+type reportResolver struct {
+	db     report.DBI
+	report report.Report
+	items  *inventoryBatch
+}
+
+func (r *reportResolver) ID() *graphql.ID {
+	return objectID(r.report.ID)
+}
+
+func (r *reportResolver) ItemID() *graphql.ID {
+	return optionalID(uuidString(r.report.ItemID))
+}
+
+func (r *reportResolver) ReportID() *graphql.ID {
+	return optionalID(uuidString(r.report.ReportID))
+}
+
+func (r *reportResolver) RsCustomerID() *graphql.ID {
+	return optionalID(uuidString(r.report.RsCustomerID))
+}
+
+func (r *reportResolver) Timestamp() *Long {
+	return optionalLong(r.report.Timestamp)
+}
+
+func (r *reportResolver) ReportType() *string {
+	return optionalString(r.report.ReportType)
+}
+
+func (r *reportResolver) Version() int32 {
+	return int32(r.report.Version)
+}
+
+func (r *reportResolver) AggregateID() int32 {
+	return int32(r.report.AggregateID)
+}
+
+func (r *reportResolver) AggregateVersion() Long {
+	return Long(r.report.AggregateVersion)
+}
+
+func (r *reportResolver) Name() *string {
+	return optionalString(r.report.Name)
+}
+
+func (r *reportResolver) StartDate() *Long {
+	return optionalLong(r.report.StartDate)
+}
+
+func (r *reportResolver) EndDate() *Long {
+	return optionalLong(r.report.EndDate)
+}
+
+func (r *reportResolver) Params() *string {
+	return optionalString(string(r.report.Params))
+}
+
+func (r *reportResolver) Result() *string {
+	return optionalString(string(r.report.Result))
+}
+
+func (r *reportResolver) Item() (*inventoryResolver, error) {
+	return r.items.getOne(uuidString(r.report.ItemID))
+}
+
+func (r *reportResolver) Customer() *customerResolver {
+	return newCustomerResolver(r.db, r.report.RsCustomerID)
+}
+
+// ================ Metrics ================
+
+type metricResolver struct {
+	metric  report.Metric
+	items   *inventoryBatch
+	devices *inventoryBatch
+}
+
+func (m *metricResolver) ID() *graphql.ID {
+	return objectID(m.metric.ID)
+}
+
+func (m *metricResolver) ItemID() *graphql.ID {
+	return optionalID(uuidString(m.metric.ItemID))
+}
+
+func (m *metricResolver) DeviceID() *graphql.ID {
+	return optionalID(uuidString(m.metric.DeviceID))
+}
+
+func (m *metricResolver) Timestamp() *Long {
+	return optionalLong(m.metric.Timestamp)
+}
+
+func (m *metricResolver) TempIn() float64 {
+	return m.metric.TempIn
+}
+
+func (m *metricResolver) Humidity() float64 {
+	return m.metric.Humidity
+}
+
+func (m *metricResolver) Ethylene() float64 {
+	return m.metric.Ethylene
+}
+
+func (m *metricResolver) CarbonDi() float64 {
+	return m.metric.CarbonDi
+}
+
+func (m *metricResolver) Version() int32 {
+	return int32(m.metric.Version)
+}
+
+func (m *metricResolver) AggregateID() int32 {
+	return int32(m.metric.AggregateID)
+}
+
+func (m *metricResolver) AggregateVersion() Long {
+	return Long(m.metric.AggregateVersion)
+}
+
+func (m *metricResolver) Item() (*inventoryResolver, error) {
+	return m.items.getOne(uuidString(m.metric.ItemID))
+}
+
+func (m *metricResolver) DeviceItems() ([]*inventoryResolver, error) {
+	return m.devices.get(uuidString(m.metric.DeviceID))
+}
+
+// ================ Inventory ================
+
+type inventoryResolver struct {
+	db   report.DBI
+	item report.Inventory
+}
+
+func (i *inventoryResolver) ID() *graphql.ID {
+	return objectID(i.item.ID)
+}
+
+func (i *inventoryResolver) ItemID() *graphql.ID {
+	return optionalID(uuidString(i.item.ItemID))
+}
+
+func (i *inventoryResolver) UPC() Long {
+	return Long(i.item.UPC)
+}
+
+func (i *inventoryResolver) SKU() Long {
+	return Long(i.item.SKU)
+}
+
+func (i *inventoryResolver) Name() *string {
+	return optionalString(i.item.Name)
+}
+
+func (i *inventoryResolver) Origin() *string {
+	return optionalString(i.item.Origin)
+}
+
+func (i *inventoryResolver) DeviceID() *graphql.ID {
+	return optionalID(uuidString(i.item.DeviceID))
+}
+
+func (i *inventoryResolver) TotalWeight() float64 {
+	return i.item.TotalWeight
+}
+
+func (i *inventoryResolver) Price() float64 {
+	return i.item.Price
+}
+
+func (i *inventoryResolver) Location() *string {
+	return optionalString(i.item.Location)
+}
+
+func (i *inventoryResolver) DateArrived() *Long {
+	return optionalLong(i.item.DateArrived)
+}
+
+func (i *inventoryResolver) ExpiryDate() *Long {
+	return optionalLong(i.item.ExpiryDate)
+}
+
+func (i *inventoryResolver) Timestamp() *Long {
+	return optionalLong(i.item.Timestamp)
+}
+
+func (i *inventoryResolver) RsCustomerID() *graphql.ID {
+	return optionalID(uuidString(i.item.RsCustomerID))
+}
+
+func (i *inventoryResolver) WasteWeight() float64 {
+	return i.item.WasteWeight
+}
+
+func (i *inventoryResolver) DonateWeight() float64 {
+	return i.item.DonateWeight
+}
+
+func (i *inventoryResolver) AggregateVersion() Long {
+	return Long(i.item.AggregateVersion)
+}
+
+func (i *inventoryResolver) AggregateID() int32 {
+	return int32(i.item.AggregateID)
+}
+
+func (i *inventoryResolver) DateSold() *Long {
+	return optionalLong(i.item.DateSold)
+}
+
+func (i *inventoryResolver) SalePrice() float64 {
+	return i.item.SalePrice
+}
+
+func (i *inventoryResolver) SoldWeight() float64 {
+	return i.item.SoldWeight
+}
+
+func (i *inventoryResolver) ProdQuantity() Long {
+	return Long(i.item.ProdQuantity)
+}
+
+// Metrics are joined to Inventory using ItemID, or using
+// DeviceID for Metrics without an ItemID, as in the reports.
+func (i *inventoryResolver) Metrics(args searchArgs) (*metricPageResolver, error) {
+	itemID := uuidString(i.item.ItemID)
+	deviceID := uuidString(i.item.DeviceID)
+	if itemID == "" && deviceID == "" {
+		return emptyMetricPage(i.db), nil
+	}
+
+	related := []report.Filter{}
+	if itemID != "" {
+		related = append(related, report.Eq("item_id", itemID))
+	}
+	if deviceID != "" {
+		related = append(related, report.And(
+			report.Eq("device_id", deviceID),
+			report.Exists("item_id", false),
+		))
+	}
+	return searchMetrics(i.db, nestedArgs(args), report.Or(related...))
+}
+
+func (i *inventoryResolver) Reports(args searchArgs) (*reportPageResolver, error) {
+	itemID := uuidString(i.item.ItemID)
+	if itemID == "" {
+		return emptyReportPage(i.db), nil
+	}
+	return searchReports(i.db, nestedArgs(args), report.Eq("item_id", itemID))
+}
+
+func (i *inventoryResolver) Customer() *customerResolver {
+	return newCustomerResolver(i.db, i.item.RsCustomerID)
+}
+
+// ================ Customers ================
+
+type customerResolver struct {
+	db report.DBI
+	id string
+}
+
+// newCustomerResolver returns nil if id is not set.
+func newCustomerResolver(db report.DBI, id uuuid.UUID) *customerResolver {
+	customerID := uuidString(id)
+	if customerID == "" {
+		return nil
+	}
+	return &customerResolver{
+		db: db,
+		id: customerID,
+	}
+}
+
+func (c *customerResolver) ID() graphql.ID {
+	return graphql.ID(c.id)
+}
+
+func (c *customerResolver) Inventory(args searchArgs) (*inventoryPageResolver, error) {
+	return searchInventory(c.db, nestedArgs(args), report.Eq("rs_customer_id", c.id))
+}
+
+func (c *customerResolver) Reports(args searchArgs) (*reportPageResolver, error) {
+	return searchReports(c.db, nestedArgs(args), report.Eq("rs_customer_id", c.id))
+}
+
+// ================ Helpers ================
+
+func emptyMetricPage(db report.DBI) *metricPageResolver {
+	return &metricPageResolver{
+		db: db,
+		page: &report.MetricPage{
+			Metrics: []report.Metric{},
+		},
+	}
+}
+
+func emptyReportPage(db report.DBI) *reportPageResolver {
+	return &reportPageResolver{
+		db: db,
+		page: &report.ReportPage{
+			Reports: []report.Report{},
+		},
+	}
+}
+
+// uuidString returns an empty string for UUIDs which are not set.
+func uuidString(id uuuid.UUID) string {
+	if id.String() == (uuuid.UUID{}).String() {
+		return ""
+	}
+	return id.String()
+}
+
+func objectID(id objectid.ObjectID) *graphql.ID {
+	if id.IsZero() {
+		return nil
+	}
+	return optionalID(id.Hex())
+}
+
+func optionalID(id string) *graphql.ID {
+	if id == "" {
+		return nil
+	}
+	gid := graphql.ID(id)
+	return &gid
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalLong(v int64) *Long {
+	if v == 0 {
+		return nil
+	}
+	l := Long(v)
+	return &l
+}
+
+func longValue(l *Long) Long {
+	if l == nil {
+		return 0
+	}
+	return *l
+}
@@ -0,0 +1,140 @@
+package gql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+type fakeDB struct {
+	report.DBI
+	inventory       []report.Inventory
+	reports         []report.Report
+	inventoryLimits []int64
+	metricLimits    []int64
+	reportLimits    []int64
+}
+
+func (db *fakeDB) QueryInventoryPage(filter report.Filter, opts report.PageOptions) (*report.InventoryPage, error) {
+	db.inventoryLimits = append(db.inventoryLimits, opts.Limit)
+	return &report.InventoryPage{Inventory: db.inventory}, nil
+}
+
+func (db *fakeDB) QueryPage(filter report.Filter, opts report.PageOptions) (*report.ReportPage, error) {
+	db.reportLimits = append(db.reportLimits, opts.Limit)
+	return &report.ReportPage{Reports: db.reports}, nil
+}
+
+func (db *fakeDB) QueryMetricPage(filter report.Filter, opts report.PageOptions) (*report.MetricPage, error) {
+	db.metricLimits = append(db.metricLimits, opts.Limit)
+	return &report.MetricPage{Metrics: []report.Metric{}}, nil
+}
+
+func newUUID(t *testing.T) uuuid.UUID {
+	t.Helper()
+	id, err := uuuid.NewV4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestNestedArgs(t *testing.T) {
+	limit := func(n int32) *int32 {
+		return &n
+	}
+	tests := []struct {
+		name string
+		page *pageInput
+		want int32
+	}{
+		{"no page", nil, maxNestedPageLimit},
+		{"no limit", &pageInput{}, maxNestedPageLimit},
+		{"small limit", &pageInput{Limit: limit(5)}, 5},
+		{"large limit", &pageInput{Limit: limit(500)}, maxNestedPageLimit},
+		{"zero limit", &pageInput{Limit: limit(0)}, maxNestedPageLimit},
+	}
+	for _, test := range tests {
+		args := searchArgs{Page: test.page}
+		got := nestedArgs(args)
+		if got.Page == nil || got.Page.Limit == nil || *got.Page.Limit != test.want {
+			t.Errorf("%s: got %+v, want limit %d", test.name, got.Page, test.want)
+		}
+	}
+
+	page := &pageInput{Limit: limit(500)}
+	nestedArgs(searchArgs{Page: page})
+	if *page.Limit != 500 {
+		t.Errorf("nestedArgs modified the page-argument: limit %d", *page.Limit)
+	}
+}
+
+func TestNestedMetricsLimit(t *testing.T) {
+	db := &fakeDB{
+		inventory: []report.Inventory{
+			{ItemID: newUUID(t)},
+			{ItemID: newUUID(t)},
+		},
+	}
+	schema, err := NewSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{ inventory { inventory { metrics(page: {limit: 1000}) { metrics { tempIn } } } } }`
+	resp := schema.Exec(context.Background(), query, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatal(resp.Errors)
+	}
+	want := []int64{maxNestedPageLimit, maxNestedPageLimit}
+	if !reflect.DeepEqual(db.metricLimits, want) {
+		t.Errorf("got limits %v, want %v", db.metricLimits, want)
+	}
+}
+
+func TestNestedCustomerLimit(t *testing.T) {
+	db := &fakeDB{
+		reports: []report.Report{
+			{RsCustomerID: newUUID(t)},
+			{RsCustomerID: newUUID(t)},
+		},
+	}
+	schema, err := NewSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{ reports { reports { customer {
+		inventory(page: {limit: 1000}) { nextPageToken }
+		reports { nextPageToken }
+	} } } }`
+	resp := schema.Exec(context.Background(), query, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatal(resp.Errors)
+	}
+	want := []int64{maxNestedPageLimit, maxNestedPageLimit}
+	if !reflect.DeepEqual(db.inventoryLimits, want) {
+		t.Errorf("got inventory-limits %v, want %v", db.inventoryLimits, want)
+	}
+	// The root page of Reports is not limited
+	want = []int64{0, maxNestedPageLimit, maxNestedPageLimit}
+	if !reflect.DeepEqual(db.reportLimits, want) {
+		t.Errorf("got report-limits %v, want %v", db.reportLimits, want)
+	}
+}
+
+func TestMaxQueryDepth(t *testing.T) {
+	schema, err := NewSchema(&fakeDB{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{ inventory { inventory { metrics { metrics { item { name } } } } } }`
+	resp := schema.Exec(context.Background(), query, "", nil)
+	if len(resp.Errors) == 0 {
+		t.Error("expected an error for a query deeper than maxQueryDepth")
+	}
+}
@@ -0,0 +1,66 @@
+package gql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Long is the GraphQL Long-scalar, for 64-bit integers.
+type Long int64
+
+// ImplementsGraphQLType implements graphql.Unmarshaler.
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+// UnmarshalGraphQL implements graphql.Unmarshaler. Literal integers are
+// 32-bit, and variables are floats, so larger values are also accepted as strings.
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*l = Long(v)
+	case int64:
+		*l = Long(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("Long must be an integer, got %v", v)
+		}
+		*l = Long(v)
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Long must be an integer, got %q", v)
+		}
+		*l = Long(i)
+	default:
+		return fmt.Errorf("Long must be an integer, got %T", input)
+	}
+	return nil
+}
+
+// MarshalJSON writes the Long as a JSON-number.
+func (l Long) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(l), 10), nil
+}
+
+// FieldValue is the GraphQL FieldValue-scalar, which is the value
+// of a report.SearchByFieldVal.
+type FieldValue struct {
+	Value interface{}
+}
+
+// ImplementsGraphQLType implements graphql.Unmarshaler.
+func (FieldValue) ImplementsGraphQLType(name string) bool {
+	return name == "FieldValue"
+}
+
+// UnmarshalGraphQL implements graphql.Unmarshaler.
+func (v *FieldValue) UnmarshalGraphQL(input interface{}) error {
+	switch input.(type) {
+	case string, bool, int32, int64, float64:
+		v.Value = input
+		return nil
+	}
+	return fmt.Errorf("FieldValue must be a string, number or boolean, got %T", input)
+}
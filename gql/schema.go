@@ -0,0 +1,194 @@
+// Package gql serves a GraphQL-API over Reports, Metrics and Inventory.
+package gql
+
+import (
+	"net/http"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// maxQueryDepth limits the nesting of queries, since each
+// level of relationships can query the DB for every result.
+// This allows one level of paged relationships below a page.
+const maxQueryDepth = 5
+
+// schema is the GraphQL-schema. Relationships are resolved through ItemID,
+// DeviceID and RsCustomerID, as in the reports.
+const schema = `
+schema {
+	query: Query
+}
+
+"""
+A 64-bit integer, such as a Unix-timestamp, UPC or SKU. Values larger than
+32-bit integers must be specified as strings or using variables.
+"""
+scalar Long
+
+"A string, number or boolean to match a field against."
+scalar FieldValue
+
+type Query {
+	reports(filter: Filter, page: Page): ReportPage!
+	metrics(filter: Filter, page: Page): MetricPage!
+	inventory(filter: Filter, page: Page): InventoryPage!
+	"The Inventory-item with itemId, or null if there is no such item."
+	item(itemId: ID!): Inventory
+	"The customer with id. Customers only exist as the rsCustomerId of Reports and Inventory."
+	customer(id: ID!): Customer!
+}
+
+"""
+Matches documents whose timestamp lies in any of the date-ranges,
+and which match all of the fields. All documents match if this is empty.
+"""
+input Filter {
+	byDate: [DateRange!]
+	fields: [FieldFilter!]
+}
+
+input DateRange {
+	startDate: Long
+	endDate: Long!
+}
+
+"Matches documents where field (the document's field-name, such as location) equals value."
+input FieldFilter {
+	field: String!
+	value: FieldValue!
+}
+
+"Results are paged using skip, or using the nextPageToken of the previous page."
+input Page {
+	limit: Int
+	skip: Int
+	pageToken: String
+	sort: [Sort!]
+	withCount: Boolean
+}
+
+input Sort {
+	field: String!
+	desc: Boolean
+}
+
+type ReportPage {
+	reports: [Report!]!
+	nextPageToken: String
+	"Total number of matching Reports, if withCount was requested."
+	totalCount: Long
+}
+
+type MetricPage {
+	metrics: [Metric!]!
+	nextPageToken: String
+	totalCount: Long
+}
+
+type InventoryPage {
+	inventory: [Inventory!]!
+	nextPageToken: String
+	totalCount: Long
+}
+
+type Report {
+	id: ID
+	itemId: ID
+	reportId: ID
+	rsCustomerId: ID
+	timestamp: Long
+	reportType: String
+	version: Int!
+	aggregateId: Int!
+	aggregateVersion: Long!
+	name: String
+	startDate: Long
+	endDate: Long
+	"Params of report-snapshots, as a JSON-string."
+	params: String
+	"Result of report-snapshots, as a JSON-string."
+	result: String
+	"The Inventory-item with the Report's itemId."
+	item: Inventory
+	customer: Customer
+}
+
+type Metric {
+	id: ID
+	itemId: ID
+	deviceId: ID
+	timestamp: Long
+	tempIn: Float!
+	humidity: Float!
+	ethylene: Float!
+	carbonDi: Float!
+	version: Int!
+	aggregateId: Int!
+	aggregateVersion: Long!
+	"The Inventory-item with the Metric's itemId."
+	item: Inventory
+	"The Inventory-items monitored by the Metric's device."
+	deviceItems: [Inventory!]!
+}
+
+type Inventory {
+	id: ID
+	itemId: ID
+	upc: Long!
+	sku: Long!
+	name: String
+	origin: String
+	deviceId: ID
+	totalWeight: Float!
+	price: Float!
+	location: String
+	dateArrived: Long
+	expiryDate: Long
+	timestamp: Long
+	rsCustomerId: ID
+	wasteWeight: Float!
+	donateWeight: Float!
+	aggregateVersion: Long!
+	aggregateId: Int!
+	dateSold: Long
+	salePrice: Float!
+	soldWeight: Float!
+	prodQuantity: Long!
+	"""
+	Metrics of the item, which are the Metrics with its itemId, and the
+	Metrics of its device without an itemId. Pages hold at most 20 Metrics.
+	"""
+	metrics(filter: Filter, page: Page): MetricPage!
+	"Reports with the item's itemId. Pages hold at most 20 Reports."
+	reports(filter: Filter, page: Page): ReportPage!
+	customer: Customer
+}
+
+type Customer {
+	id: ID!
+	"Inventory with the customer's rsCustomerId. Pages hold at most 20 items."
+	inventory(filter: Filter, page: Page): InventoryPage!
+	"Reports with the customer's rsCustomerId. Pages hold at most 20 Reports."
+	reports(filter: Filter, page: Page): ReportPage!
+}
+`
+
+// NewSchema parses the GraphQL-schema, with resolvers querying db.
+func NewSchema(db report.DBI) (*graphql.Schema, error) {
+	return graphql.ParseSchema(
+		schema,
+		&Resolver{
+			db: db,
+		},
+		graphql.MaxDepth(maxQueryDepth),
+	)
+}
+
+// Handler serves GraphQL-queries, which are POSTed as JSON.
+func Handler(schema *graphql.Schema) http.Handler {
+	return &relay.Handler{
+		Schema: schema,
+	}
+}
@@ -0,0 +1,86 @@
+package grpcserver
+
+import (
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/bhupeshbhatia/go-report-query/reportpb"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// IDs which are not set are empty strings, same as in JSON-responses.
+
+func uuidString(id uuuid.UUID) string {
+	if id.String() == (uuuid.UUID{}).String() {
+		return ""
+	}
+	return id.String()
+}
+
+func objectIDString(id objectid.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+func reportMessage(r report.Report) *reportpb.Report {
+	return &reportpb.Report{
+		Id:               objectIDString(r.ID),
+		ItemId:           uuidString(r.ItemID),
+		ReportId:         uuidString(r.ReportID),
+		RsCustomerId:     uuidString(r.RsCustomerID),
+		Timestamp:        r.Timestamp,
+		ReportType:       r.ReportType,
+		Version:          int64(r.Version),
+		AggregateId:      int32(r.AggregateID),
+		AggregateVersion: r.AggregateVersion,
+		Name:             r.Name,
+		StartDate:        r.StartDate,
+		EndDate:          r.EndDate,
+		Params:           string(r.Params),
+		Result:           string(r.Result),
+	}
+}
+
+func metricMessage(m report.Metric) *reportpb.Metric {
+	return &reportpb.Metric{
+		Id:               objectIDString(m.ID),
+		ItemId:           uuidString(m.ItemID),
+		DeviceId:         uuidString(m.DeviceID),
+		Timestamp:        m.Timestamp,
+		TempIn:           m.TempIn,
+		Humidity:         m.Humidity,
+		Ethylene:         m.Ethylene,
+		CarbonDi:         m.CarbonDi,
+		Version:          int64(m.Version),
+		AggregateId:      int32(m.AggregateID),
+		AggregateVersion: m.AggregateVersion,
+	}
+}
+
+func inventoryMessage(item report.Inventory) *reportpb.Inventory {
+	return &reportpb.Inventory{
+		Id:               objectIDString(item.ID),
+		ItemId:           uuidString(item.ItemID),
+		Upc:              item.UPC,
+		Sku:              item.SKU,
+		Name:             item.Name,
+		Origin:           item.Origin,
+		DeviceId:         uuidString(item.DeviceID),
+		TotalWeight:      item.TotalWeight,
+		Price:            item.Price,
+		Location:         item.Location,
+		DateArrived:      item.DateArrived,
+		ExpiryDate:       item.ExpiryDate,
+		Timestamp:        item.Timestamp,
+		RsCustomerId:     uuidString(item.RsCustomerID),
+		WasteWeight:      item.WasteWeight,
+		DonateWeight:     item.DonateWeight,
+		AggregateVersion: item.AggregateVersion,
+		AggregateId:      int32(item.AggregateID),
+		DateSold:         item.DateSold,
+		SalePrice:        item.SalePrice,
+		SoldWeight:       item.SoldWeight,
+		ProdQuantity:     item.ProdQuantity,
+	}
+}
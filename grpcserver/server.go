@@ -0,0 +1,255 @@
+// Package grpcserver serves the ReportQuery gRPC-service defined in reportpb.
+package grpcserver
+
+import (
+	"context"
+	"log"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/bhupeshbhatia/go-report-query/reportpb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// streamBatchSize is the number of documents read from DB at a time by Stream-RPCs.
+const streamBatchSize = 500
+
+// Server implements reportpb.ReportQueryServer using a report.DBI.
+type Server struct {
+	reportpb.UnimplementedReportQueryServer
+	db report.DBI
+}
+
+// NewServer creates a Server which searches db.
+func NewServer(db report.DBI) *Server {
+	return &Server{
+		db: db,
+	}
+}
+
+// Register registers the ReportQuery-service on s, along with server-reflection
+// so the service can be explored using tools such as grpcurl.
+func (srv *Server) Register(s *grpc.Server) {
+	reportpb.RegisterReportQueryServer(s, srv)
+	reflection.Register(s)
+}
+
+// requestFilter creates the Filter for req. Documents must lie in any of the
+// date-ranges and match all of the field-values.
+func requestFilter(req *reportpb.SearchRequest) (report.Filter, error) {
+	filters := []report.Filter{}
+	if len(req.GetByDate()) > 0 {
+		search := make([]report.SearchByDate, len(req.GetByDate()))
+		for i, d := range req.GetByDate() {
+			search[i] = report.SearchByDate{
+				StartDate: d.GetStartDate(),
+				EndDate:   d.GetEndDate(),
+			}
+		}
+		filter, err := report.TimestampFilter(search)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filters = append(filters, filter)
+	}
+	if len(req.GetByFieldVal()) > 0 {
+		search := make([]report.SearchByFieldVal, len(req.GetByFieldVal()))
+		for i, v := range req.GetByFieldVal() {
+			search[i] = report.SearchByFieldVal{
+				SearchField: v.GetSearchField(),
+				SearchVal:   v.GetSearchVal().AsInterface(),
+			}
+		}
+		filter, err := report.FieldValFilter(search)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filters = append(filters, filter)
+	}
+
+	if len(filters) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "No date-range or field-value specified")
+	}
+	return report.And(filters...), nil
+}
+
+// pageOptions converts the PageOptions of req.
+func pageOptions(req *reportpb.SearchRequest) report.PageOptions {
+	page := req.GetPage()
+	opts := report.PageOptions{
+		Limit:     page.GetLimit(),
+		Skip:      page.GetSkip(),
+		PageToken: page.GetPageToken(),
+		Fields:    page.GetFields(),
+		WithCount: page.GetWithCount(),
+	}
+	for _, s := range page.GetSort() {
+		opts.Sort = append(opts.Sort, report.SortField{
+			Field: s.GetField(),
+			Desc:  s.GetDesc(),
+		})
+	}
+	return opts
+}
+
+// rpcError logs err and converts it to an InvalidArgument-error if it was
+// caused by invalid PageOptions, or to an Internal-error otherwise.
+func rpcError(err error, msg string) error {
+	err = errors.Wrap(err, msg)
+	log.Println(err)
+	if _, ok := errors.Cause(err).(*report.InvalidPageError); ok {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// SearchReports returns a page of the Reports matching req.
+func (srv *Server) SearchReports(
+	ctx context.Context,
+	req *reportpb.SearchRequest,
+) (*reportpb.ReportPage, error) {
+	filter, err := requestFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	page, err := srv.db.QueryPage(filter, pageOptions(req))
+	if err != nil {
+		return nil, rpcError(err, "Unable to get report-page")
+	}
+
+	result := &reportpb.ReportPage{
+		Reports:       make([]*reportpb.Report, len(page.Reports)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    page.TotalCount,
+	}
+	for i, r := range page.Reports {
+		result.Reports[i] = reportMessage(r)
+	}
+	return result, nil
+}
+
+// StreamReports streams the Reports matching req as they are read from DB.
+func (srv *Server) StreamReports(
+	req *reportpb.SearchRequest,
+	stream reportpb.ReportQuery_StreamReportsServer,
+) error {
+	filter, err := requestFilter(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	reports, errChan := srv.db.StreamReports(ctx, filter, streamBatchSize)
+	for r := range reports {
+		if err := stream.Send(reportMessage(r)); err != nil {
+			// Client disconnected, stop reading from DB
+			return err
+		}
+	}
+	if err := <-errChan; err != nil {
+		return rpcError(err, "Unable to stream reports")
+	}
+	return nil
+}
+
+// SearchMetrics returns a page of the Metrics matching req.
+func (srv *Server) SearchMetrics(
+	ctx context.Context,
+	req *reportpb.SearchRequest,
+) (*reportpb.MetricPage, error) {
+	filter, err := requestFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	page, err := srv.db.QueryMetricPage(filter, pageOptions(req))
+	if err != nil {
+		return nil, rpcError(err, "Unable to get metric-page")
+	}
+
+	result := &reportpb.MetricPage{
+		Metrics:       make([]*reportpb.Metric, len(page.Metrics)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    page.TotalCount,
+	}
+	for i, m := range page.Metrics {
+		result.Metrics[i] = metricMessage(m)
+	}
+	return result, nil
+}
+
+// StreamMetrics streams the Metrics matching req as they are read from DB.
+func (srv *Server) StreamMetrics(
+	req *reportpb.SearchRequest,
+	stream reportpb.ReportQuery_StreamMetricsServer,
+) error {
+	filter, err := requestFilter(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	metrics, errChan := srv.db.StreamMetrics(ctx, filter, streamBatchSize)
+	for m := range metrics {
+		if err := stream.Send(metricMessage(m)); err != nil {
+			return err
+		}
+	}
+	if err := <-errChan; err != nil {
+		return rpcError(err, "Unable to stream metrics")
+	}
+	return nil
+}
+
+// SearchInventory returns a page of the Inventory matching req.
+func (srv *Server) SearchInventory(
+	ctx context.Context,
+	req *reportpb.SearchRequest,
+) (*reportpb.InventoryPage, error) {
+	filter, err := requestFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	page, err := srv.db.QueryInventoryPage(filter, pageOptions(req))
+	if err != nil {
+		return nil, rpcError(err, "Unable to get inventory-page")
+	}
+
+	result := &reportpb.InventoryPage{
+		Inventory:     make([]*reportpb.Inventory, len(page.Inventory)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    page.TotalCount,
+	}
+	for i, item := range page.Inventory {
+		result.Inventory[i] = inventoryMessage(item)
+	}
+	return result, nil
+}
+
+// StreamInventory streams the Inventory matching req as it is read from DB.
+func (srv *Server) StreamInventory(
+	req *reportpb.SearchRequest,
+	stream reportpb.ReportQuery_StreamInventoryServer,
+) error {
+	filter, err := requestFilter(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	items, errChan := srv.db.StreamInventory(ctx, filter, streamBatchSize)
+	for item := range items {
+		if err := stream.Send(inventoryMessage(item)); err != nil {
+			return err
+		}
+	}
+	if err := <-errChan; err != nil {
+		return rpcError(err, "Unable to stream inventory")
+	}
+	return nil
+}
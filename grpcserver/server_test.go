@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/bhupeshbhatia/go-report-query/reportpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failingDB fails all page-searches with err.
+type failingDB struct {
+	report.DBI
+	err error
+}
+
+func (db *failingDB) QueryPage(filter report.Filter, opts report.PageOptions) (*report.ReportPage, error) {
+	return nil, db.err
+}
+
+func TestSearchReportsErrors(t *testing.T) {
+	byDate := []*reportpb.SearchByDate{{StartDate: 1, EndDate: 2}}
+	testCases := []struct {
+		name string
+		db   report.DBI
+		req  *reportpb.SearchRequest
+		want codes.Code
+	}{
+		{
+			name: "no search",
+			db:   &failingDB{},
+			req:  &reportpb.SearchRequest{},
+			want: codes.InvalidArgument,
+		},
+		{
+			// Page-options are checked before the collection is searched
+			name: "bad page-token",
+			db:   &report.DB{},
+			req: &reportpb.SearchRequest{
+				ByDate: byDate,
+				Page:   &reportpb.PageOptions{PageToken: "not a token!"},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "bad limit",
+			db:   &report.DB{},
+			req: &reportpb.SearchRequest{
+				ByDate: byDate,
+				Page:   &reportpb.PageOptions{Limit: -1},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "bad sort-field",
+			db:   &report.DB{},
+			req: &reportpb.SearchRequest{
+				ByDate: byDate,
+				Page: &reportpb.PageOptions{
+					Sort: []*reportpb.SortField{{Field: "$where"}},
+				},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "DB-error",
+			db:   &failingDB{err: errors.New("connection lost")},
+			req:  &reportpb.SearchRequest{ByDate: byDate},
+			want: codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewServer(tc.db).SearchReports(context.Background(), tc.req)
+		if code := status.Code(err); code != tc.want {
+			t.Errorf("%s: got code %s (%v), want %s", tc.name, code, err, tc.want)
+		}
+	}
+}
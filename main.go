@@ -1,51 +1,99 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"net/smtp"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/TerrexTech/go-commonutils/commonutil"
+	"github.com/TerrexTech/uuuid"
 
 	"github.com/bhupeshbhatia/go-agg-inventory-v2/model"
+	"github.com/bhupeshbhatia/go-report-query/alert"
+	"github.com/bhupeshbhatia/go-report-query/export"
+	"github.com/bhupeshbhatia/go-report-query/gql"
+	"github.com/bhupeshbhatia/go-report-query/grpcserver"
+	"github.com/bhupeshbhatia/go-report-query/metrics"
+	"github.com/bhupeshbhatia/go-report-query/middleware"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/bhupeshbhatia/go-report-query/schedule"
+	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 const AGGREGATE_ID = 2
 
 type Env struct {
-	db model.Datastore
+	db       model.Datastore
+	reportDB report.DBI
+	alerts   *alert.Engine
+	graphql  http.Handler
 }
 
 func ErrorStackTrace(err error) string {
 	return fmt.Sprintf("%+v\n", err)
 }
 
-// func initRoutes() *mux.Router {
-// 	router := mux.NewRouter()
-// 	router = setAuthenticationRoute(router)
-// 	return router
-// }
-
-// func setAuthenticationRoute(router *mux.Router, env *Env) *mux.Router {
-// 	// router.HandleFunc("/add-product", service.AddInventory).Methods("POST", "OPTIONS")
-// 	// router.HandleFunc("/update-product", service.UpdateInventory).Methods("POST", "OPTIONS")
-// 	// router.HandleFunc("/delete-product", service.DeleteInventory).Methods("POST", "OPTIONS")
-// 	// router.HandleFunc("/search-range", service.TimeSearchInTable).Methods("POST", "OPTIONS")
-// 	router.HandleFunc("/create-data", env.LoadDataInMongo).Methods("GET", "OPTIONS")
-// 	router.HandleFunc("/load-table", env.LoadInventoryTable).Methods("POST", "OPTIONS")
-// 	// router.HandleFunc("/dist-weight", service.DistributionByWeight).Methods("GET", "OPTIONS")
-// 	// router.HandleFunc("/twsalewaste", service.TotalWeightSoldWasteDonatePerDay).Methods("POST", "OPTIONS")
-// 	// router.HandleFunc("/search-table", service.SearchInvTable).Methods("POST", "OPTIONS")
+// initRoutes routes the endpoints by path and method. Requests with other
+// methods are answered with "405 Method Not Allowed". Each route is
+// instrumented with request-counters, which are served at "/metrics"
+// along with live location-gauges.
+func initRoutes(env *Env, m *metrics.Metrics) *mux.Router {
+	router := mux.NewRouter()
+	handle := func(path string, h http.HandlerFunc, methods ...string) {
+		router.HandleFunc(path, m.Instrument(path, h)).Methods(methods...)
+	}
+	router.Handle("/metrics", m.Handler()).Methods("GET")
+
+	handle("/create-data", env.LoadDataInMongo, "GET")
+	handle("/gen-data", env.GenDataForAdd, "GET")
+	handle("/load-table", env.LoadInventoryTable, "POST")
+	handle("/search-inv", env.SearchTable, "POST")
+	handle("/add-inv", env.AddInv, "POST")
+	handle("/up-inv", env.UpdateInv, "POST", "PUT")
+	handle("/del-inv", env.DeleteInv, "POST", "DELETE")
+	handle("/total-inv", env.TotalGraph, "POST")
+	handle("/sold-inv", env.SoldPerHr, "POST")
+	handle("/dist-inv", env.DistWeight, "GET", "POST")
+	// Aggregated forms of the charts above, with the shapes documented on each handler
+	handle("/total-inv-stats", env.TotalGraphStats, "POST")
+	handle("/sold-inv-stats", env.SoldPerHrStats, "POST")
+	handle("/dist-inv-stats", env.DistWeightStats, "GET", "POST")
+	handle("/agg-stats", env.AggStats, "POST")
+
+	handle("/report-types", env.ReportTypes, "GET")
+	handle("/reports/{type}", env.RunReport, "POST")
+	// Each report-type is also served at "/<type>-report", such as "/waste-report"
+	for _, t := range report.ReportTypes() {
+		handle("/"+t.Name()+"-report", env.reportHandler(t), "POST")
+	}
+	handle("/report-snapshots", env.ReportSnapshots, "GET")
+	handle("/report-snapshots/{report_id}", env.ReportSnapshots, "GET")
+	handle("/report-snapshots/{report_id}/{version}", env.ReportSnapshots, "GET")
 
-// 	// router.HandleFunc("/perhr-sale", service.ProdSoldPerHour).Methods("POST", "OPTIONS")
+	handle("/alerts", env.ActiveAlerts, "GET")
+	handle("/graphql", env.graphql.ServeHTTP, "POST")
+	return router
+}
 
-// 	return router
-// }
+// param is the path-parameter name, or else the query-param name.
+func param(r *http.Request, name string) string {
+	if v, ok := mux.Vars(r)[name]; ok {
+		return v
+	}
+	return r.URL.Query().Get(name)
+}
 
 func main() {
 	err := godotenv.Load()
@@ -90,42 +138,102 @@ func main() {
 		return
 	}
 
-	//This Env is in file route_handlers.go
-	env := &Env{db}
+	reportCollection := os.Getenv("MONGO_REPORT_COLLECTION")
+	if reportCollection == "" {
+		reportCollection = "report"
+	}
+	metricCollection := os.Getenv("MONGO_METRIC_COLLECTION")
+	if metricCollection == "" {
+		metricCollection = "metric"
+	}
 
-	// router := mux.NewRouter()
-	// router = setAuthenticationRoute(router, env)
+	reportConfig := report.DBIConfig{
+		Hosts:               *commonutil.ParseHosts(hosts),
+		Username:            username,
+		Password:            password,
+		TimeoutMilliseconds: 3000,
+		Database:            database,
+		Collection:          reportCollection,
+		MetricCollection:    metricCollection,
+		InventoryCollection: collection,
+	}
+
+	reportDB, err := report.NewDB(reportConfig)
+	if err != nil {
+		err = errors.Wrap(err, "Error connecting to Report DB")
+		log.Println(err)
+		return
+	}
 
-	// n := negroni.Classic()
-	// n.UseHandler(router)
+	//This Env is in file route_handlers.go
+	env := &Env{
+		db:       db,
+		reportDB: reportDB,
+	}
 
-	// http.ListenAndServe(":8080", n)
+	schema, err := gql.NewSchema(reportDB)
+	if err != nil {
+		err = errors.Wrap(err, "Error parsing GraphQL-schema")
+		log.Println(err)
+		return
+	}
+	env.graphql = gql.Handler(schema)
+
+	rulesFile := os.Getenv("ALERT_RULES_FILE")
+	if rulesFile != "" {
+		env.alerts, err = newAlertEngine(reportDB, rulesFile)
+		if err != nil {
+			err = errors.Wrap(err, "Error creating alert-engine")
+			log.Println(err)
+			return
+		}
+		interval := 60
+		if v := os.Getenv("ALERT_INTERVAL_SECONDS"); v != "" {
+			interval, err = strconv.Atoi(v)
+			if err != nil || interval <= 0 {
+				log.Fatalf("Error: ALERT_INTERVAL_SECONDS must be a positive integer")
+			}
+		}
+		go env.alerts.Run(context.Background(), time.Duration(interval)*time.Second)
+	}
 
-	http.HandleFunc("/create-data", env.LoadDataInMongo)
-	http.HandleFunc("/load-table", env.LoadInventoryTable)
-	http.HandleFunc("/add-inv", env.AddInv)
-	http.HandleFunc("/up-inv", env.UpdateInv)
-	http.HandleFunc("/del-inv", env.DeleteInv)
-	http.HandleFunc("/total-inv", env.TotalGraph)
-	http.HandleFunc("/sold-inv", env.SoldPerHr)
-	http.HandleFunc("/dist-inv", env.DistWeight)
-	http.HandleFunc("/search-inv", env.SearchTable)
-	http.HandleFunc("/gen-data", env.GenDataForAdd)
-	http.ListenAndServe(":8080", nil)
-}
+	schedulesFile := os.Getenv("REPORT_SCHEDULES_FILE")
+	if schedulesFile != "" {
+		defs, err := schedule.LoadDefinitions(schedulesFile)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		scheduler, err := schedule.NewScheduler(reportDB, defs)
+		if err != nil {
+			err = errors.Wrap(err, "Error creating report-scheduler")
+			log.Println(err)
+			return
+		}
+		go scheduler.Run(context.Background())
+	}
 
-func (env *Env) LoadDataInMongo(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	// The gRPC-service is served alongside the HTTP-endpoints, if GRPC_ADDR is set
+	grpcAddr := os.Getenv("GRPC_ADDR")
+	if grpcAddr != "" {
+		go serveGRPC(grpcAddr, reportDB)
 	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
+
+	// Cross-origin requests are allowed from the comma-separated
+	// CORS_ALLOWED_ORIGINS, or from any origin if it is "*".
+	// Any origin is allowed if it is not set, as before the allow-list.
+	allowedOrigins := middleware.ParseOrigins(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	if len(allowedOrigins) == 0 {
+		log.Println("Warning: CORS_ALLOWED_ORIGINS not set, allowing cross-origin requests from any origin")
+		allowedOrigins = []string{"*"}
 	}
 
+	router := initRoutes(env, metrics.New(reportDB))
+	n := middleware.Chain(router, middleware.CORS(allowedOrigins))
+	http.ListenAndServe(":8080", n)
+}
+
+func (env *Env) LoadDataInMongo(w http.ResponseWriter, r *http.Request) {
 	// DB connection
 	insertedData, err := env.db.CreateDataMongo(100)
 	if err != nil {
@@ -137,17 +245,6 @@ func (env *Env) LoadDataInMongo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) GenDataForAdd(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
-	}
-
 	totalResult, err := env.db.GenForAddInv()
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
@@ -159,15 +256,10 @@ func (env *Env) GenDataForAdd(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) LoadInventoryTable(w http.ResponseWriter, r *http.Request) {
-
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
 		return
 	}
 
@@ -178,6 +270,41 @@ func (env *Env) LoadInventoryTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pageOpts, err := report.ParsePageOptions(r.URL.Query())
+	if err != nil {
+		err = errors.Wrap(err, "Unable to parse page-options - LoadInvTable")
+		log.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if pageOpts != nil || format != export.FormatJSON {
+		search := report.SearchByDate{}
+		err = json.Unmarshal(body, &search)
+		if err != nil {
+			err = errors.Wrap(err, "Unable to unmarshal SearchByDate - LoadInvTable")
+			log.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		filter, err := report.TimestampFilter([]report.SearchByDate{search})
+		if err != nil {
+			err = errors.Wrap(err, "Unable to create filter - LoadInvTable")
+			log.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if format == export.FormatNDJSON {
+			env.streamInventory(w, r, filter)
+			return
+		}
+		if pageOpts == nil {
+			env.writeInventory(w, format, filter)
+			return
+		}
+		env.writeInventoryPage(w, format, filter, *pageOpts)
+		return
+	}
+
 	totalResult, err := env.db.SearchByDate(body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to get results - LoadInvTable")
@@ -189,14 +316,10 @@ func (env *Env) LoadInventoryTable(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) SearchTable(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
 		return
 	}
 
@@ -207,6 +330,41 @@ func (env *Env) SearchTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pageOpts, err := report.ParsePageOptions(r.URL.Query())
+	if err != nil {
+		err = errors.Wrap(err, "Unable to parse page-options - SearchTable")
+		log.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if pageOpts != nil || format != export.FormatJSON {
+		search := []report.SearchByFieldVal{}
+		err = json.Unmarshal(body, &search)
+		if err != nil {
+			err = errors.Wrap(err, "Unable to unmarshal SearchByFieldVal - SearchTable")
+			log.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		filter, err := report.FieldValFilter(search)
+		if err != nil {
+			err = errors.Wrap(err, "Unable to create filter - SearchTable")
+			log.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if format == export.FormatNDJSON {
+			env.streamInventory(w, r, filter)
+			return
+		}
+		if pageOpts == nil {
+			env.writeInventory(w, format, filter)
+			return
+		}
+		env.writeInventoryPage(w, format, filter, *pageOpts)
+		return
+	}
+
 	invAfterSearch, err := env.db.SearchByKeyVal(body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
@@ -217,18 +375,139 @@ func (env *Env) SearchTable(w http.ResponseWriter, r *http.Request) {
 	w.Write(invAfterSearch)
 }
 
-func (env *Env) AddInv(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+// streamBatchSize is the number of documents read from DB at a time when streaming.
+const streamBatchSize = 500
+
+// streamInventory writes the Inventory matching filter as newline-delimited
+// JSON while it is read from DB. The response is flushed whenever the next
+// batch is being read, so clients can process the items as they arrive.
+// An error after streaming starts is written as a final {"error": "..."} line.
+func (env *Env) streamInventory(w http.ResponseWriter, r *http.Request, filter report.Filter) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	items, errChan := env.reportDB.StreamInventory(ctx, filter, streamBatchSize)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	count := 0
+	for {
+		var item report.Inventory
+		var ok bool
+		select {
+		case item, ok = <-items:
+		default:
+			// Next batch is being read
+			if flusher != nil && count > 0 {
+				flusher.Flush()
+			}
+			item, ok = <-items
+		}
+		if !ok {
+			break
+		}
+
+		// MarshalJSON has a pointer-receiver
+		err := encoder.Encode(&item)
+		if err != nil {
+			// Client disconnected, stop reading from DB
+			err = errors.Wrap(err, "Unable to write inventory-stream")
+			log.Println(err)
+			return
+		}
+		count++
 	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
+
+	if err := <-errChan; err != nil {
+		err = errors.Wrap(err, "Unable to stream inventory")
+		log.Println(err)
+		if count == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		encoder.Encode(map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// writeInventoryPage writes the page of Inventory matching filter in format.
+// This is used by the table-handlers when paging is requested.
+func (env *Env) writeInventoryPage(
+	w http.ResponseWriter,
+	format string,
+	filter report.Filter,
+	opts report.PageOptions,
+) {
+	page, err := env.reportDB.QueryInventoryPage(filter, opts)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to get inventory-page")
+		log.Println(err)
+		if _, ok := errors.Cause(err).(*report.InvalidPageError); ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if format != export.FormatJSON {
+		writeDocument(w, format, "inventory", inventoryDocument(page.Inventory))
+		return
+	}
+	writeJSON(w, page)
+}
+
+// writeInventory writes all Inventory matching filter in format.
+// This is used by the table-handlers to export unpaged results.
+func (env *Env) writeInventory(w http.ResponseWriter, format string, filter report.Filter) {
+	items, err := env.reportDB.QueryInventory(filter)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to get inventory for export")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeDocument(w, format, "inventory", inventoryDocument(*items))
+}
+
+// writeDocument writes doc in format, as a file named filename.
+func writeDocument(w http.ResponseWriter, format string, filename string, doc export.Document) {
+	err := export.Write(w, format, filename, doc)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to export "+filename)
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// inventoryDocument creates the Document for exporting Inventory search-results.
+func inventoryDocument(items []report.Inventory) export.Document {
+	return export.Document{
+		Title:       "Inventory",
+		GeneratedAt: time.Now(),
+		Tables:      []report.Table{report.InventoryTable(items)},
+	}
+}
+
+// writeJSON writes v as JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	result, err := json.Marshal(v)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to marshal response")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(result)
+}
 
+func (env *Env) AddInv(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
@@ -248,17 +527,6 @@ func (env *Env) AddInv(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) UpdateInv(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
-	}
-
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
@@ -277,17 +545,6 @@ func (env *Env) UpdateInv(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) DeleteInv(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
-	}
-
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
@@ -303,28 +560,34 @@ func (env *Env) DeleteInv(w http.ResponseWriter, r *http.Request) {
 	w.Write(delResult)
 }
 
-func (env *Env) TotalGraph(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
-	}
-
+// chartWindow reads the date-range of the charts from request-body,
+// which is a JSON-encoded report.SearchByDate. The Interval by which the
+// charts are bucketed can be specified by the "interval" query-param.
+func chartWindow(r *http.Request, interval report.Interval) (report.SearchByDate, report.Interval, error) {
+	window := report.SearchByDate{}
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
-		log.Println(err)
-		return
+		return window, "", err
 	}
+	err = json.Unmarshal(body, &window)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to unmarshal date-range")
+		return window, "", err
+	}
+	if window.EndDate == 0 {
+		return window, "", errors.New("end_date is required")
+	}
+	if v := r.URL.Query().Get("interval"); v != "" {
+		interval = report.Interval(v)
+	}
+	return window, interval, nil
+}
 
-	_, err = env.db.CreateDataMongo(1)
+func (env *Env) TotalGraph(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		err = errors.Wrap(err, "Unable to insert new data - TotalGraph")
+		err = errors.Wrap(err, "Unable to read the request body")
 		log.Println(err)
 		return
 	}
@@ -344,17 +607,6 @@ func (env *Env) TotalGraph(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) SoldPerHr(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
-	}
-
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		err = errors.Wrap(err, "Unable to read the request body")
@@ -377,17 +629,6 @@ func (env *Env) SoldPerHr(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env *Env) DistWeight(w http.ResponseWriter, r *http.Request) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-	}
-	// Stop here if its Preflighted OPTIONS request
-	if r.Method == "OPTIONS" {
-		return
-	}
-
 	// body, err := ioutil.ReadAll(r.Body)
 	// if err != nil {
 	// 	err = errors.Wrap(err, "Unable to read the request body")
@@ -402,6 +643,367 @@ func (env *Env) DistWeight(w http.ResponseWriter, r *http.Request) {
 	w.Write(results)
 }
 
+// TotalGraphStats is the aggregated form of TotalGraph: the total, sold,
+// wasted and donated weight of Inventory in the date-range, as
+// report.WeightTotals bucketed by day (or the "interval" query-param).
+func (env *Env) TotalGraphStats(w http.ResponseWriter, r *http.Request) {
+	window, interval, err := chartWindow(r, report.IntervalDay)
+	if err != nil {
+		err = errors.Wrap(err, "Invalid request - TotalGraphStats")
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	totals, err := env.reportDB.InventoryWeights(
+		interval,
+		report.TimeRange("timestamp", window.StartDate, window.EndDate),
+	)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to aggregate weights - TotalGraphStats")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, totals)
+}
+
+// SoldPerHrStats is the aggregated form of SoldPerHr: the weight sold per
+// product ("name") in each hour of the date-range, as report.StatsBuckets
+// of "sold_weight" by "date_sold".
+func (env *Env) SoldPerHrStats(w http.ResponseWriter, r *http.Request) {
+	window, interval, err := chartWindow(r, report.IntervalHour)
+	if err != nil {
+		err = errors.Wrap(err, "Invalid request - SoldPerHrStats")
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := env.reportDB.InventoryStats(report.StatsQuery{
+		Field:     "sold_weight",
+		Interval:  interval,
+		TimeField: "date_sold",
+		GroupBy:   []string{"name"},
+		Filter:    report.TimeRange("date_sold", window.StartDate, window.EndDate),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Unable to aggregate sold-weight - SoldPerHrStats")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+// DistWeightStats is the aggregated form of DistWeight: the distribution of
+// Inventory-weight per product ("name"), as report.StatsBuckets of "total_weight".
+func (env *Env) DistWeightStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := env.reportDB.InventoryStats(report.StatsQuery{
+		Field:   "total_weight",
+		GroupBy: []string{"name"},
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Unable to aggregate weight - DistWeightStats")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+// statsRequest is the request-body for AggStats.
+// Source is the collection to aggregate: "report", "metric" or "inventory".
+type statsRequest struct {
+	Source string `json:"source"`
+	report.StatsQuery
+	report.SearchByDate
+}
+
+func (env *Env) AggStats(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to read the request body")
+		log.Println(err)
+		return
+	}
+
+	statsReq := statsRequest{}
+	err = json.Unmarshal(body, &statsReq)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to unmarshal stats-request - AggStats")
+		log.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	query := statsReq.StatsQuery
+	timeField := query.TimeField
+	if timeField == "" {
+		timeField = "timestamp"
+	}
+	query.Filter = report.TimeRange(timeField, statsReq.StartDate, statsReq.EndDate)
+
+	var stats []report.StatsBucket
+	switch statsReq.Source {
+	case "report":
+		stats, err = env.reportDB.ReportStats(query)
+	case "metric":
+		stats, err = env.reportDB.MetricStats(query)
+	case "inventory", "":
+		stats, err = env.reportDB.InventoryStats(query)
+	default:
+		log.Printf("Unknown stats-source: %s", statsReq.Source)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "Unable to aggregate stats - AggStats")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, stats)
+}
+
+// ReportTypes lists the available report-types and their parameters.
+func (env *Env) ReportTypes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, report.ListReportTypes())
+}
+
+// reportTitle is the title of documents for ReportType t, such as "Waste report".
+func reportTitle(t report.ReportType) string {
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:] + " report"
+}
+
+// RunReport generates the report-type named in path "/reports/{type}".
+func (env *Env) RunReport(w http.ResponseWriter, r *http.Request) {
+	t, ok := report.LookupReportType(mux.Vars(r)["type"])
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	env.reportHandler(t)(w, r)
+}
+
+// reportHandler generates a report of ReportType t, with the
+// JSON-encoded report-parameters in request-body. The report is compared
+// against a previous window if "compare" (previous, day, week, month or year)
+// or "prev_start_date" and "prev_end_date" query-params are set.
+// The report's tables are exported as CSV or XLSX, or rendered as an HTML or
+// PDF document, if requested by the "format" query-param or Accept-header.
+// Documents show the name in "customer" query-param in their header.
+func (env *Env) reportHandler(t report.ReportType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := export.Negotiate(r)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusNotAcceptable)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			err = errors.Wrap(err, "Unable to read the request body")
+			log.Println(err)
+			return
+		}
+
+		query, err := report.ParseQuery(t, body)
+		if err == nil {
+			err = query.Validate()
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "Invalid params for %s-report", t.Name())
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		params := r.URL.Query()
+		if params.Get("compare") != "" || params.Get("prev_end_date") != "" {
+			opts := report.CompareOptions{
+				Compare: params.Get("compare"),
+			}
+			opts.PrevStartDate, _ = strconv.ParseInt(params.Get("prev_start_date"), 10, 64)
+			opts.PrevEndDate, _ = strconv.ParseInt(params.Get("prev_end_date"), 10, 64)
+			if err = opts.Validate(); err != nil {
+				err = errors.Wrapf(err, "Invalid comparison for %s-report", t.Name())
+				log.Println(err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			comparison, err := report.CompareReport(env.reportDB, t, query, opts)
+			if err != nil {
+				err = errors.Wrapf(err, "Unable to compare %s-report", t.Name())
+				log.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if format != export.FormatJSON {
+				writeDocument(w, format, t.Name()+"-comparison", export.Document{
+					Title:          reportTitle(t) + " comparison",
+					Customer:       params.Get("customer"),
+					Period:         comparison.Current,
+					PreviousPeriod: comparison.Previous,
+					GeneratedAt:    time.Now(),
+					Tables:         comparison.Tables,
+				})
+				return
+			}
+			writeJSON(w, comparison)
+			return
+		}
+
+		result, err := t.Compute(env.reportDB, query)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to generate %s-report", t.Name())
+			log.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if format != export.FormatJSON {
+			tables, err := t.Render(result)
+			if err != nil {
+				err = errors.Wrapf(err, "Unable to render %s-report", t.Name())
+				log.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			period := query.Window()
+			if period.EndDate == 0 {
+				period.EndDate = time.Now().Unix()
+			}
+			writeDocument(w, format, t.Name()+"-report", export.Document{
+				Title:       reportTitle(t),
+				Customer:    params.Get("customer"),
+				Period:      period,
+				GeneratedAt: time.Now(),
+				Tables:      tables,
+			})
+			return
+		}
+		writeJSON(w, result)
+	}
+}
+
+// newAlertEngine creates the alert-engine for rules in rulesFile.
+// Alerts are always logged, and are also sent to the webhook and
+// email-recipients configured in env-vars.
+func newAlertEngine(reportDB report.DBI, rulesFile string) (*alert.Engine, error) {
+	rules, err := alert.LoadRules(rulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers := []alert.Notifier{alert.LogNotifier{}}
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, &alert.WebhookNotifier{
+			URL: url,
+		})
+	}
+	if addr := os.Getenv("ALERT_SMTP_ADDR"); addr != "" {
+		to := splitList(os.Getenv("ALERT_SMTP_TO"))
+		if len(to) == 0 {
+			return nil, errors.New("ALERT_SMTP_TO must list the email-recipients if ALERT_SMTP_ADDR is set")
+		}
+		notifier := &alert.SMTPNotifier{
+			Addr: addr,
+			From: os.Getenv("ALERT_SMTP_FROM"),
+			To:   to,
+		}
+		if username := os.Getenv("ALERT_SMTP_USERNAME"); username != "" {
+			host := strings.Split(addr, ":")[0]
+			notifier.Auth = smtp.PlainAuth("", username, os.Getenv("ALERT_SMTP_PASSWORD"), host)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return alert.NewEngine(reportDB, rules, notifiers...)
+}
+
+// splitList splits the comma-separated list, such as
+// "a@example.com, b@example.com", dropping empty entries.
+func splitList(list string) []string {
+	var parsed []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			parsed = append(parsed, v)
+		}
+	}
+	return parsed
+}
+
+// serveGRPC serves the ReportQuery gRPC-service on addr.
+func serveGRPC(addr string, reportDB report.DBI) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		err = errors.Wrap(err, "Error listening for gRPC-requests")
+		log.Println(err)
+		return
+	}
+
+	s := grpc.NewServer()
+	grpcserver.NewServer(reportDB).Register(s)
+	err = s.Serve(listener)
+	if err != nil {
+		err = errors.Wrap(err, "Error serving gRPC-requests")
+		log.Println(err)
+	}
+}
+
+func (env *Env) ActiveAlerts(w http.ResponseWriter, r *http.Request) {
+	if env.alerts == nil {
+		writeJSON(w, []alert.Alert{})
+		return
+	}
+	writeJSON(w, env.alerts.Active())
+}
+
+// ReportSnapshots fetches the snapshots for report_id, given in path
+// "/report-snapshots/{report_id}" or as query-param. All versions are
+// returned, latest first, unless a specific version is requested.
+func (env *Env) ReportSnapshots(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuuid.FromString(param(r, "report_id"))
+	if err != nil {
+		err = errors.Wrap(err, "Unable to parse report_id - ReportSnapshots")
+		log.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if v := param(r, "version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		snapshot, err := env.reportDB.ReportSnapshot(reportID, version)
+		if err != nil {
+			err = errors.Wrap(err, "Unable to fetch report-snapshot")
+			log.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if snapshot == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, snapshot)
+		return
+	}
+
+	snapshots, err := env.reportDB.ReportSnapshots(reportID)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to fetch report-snapshots")
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, snapshots)
+}
+
 //----------------------------------------------------------
 
 //Calling Mongo
@@ -0,0 +1,212 @@
+// Package metrics exposes live report-gauges and service-metrics
+// in the Prometheus/OpenMetrics exposition-format.
+package metrics
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all metrics.
+const namespace = "report_query"
+
+var locationLabels = []string{"location"}
+
+// Descriptions of location-gauges.
+var (
+	tempDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "location", "temperature_celsius"),
+		"Average of latest temperature-readings at location.",
+		locationLabels, nil,
+	)
+	humidityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "location", "humidity_percent"),
+		"Average of latest humidity-readings at location.",
+		locationLabels, nil,
+	)
+	ethyleneDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "location", "ethylene_ppm"),
+		"Average of latest ethylene-readings at location.",
+		locationLabels, nil,
+	)
+	carbonDiDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "location", "co2_ppm"),
+		"Average of latest CO2-readings at location.",
+		locationLabels, nil,
+	)
+	devicesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "location", "devices"),
+		"Number of devices at location with a recent reading.",
+		locationLabels, nil,
+	)
+	readingTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "location", "last_reading_timestamp_seconds"),
+		"Unix-timestamp of latest reading at location.",
+		locationLabels, nil,
+	)
+	itemsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "items"),
+		"Number of on-hand inventory-items at location.",
+		locationLabels, nil,
+	)
+	onHandDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "on_hand_weight"),
+		"Weight of inventory still on hand at location.",
+		locationLabels, nil,
+	)
+	wasteDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "waste_weight"),
+		"Weight of inventory wasted at location.",
+		locationLabels, nil,
+	)
+	donateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "donated_weight"),
+		"Weight of inventory donated at location.",
+		locationLabels, nil,
+	)
+	scrapeErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "gauges", "scrape_error"),
+		"1 if loading the location-gauges failed, else 0.",
+		nil, nil,
+	)
+)
+
+// Collector collects the current report.LocationGauges on each scrape,
+// so the gauges are always calculated from the latest data.
+type Collector struct {
+	db report.DBI
+}
+
+// NewCollector creates a Collector for the location-gauges of db.
+func NewCollector(db report.DBI) *Collector {
+	return &Collector{
+		db: db,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tempDesc
+	ch <- humidityDesc
+	ch <- ethyleneDesc
+	ch <- carbonDiDesc
+	ch <- devicesDesc
+	ch <- readingTimeDesc
+	ch <- itemsDesc
+	ch <- onHandDesc
+	ch <- wasteDesc
+	ch <- donateDesc
+	ch <- scrapeErrorDesc
+}
+
+// Collect implements prometheus.Collector. Readings are only reported
+// for locations with a recent reading.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	gauges, err := c.db.LocationGauges(0)
+	if err != nil {
+		err = errors.Wrap(err, "Error collecting location-gauges")
+		log.Println(err)
+		ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, 1)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, 0)
+
+	gauge := func(desc *prometheus.Desc, value float64, location string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, location)
+	}
+	for _, g := range gauges {
+		gauge(devicesDesc, float64(g.Devices), g.Location)
+		if g.Devices > 0 {
+			gauge(tempDesc, g.TempIn, g.Location)
+			gauge(humidityDesc, g.Humidity, g.Location)
+			gauge(ethyleneDesc, g.Ethylene, g.Location)
+			gauge(carbonDiDesc, g.CarbonDi, g.Location)
+			gauge(readingTimeDesc, float64(g.Timestamp), g.Location)
+		}
+		gauge(itemsDesc, float64(g.Items), g.Location)
+		gauge(onHandDesc, g.OnHandWeight, g.Location)
+		gauge(wasteDesc, g.WasteWeight, g.Location)
+		gauge(donateDesc, g.DonateWeight, g.Location)
+	}
+}
+
+// Metrics is a registry of the location-gauges and service-metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// New creates the Metrics for db, including Go-runtime and process-metrics.
+func New(db report.DBI) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "requests_total",
+				Help:      "Number of HTTP-requests by handler, method and status-code.",
+			},
+			[]string{"handler", "method", "code"},
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "request_duration_seconds",
+				Help:      "Duration of HTTP-requests by handler.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"handler"},
+		),
+		inFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "requests_in_flight",
+				Help:      "Number of HTTP-requests currently being served by handler.",
+			},
+			[]string{"handler"},
+		),
+	}
+	m.registry.MustRegister(
+		NewCollector(db),
+		m.requests,
+		m.duration,
+		m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Instrument wraps h to count its requests by status-code and measure their
+// duration. name is the handler-label, usually the route's path.
+func (m *Metrics) Instrument(name string, h http.HandlerFunc) http.HandlerFunc {
+	labels := prometheus.Labels{"handler": name}
+	instrumented := promhttp.InstrumentHandlerInFlight(
+		m.inFlight.With(labels),
+		promhttp.InstrumentHandlerDuration(
+			m.duration.MustCurryWith(labels),
+			promhttp.InstrumentHandlerCounter(m.requests.MustCurryWith(labels), h),
+		),
+	)
+	return instrumented.ServeHTTP
+}
+
+// Handler serves the Metrics. The OpenMetrics-format is served
+// if requested by the scraper, else the Prometheus text-format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+		ErrorLog:          log.New(log.Writer(), "metrics: ", log.LstdFlags),
+	})
+}
@@ -0,0 +1,88 @@
+// Package middleware provides the HTTP-middleware wrapped around
+// the router, so handlers only contain the request's business-logic.
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps a Handler with additional behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the middleware. The first middleware is the
+// outermost, and so sees each request before the others.
+func Chain(h http.Handler, mw ...Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+const (
+	corsMethods = "POST, GET, OPTIONS, PUT, DELETE"
+	corsHeaders = "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization"
+)
+
+// CORS allows cross-origin requests from the allowedOrigins, and answers
+// their preflighted OPTIONS-requests before these reach the router.
+// An origin of "*" allows requests from any origin.
+// Requests from other origins are served without CORS-headers,
+// and their preflight-requests are rejected.
+func CORS(allowedOrigins []string) Middleware {
+	allowAll := false
+	allowed := map[string]bool{}
+	for _, o := range allowedOrigins {
+		o = strings.TrimSpace(o)
+		if o == "*" {
+			allowAll = true
+		}
+		if o != "" {
+			allowed[strings.ToLower(o)] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Responses differ by origin, so caches must key them by it
+			w.Header().Add("Vary", "Origin")
+			ok := allowAll || allowed[strings.ToLower(origin)]
+
+			preflight := r.Method == http.MethodOptions &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				if !ok {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", corsMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if ok {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParseOrigins splits the comma-separated list of origins, such
+// as "https://a.example.com, https://b.example.com".
+func ParseOrigins(origins string) []string {
+	var parsed []string
+	for _, o := range strings.Split(origins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			parsed = append(parsed, o)
+		}
+	}
+	return parsed
+}
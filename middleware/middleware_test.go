@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// testRouter routes "/reports" for POST only, as the routes of main.
+func testRouter() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+	return router
+}
+
+func serve(h http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/reports", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	h := Chain(testRouter(), CORS([]string{"https://Dashboard.example.com"}))
+	preflight := map[string]string{"Access-Control-Request-Method": "POST"}
+
+	// The router has no OPTIONS-route, so preflights must be answered before it
+	w := serve(h, http.MethodOptions, "https://dashboard.example.com", preflight)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("allowed preflight: got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Errorf("allowed preflight: got Access-Control-Allow-Origin %q", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Methods") != corsMethods {
+		t.Errorf("allowed preflight: got Access-Control-Allow-Methods %q", w.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	w = serve(h, http.MethodOptions, "https://evil.example.com", preflight)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("denied preflight: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("denied preflight: got Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestCORSRequests(t *testing.T) {
+	h := Chain(testRouter(), CORS([]string{"https://dashboard.example.com"}))
+
+	testCases := []struct {
+		name       string
+		origin     string
+		wantOrigin string
+		wantVary   []string
+	}{
+		{"allowed origin", "https://dashboard.example.com", "https://dashboard.example.com", []string{"Origin"}},
+		// Denied origins are served without CORS-headers, so browsers block the response
+		{"denied origin", "https://evil.example.com", "", []string{"Origin"}},
+		{"same origin", "", "", nil},
+	}
+	for _, tc := range testCases {
+		w := serve(h, http.MethodPost, tc.origin, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: got status %d, want %d", tc.name, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.wantOrigin {
+			t.Errorf("%s: got Access-Control-Allow-Origin %q, want %q", tc.name, got, tc.wantOrigin)
+		}
+		if got := w.Header()["Vary"]; !reflect.DeepEqual(got, tc.wantVary) {
+			t.Errorf("%s: got Vary %v, want %v", tc.name, got, tc.wantVary)
+		}
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	h := Chain(testRouter(), CORS(ParseOrigins("*")))
+
+	w := serve(h, http.MethodPost, "https://any.example.com", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://any.example.com" {
+		t.Errorf("got Access-Control-Allow-Origin %q, want the request's origin", got)
+	}
+	w = serve(h, http.MethodOptions, "https://any.example.com", map[string]string{
+		"Access-Control-Request-Method": "POST",
+	})
+	if w.Code != http.StatusNoContent {
+		t.Errorf("preflight: got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	h := Chain(testRouter(), CORS([]string{"https://dashboard.example.com"}))
+
+	// Only preflights are answered by CORS, other methods reach the router
+	for _, method := range []string{http.MethodGet, http.MethodDelete, http.MethodOptions} {
+		w := serve(h, method, "https://dashboard.example.com", nil)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: got status %d, want %d", method, w.Code, http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	got := ParseOrigins(" https://a.example.com, ,https://b.example.com,")
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := ParseOrigins(""); got != nil {
+		t.Errorf("got %v for no origins, want nil", got)
+	}
+}
@@ -0,0 +1,388 @@
+package report
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TerrexTech/go-mongoutils/mongo"
+	"github.com/pkg/errors"
+)
+
+// Interval is the time-period by which statistics are bucketed.
+type Interval string
+
+// Supported Intervals. Buckets are calculated in UTC,
+// and weeks start on Monday (ISO-weeks).
+const (
+	IntervalHour  Interval = "hour"
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+)
+
+// StatsQuery specifies the statistics to be calculated for a numeric field.
+type StatsQuery struct {
+	// Field is the numeric field to calculate statistics for.
+	Field string `json:"field"`
+	// Interval is the time-period to bucket statistics by.
+	// All documents are put in a single bucket if this is empty.
+	Interval Interval `json:"interval,omitempty"`
+	// TimeField is the Unix-timestamp field used for bucketing.
+	// Defaults to "timestamp".
+	TimeField string `json:"time_field,omitempty"`
+	// GroupBy are the additional fields to group statistics by,
+	// such as "report_type", "rs_customer_id" or "location".
+	GroupBy []string `json:"group_by,omitempty"`
+	// Percentiles to calculate, in range (0, 100].
+	// Calculating percentiles requires transferring all values of the
+	// field from DB, so these should only be requested when required.
+	Percentiles []float64 `json:"percentiles,omitempty"`
+	// Filter restricts the documents included in statistics.
+	Filter Filter `json:"-"`
+}
+
+// StatsBucket contains the statistics for a single time-bucket and group.
+type StatsBucket struct {
+	// Bucket is the Unix-timestamp for start of the time-bucket.
+	Bucket int64                  `json:"bucket,omitempty"`
+	Group  map[string]interface{} `json:"group,omitempty"`
+	Count  int64                  `json:"count"`
+	Sum    float64                `json:"sum"`
+	Avg    float64                `json:"avg"`
+	Min    float64                `json:"min"`
+	Max    float64                `json:"max"`
+	// Percentiles are keyed as "p" followed by the percentile, such as "p95".
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+}
+
+// statsResult is the result of stats-aggregation pipeline.
+type statsResult struct {
+	Bucket time.Time              `bson:"bucket,omitempty"`
+	Group  map[string]interface{} `bson:"group,omitempty"`
+	Count  int64                  `bson:"count,omitempty"`
+	Sum    float64                `bson:"sum,omitempty"`
+	Avg    float64                `bson:"avg,omitempty"`
+	Min    float64                `bson:"min,omitempty"`
+	Max    float64                `bson:"max,omitempty"`
+	Values []float64              `bson:"values,omitempty"`
+}
+
+// WeightTotals are the summed Inventory-weights in a time-bucket.
+type WeightTotals struct {
+	// Bucket is the Unix-timestamp for start of the time-bucket.
+	Bucket       int64   `json:"bucket"`
+	TotalWeight  float64 `json:"total_weight"`
+	SoldWeight   float64 `json:"sold_weight"`
+	WasteWeight  float64 `json:"waste_weight"`
+	DonateWeight float64 `json:"donate_weight"`
+}
+
+// weightTotalsResult is the result of weights-aggregation pipeline.
+type weightTotalsResult struct {
+	Bucket       time.Time `bson:"bucket,omitempty"`
+	TotalWeight  float64   `bson:"total_weight,omitempty"`
+	SoldWeight   float64   `bson:"sold_weight,omitempty"`
+	WasteWeight  float64   `bson:"waste_weight,omitempty"`
+	DonateWeight float64   `bson:"donate_weight,omitempty"`
+}
+
+// ReportStats calculates statistics over Reports, excluding Anomaly-reports.
+func (db *DB) ReportStats(query StatsQuery) ([]StatsBucket, error) {
+	query.Filter = withoutAnomalies(query.Filter)
+	return aggregateStats(db.collection, query)
+}
+
+// MetricStats calculates statistics over Metrics.
+func (db *DB) MetricStats(query StatsQuery) ([]StatsBucket, error) {
+	if db.metric == nil {
+		return nil, errors.New("Metric collection is not configured")
+	}
+	return aggregateStats(db.metric, query)
+}
+
+// InventoryStats calculates statistics over Inventory.
+func (db *DB) InventoryStats(query StatsQuery) ([]StatsBucket, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+	return aggregateStats(db.inventory, query)
+}
+
+// InventoryWeights sums the total, sold, wasted and donated weights of the
+// Inventory matching filter, bucketed by interval of their timestamp.
+func (db *DB) InventoryWeights(interval Interval, filter Filter) ([]WeightTotals, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+	pipeline, err := weightsPipeline(interval, filter)
+	if err != nil {
+		err = errors.Wrap(err, "Error creating weights-pipeline")
+		return nil, err
+	}
+
+	weightsColl, err := resultCollection(db.inventory, &weightTotalsResult{})
+	if err != nil {
+		return nil, err
+	}
+	aggResults, err := weightsColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error aggregating weights")
+		log.Println(err)
+		return nil, err
+	}
+
+	totals := []WeightTotals{}
+	for _, v := range aggResults {
+		result, ok := v.(*weightTotalsResult)
+		if !ok {
+			err = errors.New("Error asserting aggregate-result to weightTotalsResult")
+			log.Println(err)
+			return nil, err
+		}
+		totals = append(totals, WeightTotals{
+			Bucket:       result.Bucket.Unix(),
+			TotalWeight:  result.TotalWeight,
+			SoldWeight:   result.SoldWeight,
+			WasteWeight:  result.WasteWeight,
+			DonateWeight: result.DonateWeight,
+		})
+	}
+	return totals, nil
+}
+
+// weightsPipeline sums all weights in a single group per time-bucket.
+func weightsPipeline(interval Interval, filter Filter) ([]interface{}, error) {
+	bucket, err := bucketExpression(interval, "$timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	group := map[string]interface{}{
+		"_id": bucket,
+	}
+	project := map[string]interface{}{
+		"_id":    0,
+		"bucket": "$_id",
+	}
+	for _, field := range []string{"total_weight", "sold_weight", "waste_weight", "donate_weight"} {
+		group[field] = map[string]interface{}{"$sum": "$" + field}
+		project[field] = 1
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"$match": And(filter).Compile(),
+		},
+		map[string]interface{}{"$group": group},
+		map[string]interface{}{"$project": project},
+		map[string]interface{}{
+			"$sort": sortDocument([]SortField{{Field: "bucket"}}),
+		},
+	}, nil
+}
+
+// aggregateStats runs the stats-aggregation pipeline for query on collection.
+func aggregateStats(coll *mongo.Collection, query StatsQuery) ([]StatsBucket, error) {
+	pipeline, err := statsPipeline(query)
+	if err != nil {
+		err = errors.Wrap(err, "Error creating stats-pipeline")
+		return nil, err
+	}
+
+	statsColl, err := resultCollection(coll, &statsResult{})
+	if err != nil {
+		return nil, err
+	}
+	aggResults, err := statsColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error aggregating stats")
+		log.Println(err)
+		return nil, err
+	}
+
+	buckets := []StatsBucket{}
+	for _, v := range aggResults {
+		result, ok := v.(*statsResult)
+		if !ok {
+			err = errors.New("Error asserting aggregate-result to statsResult")
+			log.Println(err)
+			return nil, err
+		}
+
+		bucket := StatsBucket{
+			Group: result.Group,
+			Count: result.Count,
+			Sum:   result.Sum,
+			Avg:   result.Avg,
+			Min:   result.Min,
+			Max:   result.Max,
+		}
+		if query.Interval != "" {
+			bucket.Bucket = result.Bucket.Unix()
+		}
+		if len(query.Percentiles) > 0 {
+			bucket.Percentiles = map[string]float64{}
+			for _, p := range query.Percentiles {
+				key := "p" + strconv.FormatFloat(p, 'f', -1, 64)
+				bucket.Percentiles[key] = percentile(result.Values, p)
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+func statsPipeline(query StatsQuery) ([]interface{}, error) {
+	if !validField(query.Field) {
+		return nil, errors.New("A valid numeric field is required")
+	}
+	timeField := query.TimeField
+	if timeField == "" {
+		timeField = "timestamp"
+	}
+	if !validField(timeField) {
+		return nil, errors.New("Invalid time-field")
+	}
+	for _, p := range query.Percentiles {
+		if p <= 0 || p > 100 {
+			return nil, fmt.Errorf("Percentile %f is not in range (0, 100]", p)
+		}
+	}
+
+	groupID := map[string]interface{}{}
+	if query.Interval != "" {
+		bucket, err := bucketExpression(query.Interval, "$"+timeField)
+		if err != nil {
+			return nil, err
+		}
+		groupID["bucket"] = bucket
+	}
+	for _, g := range query.GroupBy {
+		if !validField(g) {
+			return nil, fmt.Errorf("Invalid group-by field: %s", g)
+		}
+		groupID[g] = "$" + g
+	}
+
+	field := "$" + query.Field
+	group := map[string]interface{}{
+		"_id":   groupID,
+		"count": map[string]interface{}{"$sum": 1},
+		"sum":   map[string]interface{}{"$sum": field},
+		"avg":   map[string]interface{}{"$avg": field},
+		"min":   map[string]interface{}{"$min": field},
+		"max":   map[string]interface{}{"$max": field},
+	}
+	project := map[string]interface{}{
+		"_id":   0,
+		"count": 1,
+		"sum":   1,
+		"avg":   1,
+		"min":   1,
+		"max":   1,
+	}
+	if query.Interval != "" {
+		project["bucket"] = "$_id.bucket"
+	}
+	if len(query.GroupBy) > 0 {
+		groupFields := map[string]interface{}{}
+		for _, g := range query.GroupBy {
+			groupFields[g] = "$_id." + g
+		}
+		project["group"] = groupFields
+	}
+
+	filter := And(query.Filter, Exists(query.Field, true))
+	pipeline := []interface{}{
+		map[string]interface{}{
+			"$match": filter.Compile(),
+		},
+	}
+	if len(query.Percentiles) > 0 {
+		// Values are pushed in sorted order, so percentiles can be read by index
+		group["values"] = map[string]interface{}{"$push": field}
+		project["values"] = 1
+		pipeline = append(pipeline, map[string]interface{}{
+			"$sort": sortDocument([]SortField{{Field: query.Field}}),
+		})
+	}
+
+	sort := []SortField{{Field: "bucket"}}
+	for _, g := range query.GroupBy {
+		sort = append(sort, SortField{Field: "group." + g})
+	}
+	pipeline = append(
+		pipeline,
+		map[string]interface{}{"$group": group},
+		map[string]interface{}{"$project": project},
+		map[string]interface{}{"$sort": sortDocument(sort)},
+	)
+	return pipeline, nil
+}
+
+// bucketExpression creates the aggregation-expression which converts
+// the Unix-timestamp field to start-date of its Interval.
+func bucketExpression(interval Interval, timeField string) (interface{}, error) {
+	date := map[string]interface{}{
+		"$add": []interface{}{
+			time.Unix(0, 0).UTC(),
+			map[string]interface{}{
+				"$multiply": []interface{}{timeField, 1000},
+			},
+		},
+	}
+
+	var parts map[string]interface{}
+	switch interval {
+	case IntervalHour:
+		parts = map[string]interface{}{
+			"year":  map[string]interface{}{"$year": date},
+			"month": map[string]interface{}{"$month": date},
+			"day":   map[string]interface{}{"$dayOfMonth": date},
+			"hour":  map[string]interface{}{"$hour": date},
+		}
+	case IntervalDay:
+		parts = map[string]interface{}{
+			"year":  map[string]interface{}{"$year": date},
+			"month": map[string]interface{}{"$month": date},
+			"day":   map[string]interface{}{"$dayOfMonth": date},
+		}
+	case IntervalWeek:
+		parts = map[string]interface{}{
+			"isoWeekYear": map[string]interface{}{"$isoWeekYear": date},
+			"isoWeek":     map[string]interface{}{"$isoWeek": date},
+		}
+	case IntervalMonth:
+		parts = map[string]interface{}{
+			"year":  map[string]interface{}{"$year": date},
+			"month": map[string]interface{}{"$month": date},
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported interval: %s", interval)
+	}
+
+	return map[string]interface{}{
+		"$dateFromParts": parts,
+	}, nil
+}
+
+// validField checks if field can be safely used as a top-level field in pipeline.
+func validField(field string) bool {
+	return field != "" && !strings.ContainsAny(field, "$.")
+}
+
+// percentile returns the p-th percentile from sorted values using nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
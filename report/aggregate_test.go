@@ -0,0 +1,185 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBucketExpression(t *testing.T) {
+	date := doc{
+		"$add": []interface{}{
+			time.Unix(0, 0).UTC(),
+			doc{"$multiply": []interface{}{"$date_sold", 1000}},
+		},
+	}
+	tests := []struct {
+		interval Interval
+		parts    doc
+	}{
+		{IntervalHour, doc{
+			"year":  doc{"$year": date},
+			"month": doc{"$month": date},
+			"day":   doc{"$dayOfMonth": date},
+			"hour":  doc{"$hour": date},
+		}},
+		{IntervalDay, doc{
+			"year":  doc{"$year": date},
+			"month": doc{"$month": date},
+			"day":   doc{"$dayOfMonth": date},
+		}},
+		// ISO-weeks start on Monday, and the ISO-year differs from the
+		// calendar-year around new year, so neither $year nor $week is used.
+		{IntervalWeek, doc{
+			"isoWeekYear": doc{"$isoWeekYear": date},
+			"isoWeek":     doc{"$isoWeek": date},
+		}},
+		{IntervalMonth, doc{
+			"year":  doc{"$year": date},
+			"month": doc{"$month": date},
+		}},
+	}
+	for _, test := range tests {
+		got, err := bucketExpression(test.interval, "$date_sold")
+		if err != nil {
+			t.Errorf("%s: %v", test.interval, err)
+			continue
+		}
+		want := doc{"$dateFromParts": test.parts}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", test.interval, got, want)
+		}
+	}
+
+	if _, err := bucketExpression("fortnight", "$timestamp"); err == nil {
+		t.Error("expected error for unsupported interval")
+	}
+}
+
+func TestStatsPipeline(t *testing.T) {
+	query := StatsQuery{
+		Field:       "total_weight",
+		Interval:    IntervalDay,
+		GroupBy:     []string{"location"},
+		Percentiles: []float64{50, 95},
+		Filter:      Eq("name", "Banana"),
+	}
+	got, err := statsPipeline(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucket, _ := bucketExpression(IntervalDay, "$timestamp")
+	want := []interface{}{
+		doc{"$match": doc{"$and": []interface{}{
+			doc{"name": doc{"$eq": "Banana"}},
+			doc{"total_weight": doc{"$exists": true}},
+		}}},
+		doc{"$sort": sortDocument([]SortField{{Field: "total_weight"}})},
+		doc{"$group": doc{
+			"_id":    doc{"bucket": bucket, "location": "$location"},
+			"count":  doc{"$sum": 1},
+			"sum":    doc{"$sum": "$total_weight"},
+			"avg":    doc{"$avg": "$total_weight"},
+			"min":    doc{"$min": "$total_weight"},
+			"max":    doc{"$max": "$total_weight"},
+			"values": doc{"$push": "$total_weight"},
+		}},
+		doc{"$project": doc{
+			"_id":    0,
+			"count":  1,
+			"sum":    1,
+			"avg":    1,
+			"min":    1,
+			"max":    1,
+			"values": 1,
+			"bucket": "$_id.bucket",
+			"group":  doc{"location": "$_id.location"},
+		}},
+		doc{"$sort": sortDocument([]SortField{{Field: "bucket"}, {Field: "group.location"}})},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Without Interval, GroupBy or Percentiles, all documents are a single group
+	got, err = statsPipeline(StatsQuery{Field: "price"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group := got[1].(doc)["$group"].(doc); !reflect.DeepEqual(group["_id"], doc{}) {
+		t.Errorf("got group-id %v, want empty", group["_id"])
+	}
+
+	invalid := []StatsQuery{
+		{},
+		{Field: "$where"},
+		{Field: "price", TimeField: "date.sold"},
+		{Field: "price", GroupBy: []string{"$location"}},
+		{Field: "price", Interval: "fortnight"},
+		{Field: "price", Percentiles: []float64{0}},
+		{Field: "price", Percentiles: []float64{100.5}},
+	}
+	for _, query := range invalid {
+		if _, err := statsPipeline(query); err == nil {
+			t.Errorf("%+v: expected error", query)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{nil, 50, 0},
+		{[]float64{7}, 95, 7},
+		{sorted, 50, 5},
+		{sorted, 95, 10},
+		{sorted, 90, 9},
+		{sorted, 100, 10},
+		{sorted, 0.1, 1},
+	}
+	for _, test := range tests {
+		if got := percentile(test.values, test.p); got != test.want {
+			t.Errorf("p%v of %v: got %v, want %v", test.p, test.values, got, test.want)
+		}
+	}
+}
+
+func TestWeightsPipeline(t *testing.T) {
+	got, err := weightsPipeline(IntervalWeek, TimeRange("timestamp", 10, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucket, _ := bucketExpression(IntervalWeek, "$timestamp")
+	want := []interface{}{
+		doc{"$match": doc{"timestamp": doc{"$gte": int64(10), "$lte": int64(20)}}},
+		doc{"$group": doc{
+			"_id":           bucket,
+			"total_weight":  doc{"$sum": "$total_weight"},
+			"sold_weight":   doc{"$sum": "$sold_weight"},
+			"waste_weight":  doc{"$sum": "$waste_weight"},
+			"donate_weight": doc{"$sum": "$donate_weight"},
+		}},
+		doc{"$project": doc{
+			"_id":           0,
+			"bucket":        "$_id",
+			"total_weight":  1,
+			"sold_weight":   1,
+			"waste_weight":  1,
+			"donate_weight": 1,
+		}},
+		doc{"$sort": sortDocument([]SortField{{Field: "bucket"}})},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := weightsPipeline("", nil); err == nil {
+		t.Error("expected error without interval")
+	}
+}
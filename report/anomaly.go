@@ -0,0 +1,467 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/pkg/errors"
+)
+
+// AnomalyReportType is the ReportType for anomalous Metric-readings.
+const AnomalyReportType = "Anomaly"
+
+// defaultAnomalyWindow is the number of previous readings
+// in rolling-baseline for a device.
+const defaultAnomalyWindow = 30
+
+// defaultAnomalyMinReadings is the minimum number of readings in
+// rolling-baseline before a reading can be flagged.
+const defaultAnomalyMinReadings = 10
+
+// defaultAnomalyThreshold is the absolute z-score at or above
+// which a reading is anomalous.
+const defaultAnomalyThreshold = 3
+
+// defaultAnomalyFields are the Metric-fields checked for anomalies.
+var defaultAnomalyFields = []string{"ethylene", "carbon_di", "temp_in"}
+
+// anomalyFieldValues are the Metric-fields which can be checked for anomalies.
+var anomalyFieldValues = map[string]func(m *Metric) float64{
+	"ethylene":  func(m *Metric) float64 { return m.Ethylene },
+	"carbon_di": func(m *Metric) float64 { return m.CarbonDi },
+	"temp_in":   func(m *Metric) float64 { return m.TempIn },
+	"humidity":  func(m *Metric) float64 { return m.Humidity },
+}
+
+// AnomalyQuery specifies the parameters for DetectAnomalies.
+type AnomalyQuery struct {
+	SearchByDate
+	// Fields are the Metric-fields to check. Defaults to defaultAnomalyFields.
+	Fields []string `json:"fields,omitempty"`
+	// WindowSize is the number of previous readings in a device's
+	// rolling-baseline. Defaults to defaultAnomalyWindow.
+	WindowSize int `json:"window,omitempty"`
+	// MinReadings is the minimum number of readings in baseline before
+	// readings are flagged. Defaults to defaultAnomalyMinReadings.
+	MinReadings int `json:"min_readings,omitempty"`
+	// Threshold is the absolute z-score at or above which a reading
+	// is anomalous. Defaults to defaultAnomalyThreshold.
+	Threshold float64 `json:"threshold,omitempty"`
+	// Store saves the detected anomalies as Anomaly-reports.
+	// Anomalies which are already stored are not saved again.
+	Store bool `json:"store,omitempty"`
+}
+
+// Validate checks the date-range and fields.
+func (q *AnomalyQuery) Validate() error {
+	if err := q.SearchByDate.Validate(); err != nil {
+		return err
+	}
+	for _, f := range q.Fields {
+		if _, ok := anomalyFieldValues[f]; !ok {
+			return fmt.Errorf("Unsupported anomaly-field: %s", f)
+		}
+	}
+	return nil
+}
+
+// Anomaly is an anomalous Metric-reading, stored as a report
+// with AnomalyReportType.
+type Anomaly struct {
+	ID         objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ReportID   uuuid.UUID        `bson:"report_id,omitempty" json:"report_id,omitempty"`
+	ReportType string            `bson:"report_type,omitempty" json:"report_type,omitempty"`
+	// Timestamp is when the anomaly was detected.
+	Timestamp int64      `bson:"timestamp,omitempty" json:"timestamp,omitempty"`
+	DeviceID  uuuid.UUID `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	ItemID    uuuid.UUID `bson:"item_id,omitempty" json:"item_id,omitempty"`
+	// Metric is the anomalous Metric-field, such as "ethylene".
+	Metric           string  `bson:"metric,omitempty" json:"metric,omitempty"`
+	ReadingTimestamp int64   `bson:"reading_timestamp,omitempty" json:"reading_timestamp,omitempty"`
+	Value            float64 `bson:"value" json:"value"`
+	Mean             float64 `bson:"mean" json:"mean"`
+	StdDev           float64 `bson:"std_dev" json:"std_dev"`
+	ZScore           float64 `bson:"z_score" json:"z_score"`
+	// Direction is "spike" for readings above baseline, and "drop" for below.
+	Direction string `bson:"direction,omitempty" json:"direction,omitempty"`
+}
+
+type marshalAnomaly struct {
+	ID               objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ReportID         string            `bson:"report_id,omitempty" json:"report_id,omitempty"`
+	ReportType       string            `bson:"report_type,omitempty" json:"report_type,omitempty"`
+	Timestamp        int64             `bson:"timestamp,omitempty" json:"timestamp,omitempty"`
+	DeviceID         string            `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	ItemID           string            `bson:"item_id,omitempty" json:"item_id,omitempty"`
+	Metric           string            `bson:"metric,omitempty" json:"metric,omitempty"`
+	ReadingTimestamp int64             `bson:"reading_timestamp,omitempty" json:"reading_timestamp,omitempty"`
+	Value            float64           `bson:"value" json:"value"`
+	Mean             float64           `bson:"mean" json:"mean"`
+	StdDev           float64           `bson:"std_dev" json:"std_dev"`
+	ZScore           float64           `bson:"z_score" json:"z_score"`
+	Direction        string            `bson:"direction,omitempty" json:"direction,omitempty"`
+}
+
+func (a Anomaly) marshalAnomaly() *marshalAnomaly {
+	ma := &marshalAnomaly{
+		ID:               a.ID,
+		ReportType:       a.ReportType,
+		Timestamp:        a.Timestamp,
+		Metric:           a.Metric,
+		ReadingTimestamp: a.ReadingTimestamp,
+		Value:            a.Value,
+		Mean:             a.Mean,
+		StdDev:           a.StdDev,
+		ZScore:           a.ZScore,
+		Direction:        a.Direction,
+	}
+	if a.ReportID.String() != (uuuid.UUID{}).String() {
+		ma.ReportID = a.ReportID.String()
+	}
+	if a.DeviceID.String() != (uuuid.UUID{}).String() {
+		ma.DeviceID = a.DeviceID.String()
+	}
+	if a.ItemID.String() != (uuuid.UUID{}).String() {
+		ma.ItemID = a.ItemID.String()
+	}
+	return ma
+}
+
+func (a Anomaly) MarshalBSON() ([]byte, error) {
+	return bson.Marshal(a.marshalAnomaly())
+}
+
+func (a Anomaly) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.marshalAnomaly())
+}
+
+func (a *Anomaly) UnmarshalBSON(in []byte) error {
+	ma := &marshalAnomaly{}
+	err := bson.Unmarshal(in, ma)
+	if err != nil {
+		err = errors.Wrap(err, "Unmarshal Error")
+		return err
+	}
+
+	*a = Anomaly{
+		ID:               ma.ID,
+		ReportType:       ma.ReportType,
+		Timestamp:        ma.Timestamp,
+		Metric:           ma.Metric,
+		ReadingTimestamp: ma.ReadingTimestamp,
+		Value:            ma.Value,
+		Mean:             ma.Mean,
+		StdDev:           ma.StdDev,
+		ZScore:           ma.ZScore,
+		Direction:        ma.Direction,
+	}
+	if ma.ReportID != "" {
+		a.ReportID, err = uuuid.FromString(ma.ReportID)
+		if err != nil {
+			err = errors.Wrap(err, "Error parsing ReportID for anomaly")
+			return err
+		}
+	}
+	if ma.DeviceID != "" {
+		a.DeviceID, err = uuuid.FromString(ma.DeviceID)
+		if err != nil {
+			err = errors.Wrap(err, "Error parsing DeviceID for anomaly")
+			return err
+		}
+	}
+	if ma.ItemID != "" {
+		a.ItemID, err = uuuid.FromString(ma.ItemID)
+		if err != nil {
+			err = errors.Wrap(err, "Error parsing ItemID for anomaly")
+			return err
+		}
+	}
+	return nil
+}
+
+// AnomalyReport is the result of DetectAnomalies.
+type AnomalyReport struct {
+	StartDate int64     `json:"start_date"`
+	EndDate   int64     `json:"end_date"`
+	Anomalies []Anomaly `json:"anomalies"`
+	// Stored is the number of new anomalies saved, if Store was requested.
+	Stored int `json:"stored"`
+}
+
+// rollingWindow maintains the mean and standard-deviation
+// of the last n values.
+type rollingWindow struct {
+	values []float64
+	next   int
+	sum    float64
+	sumSq  float64
+}
+
+func (w *rollingWindow) add(v float64, size int) {
+	if len(w.values) < size {
+		w.values = append(w.values, v)
+	} else {
+		old := w.values[w.next]
+		w.sum -= old
+		w.sumSq -= old * old
+		w.values[w.next] = v
+		w.next = (w.next + 1) % size
+	}
+	w.sum += v
+	w.sumSq += v * v
+}
+
+func (w *rollingWindow) stats() (float64, float64) {
+	n := float64(len(w.values))
+	mean := w.sum / n
+	variance := w.sumSq/n - mean*mean
+	if variance < 0 {
+		// Rounding-errors
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
+
+// DetectAnomalies flags the Metric-readings in date-range which deviate
+// from their device's rolling-baseline by at least Threshold standard-deviations.
+// The baseline is built from readings within date-range, so the first
+// MinReadings readings for each device are never flagged.
+func (db *DB) DetectAnomalies(query AnomalyQuery) (*AnomalyReport, error) {
+	if db.metric == nil {
+		return nil, errors.New("Metric collection is not configured")
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	startDate, endDate := reportWindow(query.SearchByDate)
+
+	fields := query.Fields
+	if len(fields) == 0 {
+		fields = defaultAnomalyFields
+	}
+	window := query.WindowSize
+	if window <= 0 {
+		window = defaultAnomalyWindow
+	}
+	minReadings := query.MinReadings
+	if minReadings <= 0 {
+		minReadings = defaultAnomalyMinReadings
+	}
+	if minReadings > window {
+		minReadings = window
+	}
+	threshold := query.Threshold
+	if threshold <= 0 {
+		threshold = defaultAnomalyThreshold
+	}
+
+	byDevice := map[string][]Metric{}
+	metrics, errChan := db.StreamMetrics(
+		context.Background(),
+		TimeRange("timestamp", startDate, endDate),
+		0,
+	)
+	for m := range metrics {
+		deviceID := m.DeviceID.String()
+		byDevice[deviceID] = append(byDevice[deviceID], m)
+	}
+	if err := <-errChan; err != nil {
+		err = errors.Wrap(err, "Error loading metrics for anomaly-detection")
+		return nil, err
+	}
+
+	result := &AnomalyReport{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Anomalies: []Anomaly{},
+	}
+	detectedAt := time.Now().Unix()
+	for _, deviceMetrics := range byDevice {
+		sort.Slice(deviceMetrics, func(i, j int) bool {
+			return deviceMetrics[i].Timestamp < deviceMetrics[j].Timestamp
+		})
+
+		for _, field := range fields {
+			anomalies := fieldAnomalies(deviceMetrics, field, window, minReadings, threshold)
+			for _, a := range anomalies {
+				a.Timestamp = detectedAt
+				result.Anomalies = append(result.Anomalies, a)
+			}
+		}
+	}
+
+	sort.Slice(result.Anomalies, func(i, j int) bool {
+		return result.Anomalies[i].ReadingTimestamp < result.Anomalies[j].ReadingTimestamp
+	})
+	if query.Store && len(result.Anomalies) > 0 {
+		stored, err := db.storeAnomalies(result.Anomalies, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		result.Stored = stored
+	}
+	return result, nil
+}
+
+// fieldAnomalies flags the readings of field in a device's metrics which deviate
+// from the rolling-baseline of the previous window readings by at least
+// threshold standard-deviations. metrics must be sorted by timestamp.
+func fieldAnomalies(
+	metrics []Metric,
+	field string,
+	window int,
+	minReadings int,
+	threshold float64,
+) []Anomaly {
+	anomalies := []Anomaly{}
+	value := anomalyFieldValues[field]
+	baseline := &rollingWindow{}
+	for i := range metrics {
+		m := &metrics[i]
+		v := value(m)
+		if len(baseline.values) >= minReadings {
+			mean, stdDev := baseline.stats()
+			if stdDev > 0 {
+				z := (v - mean) / stdDev
+				if math.Abs(z) >= threshold {
+					direction := "spike"
+					if z < 0 {
+						direction = "drop"
+					}
+					anomalies = append(anomalies, Anomaly{
+						ReportType:       AnomalyReportType,
+						DeviceID:         m.DeviceID,
+						ItemID:           m.ItemID,
+						Metric:           field,
+						ReadingTimestamp: m.Timestamp,
+						Value:            v,
+						Mean:             mean,
+						StdDev:           stdDev,
+						ZScore:           z,
+						Direction:        direction,
+					})
+				}
+			}
+		}
+		baseline.add(v, window)
+	}
+	return anomalies
+}
+
+// withoutAnomalies excludes the Anomaly-reports from filter. These are
+// stored in the Report collection, but are not shaped as Reports.
+func withoutAnomalies(filter Filter) Filter {
+	return And(filter, Ne("report_type", AnomalyReportType))
+}
+
+// storeAnomalies saves the anomalies not already stored for date-range.
+// The ReportIDs of anomalies are set when they are saved.
+func (db *DB) storeAnomalies(anomalies []Anomaly, startDate int64, endDate int64) (int, error) {
+	existing, err := db.QueryAnomalies(TimeRange("reading_timestamp", startDate, endDate))
+	if err != nil {
+		return 0, err
+	}
+	anomalyKey := func(a *Anomaly) string {
+		return fmt.Sprintf("%s|%s|%d", a.DeviceID.String(), a.Metric, a.ReadingTimestamp)
+	}
+	stored := map[string]bool{}
+	for i := range *existing {
+		stored[anomalyKey(&(*existing)[i])] = true
+	}
+
+	newAnomalies := []interface{}{}
+	for i := range anomalies {
+		a := &anomalies[i]
+		if stored[anomalyKey(a)] {
+			continue
+		}
+		a.ReportID = generateNewUUID()
+		newAnomalies = append(newAnomalies, a)
+	}
+	if len(newAnomalies) == 0 {
+		return 0, nil
+	}
+
+	_, err = db.collection.InsertMany(newAnomalies)
+	if err != nil {
+		err = errors.Wrap(err, "Unable to insert anomalies")
+		log.Println(err)
+		return 0, err
+	}
+	return len(newAnomalies), nil
+}
+
+// QueryAnomalies fetches the stored Anomaly-reports matching the specified Filter.
+// A nil Filter matches all Anomaly-reports.
+func (db *DB) QueryAnomalies(filter Filter) (*[]Anomaly, error) {
+	anomalyColl, err := resultCollection(db.collection, &Anomaly{})
+	if err != nil {
+		return nil, err
+	}
+
+	findResults, err := anomalyColl.Find(
+		And(Eq("report_type", AnomalyReportType), filter).Compile(),
+	)
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching anomalies.")
+		log.Println(err)
+		return nil, err
+	}
+
+	anomalies := []Anomaly{}
+	for _, v := range findResults {
+		result, ok := v.(*Anomaly)
+		if !ok {
+			err = errors.New("Error asserting search-result to Anomaly")
+			log.Println(err)
+			return nil, err
+		}
+		anomalies = append(anomalies, *result)
+	}
+	return &anomalies, nil
+}
+
+// anomalyReport is the "anomaly" ReportType.
+type anomalyReport struct{}
+
+func init() {
+	RegisterReportType(anomalyReport{})
+}
+
+func (anomalyReport) Name() string {
+	return "anomaly"
+}
+
+func (anomalyReport) Description() string {
+	return "Sensor-readings deviating from their rolling mean by more than a z-score threshold."
+}
+
+func (anomalyReport) Params() []Param {
+	return queryParams(&AnomalyQuery{})
+}
+
+func (anomalyReport) NewQuery() Query {
+	return &AnomalyQuery{}
+}
+
+func (anomalyReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.DetectAnomalies(*query.(*AnomalyQuery))
+}
+
+func (anomalyReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*AnomalyReport)
+	if !ok {
+		return nil, errors.New("Error asserting result to AnomalyReport")
+	}
+	return []Table{
+		structTable("anomalies", r.Anomalies, "device_id", "metric", "reading_timestamp"),
+	}, nil
+}
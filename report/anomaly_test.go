@@ -0,0 +1,108 @@
+package report
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRollingWindow(t *testing.T) {
+	w := &rollingWindow{}
+	for _, v := range []float64{1, 2, 3} {
+		w.add(v, 3)
+	}
+	mean, stdDev := w.stats()
+	if mean != 2 || math.Abs(stdDev-math.Sqrt(2.0/3)) > 1e-9 {
+		t.Errorf("got %v, %v, want 2, %v", mean, stdDev, math.Sqrt(2.0/3))
+	}
+
+	// Older values are replaced once the window is full
+	for _, v := range []float64{10, 10, 10} {
+		w.add(v, 3)
+	}
+	mean, stdDev = w.stats()
+	if !reflect.DeepEqual(w.values, []float64{10, 10, 10}) {
+		t.Errorf("got values %v, want [10 10 10]", w.values)
+	}
+	if mean != 10 || stdDev != 0 {
+		t.Errorf("got %v, %v, want 10, 0", mean, stdDev)
+	}
+}
+
+// sensorMetrics are Metrics of a single device with the temperatures.
+func sensorMetrics(t *testing.T, temps ...float64) []Metric {
+	deviceID := newUUID(t)
+	metrics := []Metric{}
+	for i, temp := range temps {
+		metrics = append(metrics, Metric{
+			DeviceID:  deviceID,
+			Timestamp: int64(i) * 60,
+			TempIn:    temp,
+		})
+	}
+	return metrics
+}
+
+func TestFieldAnomalies(t *testing.T) {
+	// Baseline alternates around 5, with a standard-deviation of 1
+	baseline := []float64{4, 6, 4, 6, 4, 6}
+	metrics := sensorMetrics(t, append(baseline, 20, 5)...)
+
+	got := fieldAnomalies(metrics, "temp_in", 6, 6, 3)
+	want := []Anomaly{
+		{
+			ReportType:       AnomalyReportType,
+			DeviceID:         metrics[6].DeviceID,
+			Metric:           "temp_in",
+			ReadingTimestamp: 360,
+			Value:            20,
+			Mean:             5,
+			StdDev:           1,
+			ZScore:           15,
+			Direction:        "spike",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = fieldAnomalies(sensorMetrics(t, append(baseline, -1)...), "temp_in", 6, 6, 3)
+	if len(got) != 1 || got[0].Direction != "drop" || got[0].ZScore != -6 {
+		t.Errorf("got %+v, want a drop with z-score -6", got)
+	}
+
+	tests := []struct {
+		name        string
+		temps       []float64
+		minReadings int
+		threshold   float64
+	}{
+		{"below threshold", append(baseline, 7), 6, 3},
+		{"before min-readings", append(baseline, 20), 7, 3},
+		{"constant baseline", []float64{5, 5, 5, 5, 5, 5, 20}, 6, 3},
+	}
+	for _, test := range tests {
+		metrics := sensorMetrics(t, test.temps...)
+		got := fieldAnomalies(metrics, "temp_in", 6, test.minReadings, test.threshold)
+		if len(got) != 0 {
+			t.Errorf("%s: got %+v, want no anomalies", test.name, got)
+		}
+	}
+}
+
+func TestWithoutAnomalies(t *testing.T) {
+	got := withoutAnomalies(nil).Compile()
+	want := doc{"report_type": doc{"$ne": AnomalyReportType}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = withoutAnomalies(Eq("report_type", "waste")).Compile()
+	want = doc{"$and": []interface{}{
+		doc{"report_type": doc{"$eq": "waste"}},
+		doc{"report_type": doc{"$ne": AnomalyReportType}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
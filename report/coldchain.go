@@ -0,0 +1,322 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/pkg/errors"
+)
+
+// Band is the acceptable range for a sensor-value.
+type Band struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// ProductBands are the acceptable temperature (°C) and
+// humidity (%RH) ranges for storing a product.
+type ProductBands struct {
+	TempIn   Band `json:"temp_in"`
+	Humidity Band `json:"humidity"`
+}
+
+// defaultProductBands are the recommended storage-conditions for products.
+var defaultProductBands = map[string]ProductBands{
+	"Apple":        {TempIn: Band{0, 4}, Humidity: Band{90, 95}},
+	"Banana":       {TempIn: Band{13, 15}, Humidity: Band{90, 95}},
+	"Grapes":       {TempIn: Band{-1, 1}, Humidity: Band{90, 95}},
+	"Lettuce":      {TempIn: Band{0, 2}, Humidity: Band{95, 100}},
+	"Mango":        {TempIn: Band{10, 13}, Humidity: Band{85, 90}},
+	"Orange":       {TempIn: Band{3, 9}, Humidity: Band{85, 90}},
+	"Pear":         {TempIn: Band{-1, 1}, Humidity: Band{90, 95}},
+	"Strawberry":   {TempIn: Band{0, 2}, Humidity: Band{90, 95}},
+	"Sweet Pepper": {TempIn: Band{7, 10}, Humidity: Band{90, 95}},
+	"Tomato":       {TempIn: Band{10, 13}, Humidity: Band{85, 90}},
+}
+
+// ColdChainQuery specifies the parameters for ColdChainReport.
+type ColdChainQuery struct {
+	SearchByDate
+	// Bands overrides the default storage-bands by product Name.
+	Bands map[string]ProductBands `json:"bands,omitempty"`
+	// MaxReadingGapSeconds is the maximum duration a reading is held for
+	// if no newer reading is available. Defaults to one hour.
+	MaxReadingGapSeconds int64 `json:"max_reading_gap_seconds,omitempty"`
+}
+
+// Validate checks the date-range and storage-bands.
+func (q *ColdChainQuery) Validate() error {
+	if err := q.SearchByDate.Validate(); err != nil {
+		return err
+	}
+	return validateBands(q.Bands)
+}
+
+// validateBands checks that no Band's Min is greater than its Max.
+func validateBands(bands map[string]ProductBands) error {
+	for name, b := range bands {
+		if b.TempIn.Min > b.TempIn.Max || b.Humidity.Min > b.Humidity.Max {
+			return fmt.Errorf("Invalid bands for %s: min cannot be greater than max", name)
+		}
+	}
+	return nil
+}
+
+// Excursion is a continuous period during which a sensor-value
+// was outside its acceptable band.
+type Excursion struct {
+	ItemID   uuuid.UUID `json:"item_id"`
+	DeviceID uuuid.UUID `json:"device_id"`
+	SKU      int64      `json:"sku,omitempty"`
+	Name     string     `json:"name,omitempty"`
+	Location string     `json:"location,omitempty"`
+	// Metric is the excursion's sensor-value: "temp_in" or "humidity".
+	Metric string `json:"metric"`
+	// Direction is "high" if value exceeded the band, or "low" if it fell below.
+	Direction       string  `json:"direction"`
+	StartTime       int64   `json:"start_time"`
+	EndTime         int64   `json:"end_time"`
+	DurationSeconds int64   `json:"duration_seconds"`
+	Limit           float64 `json:"limit"`
+	PeakValue       float64 `json:"peak_value"`
+	PeakDeviation   float64 `json:"peak_deviation"`
+	// DeviationHours is the deviation from limit integrated over excursion's
+	// duration (such as degree-hours), and is a measure of its severity.
+	DeviationHours float64 `json:"deviation_hours"`
+}
+
+// ExcursionItem summarizes the Excursions for an affected Inventory item.
+type ExcursionItem struct {
+	ItemID               uuuid.UUID `json:"item_id"`
+	SKU                  int64      `json:"sku,omitempty"`
+	Name                 string     `json:"name,omitempty"`
+	Location             string     `json:"location,omitempty"`
+	Excursions           int        `json:"excursions"`
+	TotalDurationSeconds int64      `json:"total_duration_seconds"`
+	TotalDeviationHours  float64    `json:"total_deviation_hours"`
+}
+
+// ColdChainExcursions is the result of ColdChainReport.
+type ColdChainExcursions struct {
+	StartDate     int64           `json:"start_date"`
+	EndDate       int64           `json:"end_date"`
+	Excursions    []Excursion     `json:"excursions"`
+	AffectedItems []ExcursionItem `json:"affected_items"`
+}
+
+// ColdChainReport detects the temperature and humidity excursions for Inventory
+// items stored during the date-range outside their product's storage-bands. Items whose
+// product has no configured bands are skipped.
+func (db *DB) ColdChainReport(query ColdChainQuery) (*ColdChainExcursions, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	startDate, endDate := reportWindow(query.SearchByDate)
+	maxGap := defaultMaxReadingGap
+	if query.MaxReadingGapSeconds > 0 {
+		maxGap = time.Duration(query.MaxReadingGapSeconds) * time.Second
+	}
+
+	items, err := db.inventoryItems(stockedFilter(startDate, endDate))
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for cold-chain report")
+		return nil, err
+	}
+	metrics, err := db.itemMetrics(items, startDate, endDate)
+	if err != nil {
+		err = errors.Wrap(err, "Error loading metrics for cold-chain report")
+		return nil, err
+	}
+
+	result := &ColdChainExcursions{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		Excursions:    []Excursion{},
+		AffectedItems: []ExcursionItem{},
+	}
+	for itemID, itemMetrics := range metrics {
+		item := items.byItemID[itemID]
+		bands, ok := query.Bands[item.Name]
+		if !ok {
+			bands, ok = defaultProductBands[item.Name]
+		}
+		if !ok {
+			continue
+		}
+
+		temp := metricValues(itemMetrics, func(m *Metric) float64 {
+			return m.TempIn
+		})
+		humidity := humidityReadings(itemMetrics)
+		excursions := append(
+			detectExcursions(temp, bands.TempIn, "temp_in", endDate, maxGap),
+			detectExcursions(humidity, bands.Humidity, "humidity", endDate, maxGap)...,
+		)
+		if len(excursions) == 0 {
+			continue
+		}
+
+		affected := ExcursionItem{
+			ItemID:   item.ItemID,
+			SKU:      item.SKU,
+			Name:     item.Name,
+			Location: item.Location,
+		}
+		for i := range excursions {
+			excursions[i].ItemID = item.ItemID
+			excursions[i].DeviceID = item.DeviceID
+			excursions[i].SKU = item.SKU
+			excursions[i].Name = item.Name
+			excursions[i].Location = item.Location
+
+			affected.Excursions++
+			affected.TotalDurationSeconds += excursions[i].DurationSeconds
+			affected.TotalDeviationHours += excursions[i].DeviationHours
+		}
+		result.Excursions = append(result.Excursions, excursions...)
+		result.AffectedItems = append(result.AffectedItems, affected)
+	}
+
+	sort.Slice(result.Excursions, func(i, j int) bool {
+		return result.Excursions[i].StartTime < result.Excursions[j].StartTime
+	})
+	sort.Slice(result.AffectedItems, func(i, j int) bool {
+		return result.AffectedItems[i].TotalDeviationHours > result.AffectedItems[j].TotalDeviationHours
+	})
+	return result, nil
+}
+
+// humidityReadings are the timed-readings of the Metrics which include humidity.
+// Readings without humidity are skipped, rather than treated as 0%.
+func humidityReadings(metrics []Metric) []timedReading {
+	readings := []timedReading{}
+	for i := range metrics {
+		if humidity, ok := metrics[i].MeasuredHumidity(); ok {
+			readings = append(readings, timedReading{
+				Timestamp: metrics[i].Timestamp,
+				Value:     humidity,
+			})
+		}
+	}
+	return readings
+}
+
+// detectExcursions finds the periods during which readings of metric were outside band.
+// An excursion ends at the first reading back inside band (or in the other
+// direction), or when readings stop. readings must be sorted by timestamp.
+func detectExcursions(
+	readings []timedReading,
+	band Band,
+	metric string,
+	endDate int64,
+	maxGap time.Duration,
+) []Excursion {
+	maxGapSeconds := int64(maxGap / time.Second)
+	excursions := []Excursion{}
+
+	var current *Excursion
+	for i, r := range readings {
+		direction := ""
+		limit := 0.0
+		switch {
+		case r.Value > band.Max:
+			direction = "high"
+			limit = band.Max
+		case r.Value < band.Min:
+			direction = "low"
+			limit = band.Min
+		}
+
+		if current != nil && current.Direction != direction {
+			current.EndTime = r.Timestamp
+			current.DurationSeconds = current.EndTime - current.StartTime
+			excursions = append(excursions, *current)
+			current = nil
+		}
+		if direction == "" {
+			continue
+		}
+		if current == nil {
+			current = &Excursion{
+				Metric:    metric,
+				Direction: direction,
+				StartTime: r.Timestamp,
+				Limit:     limit,
+			}
+		}
+
+		deviation := math.Abs(r.Value - limit)
+		if deviation > current.PeakDeviation {
+			current.PeakDeviation = deviation
+			current.PeakValue = r.Value
+		}
+
+		// Readings are held till the next reading, or for maxGap
+		until := endDate
+		if i+1 < len(readings) {
+			until = readings[i+1].Timestamp
+		}
+		held := until - r.Timestamp
+		if held > maxGapSeconds {
+			held = maxGapSeconds
+			// Readings stopped, so excursion ends when this reading expires
+			current.EndTime = r.Timestamp + held
+			current.DeviationHours += deviation * float64(held) / 3600
+			current.DurationSeconds = current.EndTime - current.StartTime
+			excursions = append(excursions, *current)
+			current = nil
+			continue
+		}
+		if held > 0 {
+			current.DeviationHours += deviation * float64(held) / 3600
+		}
+	}
+
+	if current != nil {
+		current.EndTime = endDate
+		current.DurationSeconds = current.EndTime - current.StartTime
+		excursions = append(excursions, *current)
+	}
+	return excursions
+}
+
+// coldChainReport is the "cold-chain" ReportType.
+type coldChainReport struct{}
+
+func init() {
+	RegisterReportType(coldChainReport{})
+}
+
+func (coldChainReport) Name() string {
+	return "cold-chain"
+}
+
+func (coldChainReport) Description() string {
+	return "Temperature and humidity excursions outside each product's storage-bands."
+}
+
+func (coldChainReport) Params() []Param {
+	return queryParams(&ColdChainQuery{})
+}
+
+func (coldChainReport) NewQuery() Query {
+	return &ColdChainQuery{}
+}
+
+func (coldChainReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.ColdChainReport(*query.(*ColdChainQuery))
+}
+
+func (coldChainReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*ColdChainExcursions)
+	if !ok {
+		return nil, errors.New("Error asserting result to ColdChainExcursions")
+	}
+	return []Table{
+		structTable("excursions", r.Excursions, "item_id", "metric", "start_time"),
+		structTable("affected_items", r.AffectedItems, "item_id"),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectExcursions(t *testing.T) {
+	readings := []timedReading{
+		{Timestamp: 0, Value: 2},
+		{Timestamp: 600, Value: 6},
+		{Timestamp: 1200, Value: 8},
+		{Timestamp: 1800, Value: 3},
+		{Timestamp: 2400, Value: -2},
+	}
+	got := detectExcursions(readings, Band{0, 4}, "temp_in", 10000, time.Hour)
+	want := []Excursion{
+		{
+			Metric:          "temp_in",
+			Direction:       "high",
+			StartTime:       600,
+			EndTime:         1800,
+			DurationSeconds: 1200,
+			Limit:           4,
+			PeakValue:       8,
+			PeakDeviation:   4,
+			// 2 degrees for 600s, then 4 degrees for 600s
+			DeviationHours: 1,
+		},
+		{
+			// Readings stop, so the last reading is held for an hour
+			Metric:          "temp_in",
+			Direction:       "low",
+			StartTime:       2400,
+			EndTime:         6000,
+			DurationSeconds: 3600,
+			Limit:           0,
+			PeakValue:       -2,
+			PeakDeviation:   2,
+			DeviationHours:  2,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestHumidityReadings(t *testing.T) {
+	metrics := []Metric{
+		{Timestamp: 0, TempIn: 1, Humidity: 92},
+		{Timestamp: 600, TempIn: 1},
+		{Timestamp: 1200, TempIn: 1, Humidity: 80},
+	}
+	readings := humidityReadings(metrics)
+	want := []timedReading{
+		{Timestamp: 0, Value: 92},
+		{Timestamp: 1200, Value: 80},
+	}
+	if !reflect.DeepEqual(readings, want) {
+		t.Fatalf("got %+v, want %+v", readings, want)
+	}
+
+	// The reading without humidity is not a low-humidity excursion
+	excursions := detectExcursions(readings, Band{90, 95}, "humidity", 1800, time.Hour)
+	if len(excursions) != 1 || excursions[0].StartTime != 1200 {
+		t.Errorf("got %+v, want a single excursion from 1200", excursions)
+	}
+}
@@ -0,0 +1,340 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Ways of choosing the previous window for CompareReport.
+const (
+	// ComparePrevious is the window of same length immediately before current window.
+	ComparePrevious = "previous"
+	// CompareDay, CompareWeek, CompareMonth and CompareYear shift the current
+	// window back by a calendar-period, such as "same day last year".
+	CompareDay   = "day"
+	CompareWeek  = "week"
+	CompareMonth = "month"
+	CompareYear  = "year"
+)
+
+// CompareOptions specify the previous window that a report is compared against.
+type CompareOptions struct {
+	// Compare chooses the previous window relative to current window:
+	// "previous", "day", "week", "month" or "year". Ignored if
+	// PrevEndDate is set.
+	Compare       string `json:"compare,omitempty"`
+	PrevStartDate int64  `json:"prev_start_date,omitempty"`
+	PrevEndDate   int64  `json:"prev_end_date,omitempty"`
+}
+
+// ReportComparison is the result of CompareReport. Its Tables have the
+// key-columns of report's Tables, followed by each value-column with its
+// previous value, absolute change and percentage change, suffixed with
+// "_previous", "_change" and "_pct_change" for numeric columns.
+type ReportComparison struct {
+	ReportType string       `json:"report_type"`
+	Current    SearchByDate `json:"current"`
+	Previous   SearchByDate `json:"previous"`
+	Tables     []Table      `json:"tables"`
+}
+
+// Validate checks that a previous window is specified.
+func (o *CompareOptions) Validate() error {
+	if o.PrevEndDate != 0 {
+		previous := SearchByDate{
+			StartDate: o.PrevStartDate,
+			EndDate:   o.PrevEndDate,
+		}
+		return previous.Validate()
+	}
+	switch o.Compare {
+	case ComparePrevious, CompareDay, CompareWeek, CompareMonth, CompareYear:
+		return nil
+	}
+	return fmt.Errorf("Unsupported comparison: %s", o.Compare)
+}
+
+// previousWindow returns the window that current is compared against, and a
+// function which aligns Unix-timestamps in previous window to current window.
+func (o *CompareOptions) previousWindow(
+	current SearchByDate,
+) (SearchByDate, func(int64) int64, error) {
+	shiftBy := func(previous SearchByDate) func(int64) int64 {
+		offset := current.EndDate - previous.EndDate
+		return func(t int64) int64 {
+			return t + offset
+		}
+	}
+	if o.PrevEndDate != 0 {
+		previous := SearchByDate{
+			StartDate: o.PrevStartDate,
+			EndDate:   o.PrevEndDate,
+		}
+		return previous, shiftBy(previous), previous.Validate()
+	}
+
+	var years, months, days int
+	switch o.Compare {
+	case ComparePrevious:
+		if current.StartDate == 0 {
+			return SearchByDate{}, nil, errors.New(
+				"A start_date is required for comparison with previous window",
+			)
+		}
+		previous := SearchByDate{
+			StartDate: current.StartDate - 1 - (current.EndDate - current.StartDate),
+			EndDate:   current.StartDate - 1,
+		}
+		return previous, shiftBy(previous), nil
+	case CompareDay:
+		days = 1
+	case CompareWeek:
+		days = 7
+	case CompareMonth:
+		months = 1
+	case CompareYear:
+		years = 1
+	default:
+		return SearchByDate{}, nil, fmt.Errorf("Unsupported comparison: %s", o.Compare)
+	}
+
+	// Calendar-shifts are applied in UTC, like report-aggregations
+	shift := func(t int64, sign int) int64 {
+		if t == 0 {
+			return 0
+		}
+		u := time.Unix(t, 0).UTC()
+		if days != 0 {
+			return u.AddDate(0, 0, sign*days).Unix()
+		}
+		// Days past the end of a shorter month are clamped to its last day,
+		// so March 31 shifts to February 28 or 29 rather than March 2 or 3.
+		first := time.Date(
+			u.Year()+sign*years, u.Month()+time.Month(sign*months), 1,
+			u.Hour(), u.Minute(), u.Second(), 0, time.UTC,
+		)
+		day := u.Day()
+		if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+		return first.AddDate(0, 0, day-1).Unix()
+	}
+	previous := SearchByDate{
+		StartDate: shift(current.StartDate, -1),
+		EndDate:   shift(current.EndDate, -1),
+	}
+	return previous, func(t int64) int64 {
+		return shift(t, 1)
+	}, nil
+}
+
+// CompareReport computes the report of ReportType t for query's window and a
+// previous window, and compares their Tables row by row. Rows are matched by
+// their key-columns, or by position in Tables without key-columns.
+func CompareReport(
+	db DBI,
+	t ReportType,
+	query Query,
+	opts CompareOptions,
+) (*ReportComparison, error) {
+	startDate, endDate := reportWindow(query.Window())
+	current := SearchByDate{
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	previous, align, err := opts.previousWindow(current)
+	if err != nil {
+		return nil, err
+	}
+
+	// Previous query is a copy of query with previous window
+	params, err := json.Marshal(query)
+	if err != nil {
+		err = errors.Wrap(err, "Error marshalling report-query")
+		return nil, err
+	}
+	prevQuery, err := ParseQuery(t, params)
+	if err != nil {
+		return nil, err
+	}
+	query.SetWindow(current)
+	prevQuery.SetWindow(previous)
+
+	currentTables, err := computeTables(db, t, query)
+	if err != nil {
+		return nil, err
+	}
+	prevTables, err := computeTables(db, t, prevQuery)
+	if err != nil {
+		err = errors.Wrap(err, "Error computing report for previous window")
+		return nil, err
+	}
+
+	comparison := &ReportComparison{
+		ReportType: t.Name(),
+		Current:    query.Window(),
+		Previous:   prevQuery.Window(),
+		Tables:     []Table{},
+	}
+	for i, table := range currentTables {
+		prevTable := Table{}
+		if i < len(prevTables) {
+			prevTable = prevTables[i]
+		}
+		comparison.Tables = append(comparison.Tables, compareTables(table, prevTable, align))
+	}
+	return comparison, nil
+}
+
+func computeTables(db DBI, t ReportType, query Query) ([]Table, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	result, err := t.Compute(db, query)
+	if err != nil {
+		return nil, err
+	}
+	return t.Render(result)
+}
+
+// compareTables compares current and previous, which are the same Table
+// computed for different windows. Time key-columns, such as trend-buckets, are
+// matched after aligning the previous window's timestamps using align.
+func compareTables(current Table, previous Table, align func(int64) int64) Table {
+	keys := []int{}
+	values := []int{}
+	for i, c := range current.Columns {
+		if c.Key {
+			keys = append(keys, i)
+		} else {
+			values = append(values, i)
+		}
+	}
+
+	table := Table{
+		Name:    current.Name,
+		Columns: []Column{},
+		Rows:    [][]interface{}{},
+		Chart:   current.Chart,
+	}
+	for _, i := range keys {
+		table.Columns = append(table.Columns, current.Columns[i])
+	}
+	for _, i := range values {
+		c := current.Columns[i]
+		table.Columns = append(table.Columns, c)
+		if isNumeric(c) {
+			table.Columns = append(
+				table.Columns,
+				Column{Name: c.Name + "_previous", Type: c.Type},
+				Column{Name: c.Name + "_change", Type: c.Type},
+				Column{Name: c.Name + "_pct_change", Type: "number"},
+			)
+		}
+	}
+
+	rowKey := func(row []interface{}, position int, align func(int64) int64) string {
+		if len(keys) == 0 {
+			return fmt.Sprint(position)
+		}
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			value := row[k]
+			if t, ok := value.(int64); ok && align != nil && current.Columns[k].Type == "time" {
+				value = align(t)
+			}
+			parts[i] = fmt.Sprint(value)
+		}
+		return strings.Join(parts, "\x00")
+	}
+
+	prevRows := map[string][]interface{}{}
+	for i, row := range previous.Rows {
+		prevRows[rowKey(row, i, align)] = row
+	}
+	matched := map[string]bool{}
+	for i, row := range current.Rows {
+		key := rowKey(row, i, nil)
+		matched[key] = true
+		table.Rows = append(table.Rows, compareRow(current.Columns, keys, values, row, prevRows[key]))
+	}
+	// Rows only in previous window, such as products no longer stocked
+	for i, row := range previous.Rows {
+		if key := rowKey(row, i, align); !matched[key] {
+			compared := compareRow(current.Columns, keys, values, nil, row)
+			for j, k := range keys {
+				if t, ok := compared[j].(int64); ok && align != nil && current.Columns[k].Type == "time" {
+					compared[j] = align(t)
+				}
+			}
+			table.Rows = append(table.Rows, compared)
+		}
+	}
+	return table
+}
+
+// compareRow compares the values in current and previous rows, either of which
+// can be nil if row only exists in one window. Missing numeric values are zero.
+func compareRow(
+	columns []Column,
+	keys []int,
+	values []int,
+	current []interface{},
+	previous []interface{},
+) []interface{} {
+	keyRow := current
+	if keyRow == nil {
+		keyRow = previous
+	}
+
+	row := []interface{}{}
+	for _, i := range keys {
+		row = append(row, keyRow[i])
+	}
+	for _, i := range values {
+		if !isNumeric(columns[i]) {
+			var v interface{}
+			if current != nil {
+				v = current[i]
+			}
+			row = append(row, v)
+			continue
+		}
+
+		var cur, prev float64
+		if current != nil {
+			cur = numericValue(current[i])
+		}
+		if previous != nil {
+			prev = numericValue(previous[i])
+		}
+		var pctChange interface{}
+		if prev != 0 {
+			pctChange = (cur - prev) / prev * 100
+		}
+		if columns[i].Type == "integer" {
+			row = append(row, int64(cur), int64(prev), int64(cur-prev), pctChange)
+		} else {
+			row = append(row, cur, prev, cur-prev, pctChange)
+		}
+	}
+	return row
+}
+
+func isNumeric(c Column) bool {
+	return c.Type == "integer" || c.Type == "number"
+}
+
+func numericValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPreviousWindow(t *testing.T) {
+	unix := func(year int, month time.Month, day int) int64 {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	// March 2020, which follows a leap-day
+	current := SearchByDate{
+		StartDate: unix(2020, 3, 1),
+		EndDate:   unix(2020, 3, 31),
+	}
+
+	tests := []struct {
+		opts CompareOptions
+		want SearchByDate
+	}{
+		{
+			opts: CompareOptions{Compare: ComparePrevious},
+			want: SearchByDate{
+				StartDate: unix(2020, 1, 31) - 1,
+				EndDate:   unix(2020, 3, 1) - 1,
+			},
+		},
+		{
+			opts: CompareOptions{Compare: CompareDay},
+			want: SearchByDate{StartDate: unix(2020, 2, 29), EndDate: unix(2020, 3, 30)},
+		},
+		{
+			opts: CompareOptions{Compare: CompareWeek},
+			want: SearchByDate{StartDate: unix(2020, 2, 23), EndDate: unix(2020, 3, 24)},
+		},
+		{
+			opts: CompareOptions{Compare: CompareMonth},
+			want: SearchByDate{StartDate: unix(2020, 2, 1), EndDate: unix(2020, 2, 29)},
+		},
+		{
+			opts: CompareOptions{Compare: CompareYear},
+			want: SearchByDate{StartDate: unix(2019, 3, 1), EndDate: unix(2019, 3, 31)},
+		},
+		{
+			opts: CompareOptions{PrevStartDate: unix(2019, 1, 1), PrevEndDate: unix(2019, 1, 31)},
+			want: SearchByDate{StartDate: unix(2019, 1, 1), EndDate: unix(2019, 1, 31)},
+		},
+	}
+	for _, test := range tests {
+		previous, align, err := test.opts.previousWindow(current)
+		if err != nil {
+			t.Errorf("%+v: %v", test.opts, err)
+			continue
+		}
+		if previous != test.want {
+			t.Errorf("%+v: got %+v, want %+v", test.opts, previous, test.want)
+		}
+		// Previous window's start is aligned to current window's start
+		if got := align(previous.StartDate); got != current.StartDate {
+			t.Errorf("%+v: aligned start to %d, want %d", test.opts, got, current.StartDate)
+		}
+	}
+
+	// Month-ends are clamped to the last day of shorter months
+	opts := CompareOptions{Compare: CompareYear}
+	previous, _, err := opts.previousWindow(SearchByDate{EndDate: unix(2020, 2, 29)})
+	if err != nil || previous.EndDate != unix(2019, 2, 28) {
+		t.Errorf("got %+v, %v, want end %d", previous, err, unix(2019, 2, 28))
+	}
+
+	// Previous window of an unbounded window
+	opts = CompareOptions{Compare: ComparePrevious}
+	if _, _, err := opts.previousWindow(SearchByDate{EndDate: current.EndDate}); err == nil {
+		t.Error("expected error for comparison without start_date")
+	}
+	opts = CompareOptions{Compare: "fortnight"}
+	if _, _, err := opts.previousWindow(current); err == nil {
+		t.Error("expected error for unsupported comparison")
+	}
+}
+
+func TestCompareTables(t *testing.T) {
+	columns := []Column{
+		{Name: "name", Type: "string", Key: true},
+		{Name: "unit", Type: "string"},
+		{Name: "items", Type: "integer"},
+		{Name: "weight", Type: "number"},
+	}
+	current := Table{
+		Name:    "by_name",
+		Columns: columns,
+		Rows: [][]interface{}{
+			{"Banana", "kg", int64(6), 30.0},
+			{"Apple", "kg", int64(2), 10.0},
+		},
+	}
+	previous := Table{
+		Name:    "by_name",
+		Columns: columns,
+		Rows: [][]interface{}{
+			{"Banana", "kg", int64(4), 40.0},
+			{"Pear", "kg", int64(1), 5.0},
+		},
+	}
+
+	got := compareTables(current, previous, nil)
+	wantColumns := []Column{
+		{Name: "name", Type: "string", Key: true},
+		{Name: "unit", Type: "string"},
+		{Name: "items", Type: "integer"},
+		{Name: "items_previous", Type: "integer"},
+		{Name: "items_change", Type: "integer"},
+		{Name: "items_pct_change", Type: "number"},
+		{Name: "weight", Type: "number"},
+		{Name: "weight_previous", Type: "number"},
+		{Name: "weight_change", Type: "number"},
+		{Name: "weight_pct_change", Type: "number"},
+	}
+	if !reflect.DeepEqual(got.Columns, wantColumns) {
+		t.Errorf("got columns %+v, want %+v", got.Columns, wantColumns)
+	}
+	wantRows := [][]interface{}{
+		{"Banana", "kg", int64(6), int64(4), int64(2), 50.0, 30.0, 40.0, -10.0, -25.0},
+		// No percentage-change without a previous value
+		{"Apple", "kg", int64(2), int64(0), int64(2), nil, 10.0, 0.0, 10.0, nil},
+		// Rows only in previous window have no current non-numeric values
+		{"Pear", nil, int64(0), int64(1), int64(-1), -100.0, 0.0, 5.0, -5.0, -100.0},
+	}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("got rows %v, want %v", got.Rows, wantRows)
+	}
+}
+
+// Time key-columns are matched after aligning previous window to current.
+func TestCompareTablesAlignsTime(t *testing.T) {
+	columns := []Column{
+		{Name: "bucket", Type: "time", Key: true},
+		{Name: "weight", Type: "number"},
+	}
+	current := Table{
+		Columns: columns,
+		Rows:    [][]interface{}{{int64(1000), 3.0}},
+	}
+	previous := Table{
+		Columns: columns,
+		Rows:    [][]interface{}{{int64(100), 2.0}, {int64(200), 1.0}},
+	}
+	align := func(t int64) int64 {
+		return t + 900
+	}
+
+	got := compareTables(current, previous, align)
+	want := [][]interface{}{
+		{int64(1000), 3.0, 2.0, 1.0, 50.0},
+		{int64(1100), 0.0, 1.0, -1.0, -100.0},
+	}
+	if !reflect.DeepEqual(got.Rows, want) {
+		t.Errorf("got %v, want %v", got.Rows, want)
+	}
+}
+
+// Tables without key-columns are compared by row-position.
+func TestCompareTablesByPosition(t *testing.T) {
+	columns := []Column{{Name: "total", Type: "number"}}
+	got := compareTables(
+		Table{Columns: columns, Rows: [][]interface{}{{8.0}}},
+		Table{Columns: columns, Rows: [][]interface{}{{10.0}}},
+		nil,
+	)
+	want := [][]interface{}{{8.0, 10.0, -2.0, -20.0}}
+	if !reflect.DeepEqual(got.Rows, want) {
+		t.Errorf("got %v, want %v", got.Rows, want)
+	}
+}
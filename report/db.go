@@ -1,18 +1,20 @@
 package report
 
 import (
+	"context"
+	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/TerrexTech/go-mongoutils/mongo"
+	"github.com/TerrexTech/uuuid"
 	"github.com/pkg/errors"
 )
 
-// type Collections struct {
-// 	Report    string
-// 	Metric    string
-// 	Inventory string
-// }
-
+// ConfigSchema was the schema of the Report-collection.
+//
+// Deprecated: each collection decodes its documents into its own
+// document-type, so this is no longer used.
 type ConfigSchema struct {
 	Report    *Report
 	Metric    *Metric
@@ -27,15 +29,66 @@ type DBIConfig struct {
 	TimeoutMilliseconds uint32
 	Database            string
 	Collection          string
+	// MetricCollection is the collection containing Metrics.
+	// Metric-searches are unavailable if this is not specified.
+	MetricCollection string
+	// InventoryCollection is the collection containing Inventory.
+	// Inventory-searches are unavailable if this is not specified.
+	InventoryCollection string
 }
 
 // DBI is the Database-interface for reporting.
 // This fetches/writes data to/from database for generating reports
 type DBI interface {
 	Collection() *mongo.Collection
-	CreateReportData() (*Report, error)
-	SearchByTimestamp(search *SearchByDate) (*Report, error)
-	SearchByFieldVal(search *[]SearchByFieldVal) (*Report, error)
+	CreateReportData(numOfVal int) ([]Report, error)
+
+	Query(filter Filter) (*[]Report, error)
+	QueryPage(filter Filter, opts PageOptions) (*ReportPage, error)
+	SearchByTimestamp(search []SearchByDate) (*[]Report, error)
+	SearchByFieldVal(search []SearchByFieldVal) (*[]Report, error)
+	StreamReports(ctx context.Context, filter Filter, batchSize int64) (<-chan Report, <-chan error)
+
+	QueryMetrics(filter Filter) (*[]Metric, error)
+	QueryMetricPage(filter Filter, opts PageOptions) (*MetricPage, error)
+	SearchMetricsByTimestamp(search []SearchByDate) (*[]Metric, error)
+	SearchMetricsByFieldVal(search []SearchByFieldVal) (*[]Metric, error)
+	StreamMetrics(ctx context.Context, filter Filter, batchSize int64) (<-chan Metric, <-chan error)
+
+	QueryInventory(filter Filter) (*[]Inventory, error)
+	QueryInventoryPage(filter Filter, opts PageOptions) (*InventoryPage, error)
+	SearchInventoryByTimestamp(search []SearchByDate) (*[]Inventory, error)
+	SearchInventoryByFieldVal(search []SearchByFieldVal) (*[]Inventory, error)
+	StreamInventory(ctx context.Context, filter Filter, batchSize int64) (<-chan Inventory, <-chan error)
+
+	ReportStats(query StatsQuery) ([]StatsBucket, error)
+	MetricStats(query StatsQuery) ([]StatsBucket, error)
+	InventoryStats(query StatsQuery) ([]StatsBucket, error)
+	InventoryWeights(interval Interval, filter Filter) ([]WeightTotals, error)
+	LocationGauges(maxAge time.Duration) ([]LocationGauge, error)
+
+	EthyleneReport(query EthyleneQuery) (*EthyleneExposure, error)
+	ColdChainReport(query ColdChainQuery) (*ColdChainExcursions, error)
+	WasteReport(query WasteQuery) (*WasteDonation, error)
+	SalesReport(query SalesQuery) (*SalesPerformance, error)
+	SpoilageReport(query SpoilageQuery) (*SpoilageRisk, error)
+	FEFOReport(query FEFOQuery) (*FEFOPickLists, error)
+	MarkdownReport(query MarkdownQuery) (*MarkdownSuggestions, error)
+
+	DetectAnomalies(query AnomalyQuery) (*AnomalyReport, error)
+	QueryAnomalies(filter Filter) (*[]Anomaly, error)
+
+	ComputeReport(reportType string, params json.RawMessage, window SearchByDate) (interface{}, error)
+	GenerateSnapshot(
+		reportID uuuid.UUID,
+		name string,
+		reportType string,
+		params json.RawMessage,
+		window SearchByDate,
+	) (*Report, error)
+	SaveSnapshot(report *Report) error
+	ReportSnapshots(reportID uuuid.UUID) (*[]Report, error)
+	ReportSnapshot(reportID uuuid.UUID, version int) (*Report, error)
 
 	// UserByUUID(uid uuuid.UUID) (*User, error)
 	// Login(user *User) (*User, error)
@@ -45,6 +98,8 @@ type DBI interface {
 // dbI is the Database-interface for generating reports.
 type DB struct {
 	collection *mongo.Collection
+	metric     *mongo.Collection
+	inventory  *mongo.Collection
 }
 
 type SearchByDate struct {
@@ -57,7 +112,17 @@ type SearchByFieldVal struct {
 	SearchVal   interface{} `bson:"search_val,omitempty" json:"search_val,omitempty"`
 }
 
+// GenerateDB creates the DB using the specified config. schema is ignored.
+//
+// Deprecated: use NewDB.
 func GenerateDB(dbConfig DBIConfig, schema *ConfigSchema) (*DB, error) {
+	return NewDB(dbConfig)
+}
+
+// NewDB creates the DB using the specified config.
+// Each collection decodes its results into its own document-type, so
+// Reports, Metrics and Inventory are fetched from separate collections.
+func NewDB(dbConfig DBIConfig) (*DB, error) {
 	config := mongo.ClientConfig{
 		Hosts:               dbConfig.Hosts,
 		Username:            dbConfig.Username,
@@ -98,22 +163,84 @@ func GenerateDB(dbConfig DBIConfig, schema *ConfigSchema) (*DB, error) {
 	// 	},
 	// }
 
+	// Snapshot-versions are unique per ReportID, see SaveSnapshot
+	indexConfigs := []mongo.IndexConfig{
+		mongo.IndexConfig{
+			ColumnConfig: []mongo.IndexColumnConfig{
+				mongo.IndexColumnConfig{
+					Name: "report_id",
+				},
+				mongo.IndexColumnConfig{
+					Name:        "version",
+					IsDescOrder: true,
+				},
+			},
+			IsUnique: true,
+			Name:     "report_id_version_index",
+		},
+	}
+
 	// ====> Create New Collection
 	collConfig := &mongo.Collection{
 		Connection:   conn,
 		Database:     dbConfig.Database,
 		Name:         dbConfig.Collection,
-		SchemaStruct: schema,
-		// Indexes:      indexConfigs,
+		SchemaStruct: &Report{},
+		Indexes:      indexConfigs,
 	}
 	c, err := mongo.EnsureCollection(collConfig)
 	if err != nil {
 		err = errors.Wrap(err, "Error creating DB-client")
 		return nil, err
 	}
-	return &DB{
+
+	db := &DB{
 		collection: c,
-	}, nil
+	}
+
+	if dbConfig.MetricCollection != "" {
+		db.metric, err = mongo.EnsureCollection(&mongo.Collection{
+			Connection:   conn,
+			Database:     dbConfig.Database,
+			Name:         dbConfig.MetricCollection,
+			SchemaStruct: &Metric{},
+		})
+		if err != nil {
+			err = errors.Wrap(err, "Error creating Metric collection")
+			return nil, err
+		}
+	}
+
+	if dbConfig.InventoryCollection != "" {
+		db.inventory, err = mongo.EnsureCollection(&mongo.Collection{
+			Connection:   conn,
+			Database:     dbConfig.Database,
+			Name:         dbConfig.InventoryCollection,
+			SchemaStruct: &Inventory{},
+		})
+		if err != nil {
+			err = errors.Wrap(err, "Error creating Inventory collection")
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// resultCollection returns a handle to the same collection, but which decodes
+// results into schema. This is used for queries whose results do not match the
+// collection's documents, such as aggregations.
+func resultCollection(coll *mongo.Collection, schema interface{}) (*mongo.Collection, error) {
+	c, err := mongo.EnsureCollection(&mongo.Collection{
+		Connection:   coll.Connection,
+		Database:     coll.Database,
+		Name:         coll.Name,
+		SchemaStruct: schema,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "Error creating result-collection")
+		return nil, err
+	}
+	return c, nil
 }
 
 // UserByUUID gets the User from DB using specified UUID.
@@ -137,84 +264,102 @@ func (db *DB) CreateReportData(numOfVal int) ([]Report, error) {
 	return report, nil
 }
 
-func (db *DB) SearchByTimestamp(search []SearchByDate) (*[]Report, error) {
-	var findResults []interface{}
-	var err error
-	for _, val := range search {
-		if val.StartDate != 0 && val.EndDate != 0 {
-			//Find
-			findResults, err = db.collection.Find(map[string]interface{}{
-				"timestamp": map[string]int64{
-					"$lte": val.EndDate,
-					"$gte": val.StartDate,
-				},
-			})
-		}
-
-		if val.StartDate == 0 && val.EndDate != 0 {
-			findResults, err = db.collection.Find(map[string]interface{}{
-				"timestamp": map[string]int64{
-					"$lte": val.EndDate,
-				},
-			})
-		}
-	}
-
+// Query fetches the Reports matching the specified Filter.
+// A nil Filter matches all Reports. Anomaly-reports are not included.
+func (db *DB) Query(filter Filter) (*[]Report, error) {
+	findResults, err := db.collection.Find(withoutAnomalies(filter).Compile())
 	if err != nil {
-		err = errors.Wrap(err, "Error while fetching product.")
+		err = errors.Wrap(err, "Error while fetching report.")
 		log.Println(err)
 		return nil, err
 	}
 
-	//length
-	if len(findResults) == 0 {
-		msg := "No results found - SearchByDate"
-		return nil, errors.New(msg)
-	}
-
 	report := []Report{}
 
 	for _, v := range findResults {
-		result := v.(*Report)
+		result, ok := v.(*Report)
+		if !ok {
+			err = errors.New("Error asserting search-result to Report")
+			log.Println(err)
+			return nil, err
+		}
 		report = append(report, *result)
 	}
 	return &report, nil
 }
 
-func (db *DB) SearchByFieldVal(search []SearchByFieldVal) (*[]Report, error) {
+// TimestampFilter creates a Filter matching documents whose
+// timestamp lies in any of the specified date-ranges.
+func TimestampFilter(search []SearchByDate) (Filter, error) {
+	ranges := []Filter{}
+	for _, val := range search {
+		if val.EndDate != 0 {
+			ranges = append(ranges, TimeRange("timestamp", val.StartDate, val.EndDate))
+		}
+	}
 
-	var findResults []interface{}
-	var err error
+	if len(ranges) == 0 {
+		msg := "No valid date-range specified - SearchByDate"
+		return nil, errors.New(msg)
+	}
+	return Or(ranges...), nil
+}
 
+// FieldValFilter creates a Filter matching documents which
+// match all of the specified field-values.
+func FieldValFilter(search []SearchByFieldVal) (Filter, error) {
+	conditions := []Filter{}
 	for _, v := range search {
-		if v.SearchField != "" && v.SearchVal != "" {
-			findResults, err = db.collection.Find(map[string]interface{}{
-				v.SearchField: map[string]interface{}{
-					"$eq": &v.SearchVal,
-				},
-			})
+		if v.SearchField != "" && v.SearchVal != nil && v.SearchVal != "" {
+			conditions = append(conditions, Eq(v.SearchField, v.SearchVal))
 		}
 	}
 
+	if len(conditions) == 0 {
+		msg := "No valid search-field specified - SearchByFieldVal"
+		return nil, errors.New(msg)
+	}
+	return And(conditions...), nil
+}
+
+// SearchByTimestamp fetches the Reports whose timestamp lies in any of the specified date-ranges.
+func (db *DB) SearchByTimestamp(search []SearchByDate) (*[]Report, error) {
+	filter, err := TimestampFilter(search)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := db.Query(filter)
 	if err != nil {
-		err = errors.Wrap(err, "Error while fetching product.")
-		log.Println(err)
 		return nil, err
 	}
 
 	//length
-	if len(findResults) == 0 {
+	if len(*report) == 0 {
 		msg := "No results found - SearchByDate"
 		return nil, errors.New(msg)
 	}
+	return report, nil
+}
 
-	report := []Report{}
+// SearchByFieldVal fetches the Reports matching all of the specified field-values.
+func (db *DB) SearchByFieldVal(search []SearchByFieldVal) (*[]Report, error) {
+	filter, err := FieldValFilter(search)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, v := range findResults {
-		result := v.(*Report)
-		report = append(report, *result)
+	report, err := db.Query(filter)
+	if err != nil {
+		return nil, err
 	}
-	return &report, nil
+
+	//length
+	if len(*report) == 0 {
+		msg := "No results found - SearchByFieldVal"
+		return nil, errors.New(msg)
+	}
+	return report, nil
 }
 
 // func (db *DB) InsertIntoReport(report Report) (*mgo.InsertOneResult, error) {
@@ -243,15 +388,6 @@ func (db *DB) SearchByFieldVal(search []SearchByFieldVal) (*[]Report, error) {
 
 // }
 
-// func (db *DB) EthyleneReport(inventory []Inventory) (*Report, error) {
-
-// 	findResults, err = db.collection.Find(map[string]interface{}{
-// 		inventory.SKU: map[string]interface{}{
-// 			"$eq": &v.SearchVal,
-// 		},
-// 	})
-// }
-
 // Collection returns the currrent MongoDB collection being used for user-auth operations.
 func (d *DB) Collection() *mongo.Collection {
 	return d.collection
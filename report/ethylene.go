@@ -0,0 +1,349 @@
+package report
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/pkg/errors"
+)
+
+// defaultRipeningThreshold is the ethylene-exposure (in ppm-hours)
+// above which an item is predicted to ripen early.
+const defaultRipeningThreshold = 500
+
+// defaultMaxReadingGap is the maximum duration for which a Metric-reading
+// is considered valid if no newer reading is available.
+const defaultMaxReadingGap = time.Hour
+
+// EthyleneQuery specifies the parameters for EthyleneReport.
+type EthyleneQuery struct {
+	SearchByDate
+	// RipeningThreshold is the exposure in ppm-hours above which items are
+	// flagged for early ripening. Defaults to defaultRipeningThreshold.
+	RipeningThreshold float64 `json:"ripening_threshold,omitempty"`
+	// ProductThresholds overrides RipeningThreshold by product Name.
+	ProductThresholds map[string]float64 `json:"product_thresholds,omitempty"`
+	// MaxReadingGapSeconds is the maximum duration a reading is held for
+	// if no newer reading is available. Defaults to one hour.
+	MaxReadingGapSeconds int64 `json:"max_reading_gap_seconds,omitempty"`
+}
+
+// ItemEthylene is the ethylene-exposure of a single Inventory item.
+type ItemEthylene struct {
+	ItemID   uuuid.UUID `json:"item_id"`
+	DeviceID uuuid.UUID `json:"device_id"`
+	SKU      int64      `json:"sku,omitempty"`
+	Name     string     `json:"name,omitempty"`
+	Location string     `json:"location,omitempty"`
+	Readings int        `json:"readings"`
+	PeakPPM  float64    `json:"peak_ppm"`
+	// Exposure is the cumulative exposure in ppm-hours during report-period.
+	Exposure float64 `json:"exposure"`
+	// ProjectedExposure is the exposure expected by item's expiry-date
+	// if the average concentration persists.
+	ProjectedExposure float64 `json:"projected_exposure"`
+	EarlyRipening     bool    `json:"early_ripening"`
+}
+
+// EthyleneRollup summarizes item-exposures for a Location or product Name.
+type EthyleneRollup struct {
+	Key           string  `json:"key"`
+	Items         int     `json:"items"`
+	TotalExposure float64 `json:"total_exposure"`
+	AvgExposure   float64 `json:"avg_exposure"`
+	MaxExposure   float64 `json:"max_exposure"`
+	EarlyRipening int     `json:"early_ripening"`
+}
+
+// EthyleneExposure is the result of EthyleneReport.
+type EthyleneExposure struct {
+	StartDate  int64            `json:"start_date"`
+	EndDate    int64            `json:"end_date"`
+	Items      []ItemEthylene   `json:"items"`
+	ByLocation []EthyleneRollup `json:"by_location"`
+	ByName     []EthyleneRollup `json:"by_name"`
+}
+
+// timedReading is a single sensor-value at a point in time.
+type timedReading struct {
+	Timestamp int64
+	Value     float64
+}
+
+// EthyleneReport calculates the ethylene-exposure for Inventory items stored
+// during the date-range from the Metric-readings of their sensors. Metrics are joined to Inventory
+// using ItemID, or using DeviceID for Metrics without an ItemID.
+func (db *DB) EthyleneReport(query EthyleneQuery) (*EthyleneExposure, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	startDate, endDate := reportWindow(query.SearchByDate)
+	threshold := query.RipeningThreshold
+	if threshold <= 0 {
+		threshold = defaultRipeningThreshold
+	}
+	maxGap := defaultMaxReadingGap
+	if query.MaxReadingGapSeconds > 0 {
+		maxGap = time.Duration(query.MaxReadingGapSeconds) * time.Second
+	}
+
+	items, err := db.inventoryItems(stockedFilter(startDate, endDate))
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for ethylene-report")
+		return nil, err
+	}
+	metrics, err := db.itemMetrics(items, startDate, endDate)
+	if err != nil {
+		err = errors.Wrap(err, "Error loading metrics for ethylene-report")
+		return nil, err
+	}
+
+	exposure := &EthyleneExposure{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Items:     []ItemEthylene{},
+	}
+	for itemID, itemMetrics := range metrics {
+		item := items.byItemID[itemID]
+		itemReadings := metricValues(itemMetrics, func(m *Metric) float64 {
+			return m.Ethylene
+		})
+
+		itemExposure := ItemEthylene{
+			ItemID:   item.ItemID,
+			DeviceID: item.DeviceID,
+			SKU:      item.SKU,
+			Name:     item.Name,
+			Location: item.Location,
+			Readings: len(itemReadings),
+			Exposure: integrateReadings(itemReadings, endDate, maxGap),
+		}
+		for _, r := range itemReadings {
+			if r.Value > itemExposure.PeakPPM {
+				itemExposure.PeakPPM = r.Value
+			}
+		}
+
+		// Project the exposure till expiry using the average concentration
+		itemExposure.ProjectedExposure = itemExposure.Exposure
+		exposedHours := float64(endDate-itemReadings[0].Timestamp) / 3600
+		if item.ExpiryDate > endDate && exposedHours > 0 {
+			avgPPM := itemExposure.Exposure / exposedHours
+			remainingHours := float64(item.ExpiryDate-endDate) / 3600
+			itemExposure.ProjectedExposure += avgPPM * remainingHours
+		}
+
+		itemThreshold := threshold
+		if t, ok := query.ProductThresholds[item.Name]; ok && t > 0 {
+			itemThreshold = t
+		}
+		itemExposure.EarlyRipening = itemExposure.ProjectedExposure >= itemThreshold
+
+		exposure.Items = append(exposure.Items, itemExposure)
+	}
+
+	sort.Slice(exposure.Items, func(i, j int) bool {
+		return exposure.Items[i].Exposure > exposure.Items[j].Exposure
+	})
+	exposure.ByLocation = rollupEthylene(exposure.Items, func(i ItemEthylene) string {
+		return i.Location
+	})
+	exposure.ByName = rollupEthylene(exposure.Items, func(i ItemEthylene) string {
+		return i.Name
+	})
+	return exposure, nil
+}
+
+func rollupEthylene(items []ItemEthylene, key func(ItemEthylene) string) []EthyleneRollup {
+	rollups := map[string]*EthyleneRollup{}
+	keys := []string{}
+	for _, item := range items {
+		k := key(item)
+		rollup, ok := rollups[k]
+		if !ok {
+			rollup = &EthyleneRollup{
+				Key: k,
+			}
+			rollups[k] = rollup
+			keys = append(keys, k)
+		}
+		rollup.Items++
+		rollup.TotalExposure += item.Exposure
+		if item.Exposure > rollup.MaxExposure {
+			rollup.MaxExposure = item.Exposure
+		}
+		if item.EarlyRipening {
+			rollup.EarlyRipening++
+		}
+	}
+
+	sort.Strings(keys)
+	result := []EthyleneRollup{}
+	for _, k := range keys {
+		rollup := rollups[k]
+		rollup.AvgExposure = rollup.TotalExposure / float64(rollup.Items)
+		result = append(result, *rollup)
+	}
+	return result
+}
+
+// integrateReadings calculates the area under readings (value-hours).
+// Each reading is held until the next reading, but for no longer than
+// maxGap. The last reading is held until endDate (or maxGap).
+// readings must be sorted by timestamp.
+func integrateReadings(readings []timedReading, endDate int64, maxGap time.Duration) float64 {
+	maxGapSeconds := int64(maxGap / time.Second)
+	total := 0.0
+	for i, r := range readings {
+		until := endDate
+		if i+1 < len(readings) {
+			until = readings[i+1].Timestamp
+		}
+		duration := until - r.Timestamp
+		if duration > maxGapSeconds {
+			duration = maxGapSeconds
+		}
+		if duration > 0 {
+			total += r.Value * float64(duration) / 3600
+		}
+	}
+	return total
+}
+
+// reportWindow returns the start and end dates for a report.
+// The end-date defaults to current time.
+func reportWindow(search SearchByDate) (int64, int64) {
+	endDate := search.EndDate
+	if endDate == 0 {
+		endDate = time.Now().Unix()
+	}
+	return search.StartDate, endDate
+}
+
+// inventoryIndex indexes Inventory items for joining with Metrics.
+type inventoryIndex struct {
+	byItemID   map[string]*Inventory
+	byDeviceID map[string][]*Inventory
+}
+
+// stockedFilter matches the Inventory stored during date-range, which
+// arrived by endDate and had not expired by startDate.
+func stockedFilter(startDate int64, endDate int64) Filter {
+	return And(
+		TimeRange("date_arrived", 0, endDate),
+		TimeRange("expiry_date", startDate, 0),
+	)
+}
+
+// inventoryItems loads the Inventory matching filter, indexed by ItemID and DeviceID.
+func (db *DB) inventoryItems(filter Filter) (*inventoryIndex, error) {
+	index := &inventoryIndex{
+		byItemID:   map[string]*Inventory{},
+		byDeviceID: map[string][]*Inventory{},
+	}
+
+	inventory, errChan := db.StreamInventory(context.Background(), filter, 0)
+	for inv := range inventory {
+		item := inv
+		index.byItemID[item.ItemID.String()] = &item
+		deviceID := item.DeviceID.String()
+		index.byDeviceID[deviceID] = append(index.byDeviceID[deviceID], &item)
+	}
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// itemMetrics loads the Metrics in date-range for items, keyed by ItemID.
+// The Metrics are sorted by timestamp.
+func (db *DB) itemMetrics(
+	items *inventoryIndex,
+	startDate int64,
+	endDate int64,
+) (map[string][]Metric, error) {
+	itemMetrics := map[string][]Metric{}
+
+	metrics, errChan := db.StreamMetrics(
+		context.Background(),
+		TimeRange("timestamp", startDate, endDate),
+		0,
+	)
+	for m := range metrics {
+		itemID := m.ItemID.String()
+		if itemID != (uuuid.UUID{}).String() {
+			if _, ok := items.byItemID[itemID]; ok {
+				itemMetrics[itemID] = append(itemMetrics[itemID], m)
+			}
+			continue
+		}
+		for _, item := range items.byDeviceID[m.DeviceID.String()] {
+			id := item.ItemID.String()
+			itemMetrics[id] = append(itemMetrics[id], m)
+		}
+	}
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	for _, m := range itemMetrics {
+		sortedMetrics := m
+		sort.Slice(sortedMetrics, func(i, j int) bool {
+			return sortedMetrics[i].Timestamp < sortedMetrics[j].Timestamp
+		})
+	}
+	return itemMetrics, nil
+}
+
+// metricValues extracts the timed-readings for a Metric-value.
+func metricValues(metrics []Metric, value func(m *Metric) float64) []timedReading {
+	readings := make([]timedReading, len(metrics))
+	for i := range metrics {
+		readings[i] = timedReading{
+			Timestamp: metrics[i].Timestamp,
+			Value:     value(&metrics[i]),
+		}
+	}
+	return readings
+}
+
+// ethyleneReport is the "ethylene" ReportType.
+type ethyleneReport struct{}
+
+func init() {
+	RegisterReportType(ethyleneReport{})
+}
+
+func (ethyleneReport) Name() string {
+	return "ethylene"
+}
+
+func (ethyleneReport) Description() string {
+	return "Cumulative ethylene-exposure per item, with items at risk of early ripening."
+}
+
+func (ethyleneReport) Params() []Param {
+	return queryParams(&EthyleneQuery{})
+}
+
+func (ethyleneReport) NewQuery() Query {
+	return &EthyleneQuery{}
+}
+
+func (ethyleneReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.EthyleneReport(*query.(*EthyleneQuery))
+}
+
+func (ethyleneReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*EthyleneExposure)
+	if !ok {
+		return nil, errors.New("Error asserting result to EthyleneExposure")
+	}
+	return []Table{
+		structTable("items", r.Items, "item_id"),
+		structTable("by_location", r.ByLocation, "key").withChart("total_exposure"),
+		structTable("by_name", r.ByName, "key").withChart("avg_exposure"),
+	}, nil
+}
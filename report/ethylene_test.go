@@ -0,0 +1,89 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIntegrateReadings(t *testing.T) {
+	tests := []struct {
+		name     string
+		readings []timedReading
+		endDate  int64
+		maxGap   time.Duration
+		want     float64
+	}{
+		{"no readings", nil, 7200, time.Hour, 0},
+		{
+			name:     "held until next reading",
+			readings: []timedReading{{0, 10}, {1800, 20}},
+			endDate:  3600,
+			maxGap:   time.Hour,
+			want:     10*0.5 + 20*0.5,
+		},
+		{
+			name:     "last reading held until endDate",
+			readings: []timedReading{{0, 4}},
+			endDate:  1800,
+			maxGap:   time.Hour,
+			want:     2,
+		},
+		{
+			name:     "gaps capped at maxGap",
+			readings: []timedReading{{0, 10}, {4 * 3600, 20}},
+			endDate:  10 * 3600,
+			maxGap:   time.Hour,
+			want:     10 + 20,
+		},
+		{
+			name:     "readings after endDate",
+			readings: []timedReading{{0, 10}, {7200, 20}},
+			endDate:  3600,
+			maxGap:   3 * time.Hour,
+			want:     10 * 2,
+		},
+	}
+	for _, test := range tests {
+		got := integrateReadings(test.readings, test.endDate, test.maxGap)
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRollupEthylene(t *testing.T) {
+	items := []ItemEthylene{
+		{Location: "B201", Exposure: 600, EarlyRipening: true},
+		{Location: "A101", Exposure: 100},
+		{Location: "B201", Exposure: 200},
+	}
+	got := rollupEthylene(items, func(i ItemEthylene) string {
+		return i.Location
+	})
+	want := []EthyleneRollup{
+		{Key: "A101", Items: 1, TotalExposure: 100, AvgExposure: 100, MaxExposure: 100},
+		{Key: "B201", Items: 2, TotalExposure: 800, AvgExposure: 400, MaxExposure: 600, EarlyRipening: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = rollupEthylene(nil, func(i ItemEthylene) string {
+		return i.Name
+	})
+	if got == nil || len(got) != 0 {
+		t.Errorf("got %#v, want empty rollups", got)
+	}
+}
+
+func TestStockedFilter(t *testing.T) {
+	got := stockedFilter(10, 20).Compile()
+	want := doc{"$and": []interface{}{
+		doc{"date_arrived": doc{"$lte": int64(20)}},
+		doc{"expiry_date": doc{"$gte": int64(10)}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
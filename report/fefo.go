@@ -0,0 +1,295 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/pkg/errors"
+)
+
+// defaultPickHorizonDays is the number of days within which expiring
+// items are included in pick-lists.
+const defaultPickHorizonDays = 3
+
+// Pick-list instructions.
+const (
+	PickRemove    = "remove"
+	PickSellFirst = "sell first"
+)
+
+// FEFOQuery specifies the parameters for FEFOReport.
+type FEFOQuery struct {
+	// AsOf is the Unix-timestamp to create pick-lists at. Defaults to current time.
+	AsOf int64 `json:"as_of,omitempty"`
+	// Location optionally restricts pick-lists to a single Location.
+	Location string `json:"location,omitempty"`
+	// HorizonDays is the number of days within which expiring items are
+	// picked. Defaults to defaultPickHorizonDays.
+	HorizonDays float64 `json:"horizon_days,omitempty"`
+}
+
+// Validate checks the horizon.
+func (q *FEFOQuery) Validate() error {
+	if q.HorizonDays < 0 {
+		return errors.New("horizon_days cannot be negative")
+	}
+	return nil
+}
+
+// Window returns the point in time the report is calculated at as EndDate.
+func (q *FEFOQuery) Window() SearchByDate {
+	return SearchByDate{
+		EndDate: q.AsOf,
+	}
+}
+
+// SetWindow calculates the report as of window's EndDate.
+func (q *FEFOQuery) SetWindow(window SearchByDate) {
+	q.AsOf = window.EndDate
+}
+
+// PickItem is an Inventory item in a pick-list.
+type PickItem struct {
+	Rank            int        `json:"rank"`
+	ItemID          uuuid.UUID `json:"item_id"`
+	SKU             int64      `json:"sku,omitempty"`
+	Name            string     `json:"name,omitempty"`
+	RemainingWeight float64    `json:"remaining_weight"`
+	ExpiryDate      int64      `json:"expiry_date"`
+	DaysToExpiry    float64    `json:"days_to_expiry"`
+	// Instruction is "remove" for expired items, and "sell first" otherwise.
+	Instruction string `json:"instruction"`
+}
+
+// RotationLot is the position of an Inventory item on shelf,
+// with position 1 being the front.
+type RotationLot struct {
+	Position        int        `json:"position"`
+	ItemID          uuuid.UUID `json:"item_id"`
+	RemainingWeight float64    `json:"remaining_weight"`
+	DateArrived     int64      `json:"date_arrived"`
+	ExpiryDate      int64      `json:"expiry_date"`
+}
+
+// Rotation is the shelf-order for the lots of a product at a Location.
+type Rotation struct {
+	Name string        `json:"name"`
+	Lots []RotationLot `json:"lots"`
+	// ArrivalOrderDiffers is true if rotating by arrival-date (FIFO) would
+	// not sell the earliest-expiring lots first.
+	ArrivalOrderDiffers bool   `json:"arrival_order_differs"`
+	Instruction         string `json:"instruction"`
+}
+
+// LocationPickList is the pick-list and rotation-instructions for a Location.
+type LocationPickList struct {
+	Location  string     `json:"location"`
+	Picks     []PickItem `json:"picks"`
+	Rotations []Rotation `json:"rotations"`
+}
+
+// FEFOPickLists is the result of FEFOReport.
+type FEFOPickLists struct {
+	AsOf      int64              `json:"as_of"`
+	Locations []LocationPickList `json:"locations"`
+}
+
+// FEFOReport creates first-expired-first-out pick-lists for each Location.
+// Pick-lists contain the items on hand expiring within horizon, ordered by
+// ExpiryDate. Rotations specify the shelf-order for products with multiple lots.
+func (db *DB) FEFOReport(query FEFOQuery) (*FEFOPickLists, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	asOf := query.AsOf
+	if asOf == 0 {
+		asOf = time.Now().Unix()
+	}
+	horizonDays := query.HorizonDays
+	if horizonDays <= 0 {
+		horizonDays = defaultPickHorizonDays
+	}
+	day := float64(24 * time.Hour / time.Second)
+	horizon := asOf + int64(horizonDays*day)
+
+	filters := []Filter{
+		Lte("date_arrived", asOf),
+		inStockFilter(),
+	}
+	if query.Location != "" {
+		filters = append(filters, Eq("location", query.Location))
+	}
+	items, err := db.inventoryItems(And(filters...))
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for FEFO-report")
+		return nil, err
+	}
+
+	byLocation := map[string][]*Inventory{}
+	locations := []string{}
+	for _, item := range items.byItemID {
+		if _, ok := byLocation[item.Location]; !ok {
+			locations = append(locations, item.Location)
+		}
+		byLocation[item.Location] = append(byLocation[item.Location], item)
+	}
+	sort.Strings(locations)
+
+	result := &FEFOPickLists{
+		AsOf:      asOf,
+		Locations: []LocationPickList{},
+	}
+	for _, location := range locations {
+		locItems := byLocation[location]
+		sortFEFO(locItems)
+
+		pickList := LocationPickList{
+			Location:  location,
+			Picks:     []PickItem{},
+			Rotations: fefoRotations(locItems),
+		}
+		for _, item := range locItems {
+			if item.ExpiryDate > horizon {
+				break
+			}
+			instruction := PickSellFirst
+			if item.ExpiryDate <= asOf {
+				instruction = PickRemove
+			}
+			pickList.Picks = append(pickList.Picks, PickItem{
+				Rank:            len(pickList.Picks) + 1,
+				ItemID:          item.ItemID,
+				SKU:             item.SKU,
+				Name:            item.Name,
+				RemainingWeight: remainingWeight(item),
+				ExpiryDate:      item.ExpiryDate,
+				DaysToExpiry:    float64(item.ExpiryDate-asOf) / day,
+				Instruction:     instruction,
+			})
+		}
+		result.Locations = append(result.Locations, pickList)
+	}
+	return result, nil
+}
+
+// fefoRotations creates the Rotations for products with multiple lots.
+// items must be sorted using sortFEFO.
+func fefoRotations(items []*Inventory) []Rotation {
+	byName := map[string][]*Inventory{}
+	names := []string{}
+	for _, item := range items {
+		if _, ok := byName[item.Name]; !ok {
+			names = append(names, item.Name)
+		}
+		byName[item.Name] = append(byName[item.Name], item)
+	}
+	sort.Strings(names)
+
+	rotations := []Rotation{}
+	for _, name := range names {
+		lots := byName[name]
+		if len(lots) < 2 {
+			continue
+		}
+
+		rotation := Rotation{
+			Name:        name,
+			Lots:        []RotationLot{},
+			Instruction: "Place lots front to back in listed order",
+		}
+		for i, lot := range lots {
+			rotation.Lots = append(rotation.Lots, RotationLot{
+				Position:        i + 1,
+				ItemID:          lot.ItemID,
+				RemainingWeight: remainingWeight(lot),
+				DateArrived:     lot.DateArrived,
+				ExpiryDate:      lot.ExpiryDate,
+			})
+			if i > 0 && lot.DateArrived < lots[i-1].DateArrived {
+				rotation.ArrivalOrderDiffers = true
+			}
+		}
+		if rotation.ArrivalOrderDiffers {
+			rotation.Instruction += "; newer arrivals expire sooner, so do not rotate by arrival date"
+		}
+		rotations = append(rotations, rotation)
+	}
+	return rotations
+}
+
+// sortFEFO sorts items by ExpiryDate, then by DateArrived.
+func sortFEFO(items []*Inventory) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ExpiryDate != items[j].ExpiryDate {
+			return items[i].ExpiryDate < items[j].ExpiryDate
+		}
+		return items[i].DateArrived < items[j].DateArrived
+	})
+}
+
+// locationPick is a PickItem with its Location, for rendering.
+type locationPick struct {
+	Location string `json:"location"`
+	PickItem
+}
+
+// rotationLot is a RotationLot with its Location and product, for rendering.
+type rotationLot struct {
+	Location            string `json:"location"`
+	Name                string `json:"name"`
+	ArrivalOrderDiffers bool   `json:"arrival_order_differs"`
+	RotationLot
+}
+
+// fefoReport is the "fefo" ReportType.
+type fefoReport struct{}
+
+func init() {
+	RegisterReportType(fefoReport{})
+}
+
+func (fefoReport) Name() string {
+	return "fefo"
+}
+
+func (fefoReport) Description() string {
+	return "First-expired-first-out pick-lists and stock-rotation instructions per location."
+}
+
+func (fefoReport) Params() []Param {
+	return queryParams(&FEFOQuery{})
+}
+
+func (fefoReport) NewQuery() Query {
+	return &FEFOQuery{}
+}
+
+func (fefoReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.FEFOReport(*query.(*FEFOQuery))
+}
+
+func (fefoReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*FEFOPickLists)
+	if !ok {
+		return nil, errors.New("Error asserting result to FEFOPickLists")
+	}
+	picks := []locationPick{}
+	lots := []rotationLot{}
+	for _, l := range r.Locations {
+		for _, p := range l.Picks {
+			picks = append(picks, locationPick{l.Location, p})
+		}
+		for _, rotation := range l.Rotations {
+			for _, lot := range rotation.Lots {
+				lots = append(lots, rotationLot{
+					l.Location, rotation.Name, rotation.ArrivalOrderDiffers, lot,
+				})
+			}
+		}
+	}
+	return []Table{
+		structTable("picks", picks, "location", "item_id"),
+		structTable("rotations", lots, "location", "name", "item_id"),
+	}, nil
+}
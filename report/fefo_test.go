@@ -0,0 +1,72 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortFEFO(t *testing.T) {
+	late := &Inventory{Name: "late", DateArrived: 1, ExpiryDate: 30}
+	newer := &Inventory{Name: "newer", DateArrived: 5, ExpiryDate: 20}
+	older := &Inventory{Name: "older", DateArrived: 2, ExpiryDate: 20}
+	items := []*Inventory{late, newer, older}
+
+	sortFEFO(items)
+	want := []*Inventory{older, newer, late}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", names(items), names(want))
+	}
+}
+
+func TestFEFORotations(t *testing.T) {
+	apples := []*Inventory{
+		{ItemID: newUUID(t), Name: "Apple", TotalWeight: 10, SoldWeight: 2, DateArrived: 1, ExpiryDate: 10},
+		{ItemID: newUUID(t), Name: "Apple", TotalWeight: 5, DateArrived: 3, ExpiryDate: 12},
+	}
+	// The newer banana-lot expires first
+	bananas := []*Inventory{
+		{ItemID: newUUID(t), Name: "Banana", TotalWeight: 4, DateArrived: 8, ExpiryDate: 11},
+		{ItemID: newUUID(t), Name: "Banana", TotalWeight: 6, DateArrived: 2, ExpiryDate: 15},
+	}
+	single := &Inventory{ItemID: newUUID(t), Name: "Cherry", TotalWeight: 1, ExpiryDate: 9}
+
+	items := []*Inventory{single, apples[0], apples[1], bananas[0], bananas[1]}
+	sortFEFO(items)
+	got := fefoRotations(items)
+
+	want := []Rotation{
+		{
+			Name: "Apple",
+			Lots: []RotationLot{
+				{Position: 1, ItemID: apples[0].ItemID, RemainingWeight: 8, DateArrived: 1, ExpiryDate: 10},
+				{Position: 2, ItemID: apples[1].ItemID, RemainingWeight: 5, DateArrived: 3, ExpiryDate: 12},
+			},
+			Instruction: "Place lots front to back in listed order",
+		},
+		{
+			Name: "Banana",
+			Lots: []RotationLot{
+				{Position: 1, ItemID: bananas[0].ItemID, RemainingWeight: 4, DateArrived: 8, ExpiryDate: 11},
+				{Position: 2, ItemID: bananas[1].ItemID, RemainingWeight: 6, DateArrived: 2, ExpiryDate: 15},
+			},
+			ArrivalOrderDiffers: true,
+			Instruction: "Place lots front to back in listed order; " +
+				"newer arrivals expire sooner, so do not rotate by arrival date",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := fefoRotations([]*Inventory{single}); got == nil || len(got) != 0 {
+		t.Errorf("got %+v, want no rotations for a single lot", got)
+	}
+}
+
+func names(items []*Inventory) []string {
+	n := []string{}
+	for _, item := range items {
+		n = append(n, item.Name)
+	}
+	return n
+}
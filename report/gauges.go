@@ -0,0 +1,206 @@
+package report
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LocationGauge is the current state of a Location, from the latest Metric-reading
+// of each device at the Location and the Inventory stored there.
+type LocationGauge struct {
+	Location string `json:"location"`
+	// Devices is the number of devices at Location with a recent reading.
+	// The readings are averaged across these devices, except Humidity,
+	// which is averaged across the devices that measured humidity.
+	Devices  int     `json:"devices"`
+	TempIn   float64 `json:"temp_in"`
+	Humidity float64 `json:"humidity"`
+	Ethylene float64 `json:"ethylene"`
+	CarbonDi float64 `json:"carbon_di"`
+	// Timestamp is the timestamp of the latest reading at Location.
+	Timestamp int64 `json:"timestamp"`
+
+	// Items and OnHandWeight are the number and remaining weight of
+	// on-hand items, while WasteWeight and DonateWeight are totals.
+	Items        int     `json:"items"`
+	OnHandWeight float64 `json:"on_hand_weight"`
+	WasteWeight  float64 `json:"waste_weight"`
+	DonateWeight float64 `json:"donate_weight"`
+}
+
+// locationStock is the Inventory at a Location, aggregated for LocationGauges.
+type locationStock struct {
+	Location     string  `bson:"location,omitempty"`
+	Items        int64   `bson:"items,omitempty"`
+	OnHandWeight float64 `bson:"on_hand_weight,omitempty"`
+	WasteWeight  float64 `bson:"waste_weight,omitempty"`
+	DonateWeight float64 `bson:"donate_weight,omitempty"`
+	// DeviceIDs are the devices of on-hand items at Location.
+	DeviceIDs []string `bson:"device_ids,omitempty"`
+}
+
+// LocationGauges returns the current gauges for each Location, sorted by Location.
+// Metric-readings older than maxAge are considered stale and are ignored, so
+// Locations without recent readings only have Inventory-totals.
+// maxAge defaults to defaultMaxReadingGap.
+func (db *DB) LocationGauges(maxAge time.Duration) ([]LocationGauge, error) {
+	if maxAge <= 0 {
+		maxAge = defaultMaxReadingGap
+	}
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+
+	now := time.Now().Unix()
+	stock, err := db.locationStock(now)
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for location-gauges")
+		return nil, err
+	}
+
+	// Latest reading of each device, which is at the Locations of its items
+	latest := map[string]Metric{}
+	metrics, errChan := db.StreamMetrics(
+		context.Background(),
+		TimeRange("timestamp", now-int64(maxAge/time.Second), now),
+		0,
+	)
+	for m := range metrics {
+		deviceID := m.DeviceID.String()
+		if l, ok := latest[deviceID]; !ok || m.Timestamp > l.Timestamp {
+			latest[deviceID] = m
+		}
+	}
+	if err := <-errChan; err != nil {
+		err = errors.Wrap(err, "Error loading metrics for location-gauges")
+		return nil, err
+	}
+	return locationGauges(stock, latest), nil
+}
+
+// locationStock aggregates the Inventory by Location. Items and OnHandWeight
+// only include the items on hand at asOf, which have arrived, not expired and
+// are not sold or wasted. WasteWeight and DonateWeight are totals of all items.
+func (db *DB) locationStock(asOf int64) ([]locationStock, error) {
+	remaining := remainingWeightExpression()
+	onHand := map[string]interface{}{
+		"$and": []interface{}{
+			map[string]interface{}{"$lte": []interface{}{"$date_arrived", asOf}},
+			map[string]interface{}{"$gt": []interface{}{"$expiry_date", asOf}},
+			map[string]interface{}{"$gt": []interface{}{remaining, 0}},
+		},
+	}
+	pipeline := []interface{}{
+		map[string]interface{}{
+			"$group": map[string]interface{}{
+				"_id": "$location",
+				"items": map[string]interface{}{
+					"$sum": map[string]interface{}{"$cond": []interface{}{onHand, 1, 0}},
+				},
+				"on_hand_weight": map[string]interface{}{
+					"$sum": map[string]interface{}{"$cond": []interface{}{onHand, remaining, 0}},
+				},
+				"waste_weight":  map[string]interface{}{"$sum": "$waste_weight"},
+				"donate_weight": map[string]interface{}{"$sum": "$donate_weight"},
+				"device_ids": map[string]interface{}{
+					"$addToSet": map[string]interface{}{
+						"$cond": []interface{}{onHand, "$device_id", "$$REMOVE"},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"$project": map[string]interface{}{
+				"_id":            0,
+				"location":       "$_id",
+				"items":          1,
+				"on_hand_weight": 1,
+				"waste_weight":   1,
+				"donate_weight":  1,
+				"device_ids":     1,
+			},
+		},
+	}
+
+	stockColl, err := resultCollection(db.inventory, &locationStock{})
+	if err != nil {
+		return nil, err
+	}
+	aggResults, err := stockColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error aggregating location-stock")
+		log.Println(err)
+		return nil, err
+	}
+
+	stock := []locationStock{}
+	for _, v := range aggResults {
+		s, ok := v.(*locationStock)
+		if !ok {
+			err = errors.New("Error asserting aggregate-result to locationStock")
+			log.Println(err)
+			return nil, err
+		}
+		stock = append(stock, *s)
+	}
+	return stock, nil
+}
+
+// locationGauges combines the stock at each Location with the
+// latest readings of its devices, keyed by DeviceID.
+func locationGauges(stock []locationStock, latest map[string]Metric) []LocationGauge {
+	gauges := map[string]*LocationGauge{}
+	deviceLocations := map[string][]string{}
+	humidityDevices := map[string]int{}
+	for _, s := range stock {
+		gauges[s.Location] = &LocationGauge{
+			Location:     s.Location,
+			Items:        int(s.Items),
+			OnHandWeight: s.OnHandWeight,
+			WasteWeight:  s.WasteWeight,
+			DonateWeight: s.DonateWeight,
+		}
+		for _, deviceID := range s.DeviceIDs {
+			deviceLocations[deviceID] = append(deviceLocations[deviceID], s.Location)
+		}
+	}
+
+	for deviceID, m := range latest {
+		for _, location := range deviceLocations[deviceID] {
+			g := gauges[location]
+			g.Devices++
+			g.TempIn += m.TempIn
+			if humidity, ok := m.MeasuredHumidity(); ok {
+				g.Humidity += humidity
+				humidityDevices[location]++
+			}
+			g.Ethylene += m.Ethylene
+			g.CarbonDi += m.CarbonDi
+			if m.Timestamp > g.Timestamp {
+				g.Timestamp = m.Timestamp
+			}
+		}
+	}
+
+	result := []LocationGauge{}
+	for _, g := range gauges {
+		if g.Devices > 0 {
+			n := float64(g.Devices)
+			g.TempIn /= n
+			g.Ethylene /= n
+			g.CarbonDi /= n
+		}
+		if n := humidityDevices[g.Location]; n > 0 {
+			g.Humidity /= float64(n)
+		}
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Location < result[j].Location
+	})
+	return result
+}
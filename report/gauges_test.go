@@ -0,0 +1,59 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocationGauges(t *testing.T) {
+	shared := newUUID(t)
+	only := newUUID(t)
+	stock := []locationStock{
+		{Location: "B201", Items: 1, OnHandWeight: 5, DeviceIDs: []string{shared.String()}},
+		{
+			Location:     "A101",
+			Items:        2,
+			OnHandWeight: 40,
+			WasteWeight:  3,
+			DeviceIDs:    []string{shared.String(), only.String()},
+		},
+		// Only expired items, so no devices
+		{Location: "C301", WasteWeight: 7},
+	}
+	latest := map[string]Metric{
+		shared.String(): {DeviceID: shared, Timestamp: 100, TempIn: 2, Humidity: 90, CarbonDi: 400},
+		// Device without a humidity-sensor
+		only.String(): {DeviceID: only, Timestamp: 200, TempIn: 4, CarbonDi: 600},
+		// Device without on-hand items
+		newUUID(t).String(): {Timestamp: 300, TempIn: 20},
+	}
+
+	got := locationGauges(stock, latest)
+	want := []LocationGauge{
+		{
+			Location:     "A101",
+			Devices:      2,
+			TempIn:       3,
+			Humidity:     90,
+			CarbonDi:     500,
+			Timestamp:    200,
+			Items:        2,
+			OnHandWeight: 40,
+			WasteWeight:  3,
+		},
+		{
+			Location:     "B201",
+			Devices:      1,
+			TempIn:       2,
+			Humidity:     90,
+			CarbonDi:     400,
+			Timestamp:    100,
+			Items:        1,
+			OnHandWeight: 5,
+		},
+		{Location: "C301", WasteWeight: 7},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
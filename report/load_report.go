@@ -51,7 +51,6 @@ func generateNewUUID() uuuid.UUID {
 var productsName = []string{"Banana", "Orange", "Apple", "Mango", "Strawberry", "Tomato", "Lettuce", "Pear", "Grapes", "Sweet Pepper"}
 var locationName = []string{"A101", "B201", "O301", "M401", "S501", "T601", "L701", "P801", "G901", "SW1001"}
 var provinceNames = []string{"ON Canada", "BC Canada", "SK Canada", "MN Canada", "NS Canada", "PEI Canada", "QC Canada"}
-var reportTypes = []string{"Metric", "Inventory"}
 
 type GeneratedData struct {
 	RType Report
@@ -81,12 +80,13 @@ func GenData() GeneratedData {
 	deviceId := generateNewUUID()
 	location := locationName[randNameAndLocation]
 	customerId := generateNewUUID()
+	reportTypes := ReportTypes()
 
 	report := Report{
 		ItemID:       itemId,
 		ReportID:     generateNewUUID(),
 		RsCustomerID: customerId,
-		ReportType:   reportTypes[generateRandomValue(1, 2)],
+		ReportType:   reportTypes[generateRandomValue(0, int64(len(reportTypes)))].Name(),
 		AggregateID:  2,
 		Timestamp:    time.Now().Unix(),
 	}
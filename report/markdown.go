@@ -0,0 +1,430 @@
+package report
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/pkg/errors"
+)
+
+// defaultMarkdownHorizonDays is the number of days within which expiring
+// items are suggested markdowns.
+const defaultMarkdownHorizonDays = 5
+
+// defaultMarkdownLookbackDays is the number of days of sales-history
+// used to estimate sell-rates.
+const defaultMarkdownLookbackDays = 30
+
+// defaultPriceElasticity is the price-elasticity of demand for produce.
+const defaultPriceElasticity = 1.5
+
+// defaultMarkdowns are the discount-levels evaluated for each item.
+var defaultMarkdowns = []float64{0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.7}
+
+// MarkdownQuery specifies the parameters for MarkdownReport.
+type MarkdownQuery struct {
+	// AsOf is the Unix-timestamp to suggest markdowns at. Defaults to current time.
+	AsOf int64 `json:"as_of,omitempty"`
+	// Location and Name optionally restrict the items included.
+	Location string `json:"location,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// HorizonDays is the number of days within which expiring items are
+	// included. Defaults to defaultMarkdownHorizonDays.
+	HorizonDays float64 `json:"horizon_days,omitempty"`
+	// LookbackDays is the number of days of sales-history used to
+	// estimate sell-rates. Defaults to defaultMarkdownLookbackDays.
+	LookbackDays float64 `json:"lookback_days,omitempty"`
+	// Markdowns are the discount-levels to evaluate, as fractions of price.
+	// Defaults to defaultMarkdowns.
+	Markdowns []float64 `json:"markdowns,omitempty"`
+	// Elasticity is the price-elasticity of demand, so a discount d
+	// multiplies the sell-rate by (1-d)^-Elasticity.
+	// Defaults to defaultPriceElasticity.
+	Elasticity float64 `json:"elasticity,omitempty"`
+	// WastePenalty is the cost per unit-weight of wasted items, such as
+	// for disposal. Markdowns are suggested to maximize revenue less this penalty.
+	WastePenalty float64 `json:"waste_penalty,omitempty"`
+}
+
+// Validate checks the markdowns, which must be in range [0, 1).
+func (q *MarkdownQuery) Validate() error {
+	for _, d := range q.Markdowns {
+		if d < 0 || d >= 1 {
+			return errors.New("Markdowns must be in range [0, 1)")
+		}
+	}
+	return nil
+}
+
+// Window returns the point in time the report is calculated at as EndDate.
+func (q *MarkdownQuery) Window() SearchByDate {
+	return SearchByDate{
+		EndDate: q.AsOf,
+	}
+}
+
+// SetWindow calculates the report as of window's EndDate.
+func (q *MarkdownQuery) SetWindow(window SearchByDate) {
+	q.AsOf = window.EndDate
+}
+
+// SellRate is the historical sell-rate for a product Name.
+type SellRate struct {
+	Name      string `json:"name"`
+	SoldItems int64  `json:"sold_items"`
+	// SellThrough is the fraction of weight sold for items sold during lookback.
+	SellThrough float64 `json:"sell_through"`
+	// WeightPerDay is the average weight sold per item per day on shelf.
+	WeightPerDay float64 `json:"weight_per_day"`
+}
+
+// MarkdownOption is the expected outcome of a markdown.
+type MarkdownOption struct {
+	Discount        float64 `json:"discount"`
+	Price           float64 `json:"price"`
+	ExpectedSold    float64 `json:"expected_sold"`
+	ExpectedWaste   float64 `json:"expected_waste"`
+	ExpectedRevenue float64 `json:"expected_revenue"`
+}
+
+// ItemMarkdown is the suggested markdown for an Inventory item.
+type ItemMarkdown struct {
+	ItemID          uuuid.UUID `json:"item_id"`
+	SKU             int64      `json:"sku,omitempty"`
+	Name            string     `json:"name,omitempty"`
+	Location        string     `json:"location,omitempty"`
+	RemainingWeight float64    `json:"remaining_weight"`
+	ExpiryDate      int64      `json:"expiry_date"`
+	DaysToExpiry    float64    `json:"days_to_expiry"`
+	// RegularPrice is the SalePrice, or the Price if item has no SalePrice.
+	RegularPrice float64          `json:"regular_price"`
+	SellRate     float64          `json:"sell_rate"`
+	Suggested    MarkdownOption   `json:"suggested"`
+	Options      []MarkdownOption `json:"options"`
+}
+
+// MarkdownSuggestions is the result of MarkdownReport.
+type MarkdownSuggestions struct {
+	AsOf      int64          `json:"as_of"`
+	SellRates []SellRate     `json:"sell_rates"`
+	Items     []ItemMarkdown `json:"items"`
+	// Expected totals without markdowns, and with suggested markdowns.
+	BaselineRevenue  float64 `json:"baseline_revenue"`
+	BaselineWaste    float64 `json:"baseline_waste"`
+	SuggestedRevenue float64 `json:"suggested_revenue"`
+	SuggestedWaste   float64 `json:"suggested_waste"`
+}
+
+// sellRateResult is the result of sell-rate aggregation.
+type sellRateResult struct {
+	Name        string  `bson:"name,omitempty"`
+	SoldItems   int64   `bson:"sold_items,omitempty"`
+	TotalWeight float64 `bson:"total_weight,omitempty"`
+	SoldWeight  float64 `bson:"sold_weight,omitempty"`
+	ShelfDays   float64 `bson:"shelf_days,omitempty"`
+}
+
+// MarkdownReport suggests discounts for on-hand items expiring within horizon.
+// The expected sales for each discount are estimated from the historical
+// sell-rate of item's product Name, adjusted for price-elasticity.
+func (db *DB) MarkdownReport(query MarkdownQuery) (*MarkdownSuggestions, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	asOf := query.AsOf
+	if asOf == 0 {
+		asOf = time.Now().Unix()
+	}
+	day := float64(24 * time.Hour / time.Second)
+	horizonDays := query.HorizonDays
+	if horizonDays <= 0 {
+		horizonDays = defaultMarkdownHorizonDays
+	}
+	lookbackDays := query.LookbackDays
+	if lookbackDays <= 0 {
+		lookbackDays = defaultMarkdownLookbackDays
+	}
+	markdowns := query.Markdowns
+	if len(markdowns) == 0 {
+		markdowns = defaultMarkdowns
+	}
+	elasticity := query.Elasticity
+	if elasticity <= 0 {
+		elasticity = defaultPriceElasticity
+	}
+
+	rates, err := db.sellRates(asOf-int64(lookbackDays*day), asOf)
+	if err != nil {
+		return nil, err
+	}
+	rateByName := map[string]float64{}
+	var overallSold, overallDays float64
+	for _, r := range rates {
+		rateByName[r.Name] = r.WeightPerDay
+		if r.WeightPerDay > 0 {
+			overallSold += r.WeightPerDay * float64(r.SoldItems)
+			overallDays += float64(r.SoldItems)
+		}
+	}
+	// Products without sales-history use the average sell-rate
+	overallRate := 0.0
+	if overallDays > 0 {
+		overallRate = overallSold / overallDays
+	}
+
+	filters := []Filter{
+		Lte("date_arrived", asOf),
+		Gt("expiry_date", asOf),
+		Lte("expiry_date", asOf+int64(horizonDays*day)),
+	}
+	if query.Location != "" {
+		filters = append(filters, Eq("location", query.Location))
+	}
+	if query.Name != "" {
+		filters = append(filters, Eq("name", query.Name))
+	}
+	items, err := db.inventoryItems(And(filters...))
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for markdown-report")
+		return nil, err
+	}
+
+	result := &MarkdownSuggestions{
+		AsOf:      asOf,
+		SellRates: rates,
+		Items:     []ItemMarkdown{},
+	}
+	for _, item := range items.byItemID {
+		remaining := remainingWeight(item)
+		if remaining <= 0 {
+			continue
+		}
+		rate, ok := rateByName[item.Name]
+		if !ok {
+			rate = overallRate
+		}
+		price := item.SalePrice
+		if price <= 0 {
+			price = item.Price
+		}
+
+		markdown := ItemMarkdown{
+			ItemID:          item.ItemID,
+			SKU:             item.SKU,
+			Name:            item.Name,
+			Location:        item.Location,
+			RemainingWeight: remaining,
+			ExpiryDate:      item.ExpiryDate,
+			DaysToExpiry:    float64(item.ExpiryDate-asOf) / day,
+			RegularPrice:    price,
+			SellRate:        rate,
+		}
+		outcome := markdownOutcome{
+			remaining:    remaining,
+			price:        price,
+			rate:         rate,
+			days:         markdown.DaysToExpiry,
+			elasticity:   elasticity,
+			wastePenalty: query.WastePenalty,
+		}
+		baseline := outcome.option(0)
+		markdown.Suggested, markdown.Options = outcome.suggest(markdowns)
+
+		result.BaselineRevenue += baseline.ExpectedRevenue
+		result.BaselineWaste += baseline.ExpectedWaste
+		result.SuggestedRevenue += markdown.Suggested.ExpectedRevenue
+		result.SuggestedWaste += markdown.Suggested.ExpectedWaste
+		result.Items = append(result.Items, markdown)
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		return result.Items[i].ExpiryDate < result.Items[j].ExpiryDate
+	})
+	return result, nil
+}
+
+// markdownOutcome estimates the outcome of markdowns for an item.
+type markdownOutcome struct {
+	remaining    float64
+	price        float64
+	rate         float64
+	days         float64
+	elasticity   float64
+	wastePenalty float64
+}
+
+// option returns the expected outcome of discount d.
+func (o markdownOutcome) option(d float64) MarkdownOption {
+	uplift := math.Pow(1-d, -o.elasticity)
+	sold := math.Min(o.remaining, o.rate*uplift*o.days)
+	return MarkdownOption{
+		Discount:        d,
+		Price:           o.price * (1 - d),
+		ExpectedSold:    sold,
+		ExpectedWaste:   o.remaining - sold,
+		ExpectedRevenue: sold * o.price * (1 - d),
+	}
+}
+
+// suggest returns the best of markdowns, along with the outcome of each
+// sorted by discount. Not discounting is always considered, so an item
+// is only marked down if that does better than leaving the price as is.
+func (o markdownOutcome) suggest(markdowns []float64) (MarkdownOption, []MarkdownOption) {
+	suggested := o.option(0)
+	bestValue := suggested.ExpectedRevenue - o.wastePenalty*suggested.ExpectedWaste
+
+	options := []MarkdownOption{}
+	for _, d := range markdowns {
+		option := o.option(d)
+		options = append(options, option)
+
+		// Ties are resolved in favour of lower waste, and then lower discount
+		value := option.ExpectedRevenue - o.wastePenalty*option.ExpectedWaste
+		if value > bestValue ||
+			(value == bestValue && option.ExpectedWaste < suggested.ExpectedWaste) ||
+			(value == bestValue && option.ExpectedWaste == suggested.ExpectedWaste && d < suggested.Discount) {
+			bestValue = value
+			suggested = option
+		}
+	}
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Discount < options[j].Discount
+	})
+	return suggested, options
+}
+
+// sellRates aggregates the sell-rates by product Name for items sold in date-range.
+func (db *DB) sellRates(startDate int64, endDate int64) ([]SellRate, error) {
+	day := int64(24 * time.Hour / time.Second)
+	pipeline := []interface{}{
+		map[string]interface{}{
+			"$match": And(
+				TimeRange("date_sold", startDate, endDate),
+				Gt("date_arrived", 0),
+			).Compile(),
+		},
+		map[string]interface{}{
+			"$group": map[string]interface{}{
+				"_id":          "$name",
+				"sold_items":   map[string]interface{}{"$sum": 1},
+				"total_weight": map[string]interface{}{"$sum": "$total_weight"},
+				"sold_weight":  map[string]interface{}{"$sum": "$sold_weight"},
+				// Items are on shelf for at least an hour
+				"shelf_days": map[string]interface{}{
+					"$sum": map[string]interface{}{
+						"$max": []interface{}{
+							map[string]interface{}{
+								"$divide": []interface{}{
+									map[string]interface{}{
+										"$subtract": []interface{}{"$date_sold", "$date_arrived"},
+									},
+									day,
+								},
+							},
+							1.0 / 24,
+						},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"$project": map[string]interface{}{
+				"_id":          0,
+				"name":         "$_id",
+				"sold_items":   1,
+				"total_weight": 1,
+				"sold_weight":  1,
+				"shelf_days":   1,
+			},
+		},
+		map[string]interface{}{
+			"$sort": sortDocument([]SortField{{Field: "name"}}),
+		},
+	}
+
+	rateColl, err := resultCollection(db.inventory, &sellRateResult{})
+	if err != nil {
+		return nil, err
+	}
+	aggResults, err := rateColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error aggregating sell-rates")
+		log.Println(err)
+		return nil, err
+	}
+
+	rates := []SellRate{}
+	for _, v := range aggResults {
+		r, ok := v.(*sellRateResult)
+		if !ok {
+			err = errors.New("Error asserting aggregate-result to sellRateResult")
+			log.Println(err)
+			return nil, err
+		}
+		rate := SellRate{
+			Name:      r.Name,
+			SoldItems: r.SoldItems,
+		}
+		if r.TotalWeight > 0 {
+			rate.SellThrough = r.SoldWeight / r.TotalWeight
+		}
+		if r.ShelfDays > 0 {
+			rate.WeightPerDay = r.SoldWeight / r.ShelfDays
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// markdownReport is the "markdown" ReportType.
+type markdownReport struct{}
+
+func init() {
+	RegisterReportType(markdownReport{})
+}
+
+func (markdownReport) Name() string {
+	return "markdown"
+}
+
+func (markdownReport) Description() string {
+	return "Suggested markdowns for near-expiry stock which maximize expected revenue net of waste."
+}
+
+func (markdownReport) Params() []Param {
+	return queryParams(&MarkdownQuery{})
+}
+
+func (markdownReport) NewQuery() Query {
+	return &MarkdownQuery{}
+}
+
+func (markdownReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.MarkdownReport(*query.(*MarkdownQuery))
+}
+
+func (markdownReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*MarkdownSuggestions)
+	if !ok {
+		return nil, errors.New("Error asserting result to MarkdownSuggestions")
+	}
+	totals := []struct {
+		BaselineRevenue  float64 `json:"baseline_revenue"`
+		BaselineWaste    float64 `json:"baseline_waste"`
+		SuggestedRevenue float64 `json:"suggested_revenue"`
+		SuggestedWaste   float64 `json:"suggested_waste"`
+	}{
+		{r.BaselineRevenue, r.BaselineWaste, r.SuggestedRevenue, r.SuggestedWaste},
+	}
+	return []Table{
+		structTable("totals", totals),
+		structTable("items", r.Items, "item_id"),
+		structTable("sell_rates", r.SellRates, "name").withChart("weight_per_day"),
+	}, nil
+}
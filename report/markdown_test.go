@@ -0,0 +1,45 @@
+package report
+
+import (
+	"testing"
+)
+
+func TestMarkdownSuggest(t *testing.T) {
+	// Sells 10 per day for 2 days without markdown, out of 40 remaining
+	outcome := markdownOutcome{
+		remaining:  40,
+		price:      2,
+		rate:       10,
+		days:       2,
+		elasticity: 1,
+	}
+
+	baseline := outcome.option(0)
+	if baseline.ExpectedSold != 20 || baseline.ExpectedWaste != 20 || baseline.ExpectedRevenue != 40 {
+		t.Errorf("got baseline %+v", baseline)
+	}
+
+	// Selling everything at half price earns the same revenue as the
+	// baseline, so the tie goes to the lower waste.
+	suggested, options := outcome.suggest([]float64{0.5, 0})
+	if suggested.Discount != 0.5 || len(options) != 2 || options[0].Discount != 0 {
+		t.Errorf("got %+v from %+v, want half-price markdown", suggested, options)
+	}
+
+	// Without a zero discount, the baseline is still considered
+	suggested, _ = outcome.suggest([]float64{0.8})
+	if suggested != baseline {
+		t.Errorf("got %+v, want baseline %+v", suggested, baseline)
+	}
+
+	// A waste-penalty makes a deeper markdown worthwhile
+	suggested, _ = outcome.suggest([]float64{0.6})
+	if suggested != baseline {
+		t.Errorf("got %+v, want baseline %+v", suggested, baseline)
+	}
+	outcome.wastePenalty = 1
+	suggested, _ = outcome.suggest([]float64{0.6})
+	if suggested.Discount != 0.6 || suggested.ExpectedWaste != 0 {
+		t.Errorf("got %+v, want 60%% markdown", suggested)
+	}
+}
@@ -0,0 +1,468 @@
+package report
+
+import (
+	"encoding/base64"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/TerrexTech/go-mongoutils/mongo"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"github.com/pkg/errors"
+)
+
+// defaultPageLimit is the number of results returned per page if no limit is specified.
+const defaultPageLimit = 50
+
+// maxPageLimit is the maximum number of results that can be returned in a single page.
+const maxPageLimit = 1000
+
+// SortField is a field to sort the search-results by.
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// PageOptions specifies the paging, sorting and projection for a search.
+// Results can be paged either using Skip, or using the NextPageToken
+// returned with the previous page, which is more efficient for large
+// result-sets. Skip is ignored if a PageToken is specified.
+type PageOptions struct {
+	Limit     int64       `json:"limit,omitempty"`
+	Skip      int64       `json:"skip,omitempty"`
+	PageToken string      `json:"page_token,omitempty"`
+	Sort      []SortField `json:"sort,omitempty"`
+	// Fields is the list of fields to be returned in results.
+	// All fields are returned if this is empty.
+	Fields []string `json:"fields,omitempty"`
+	// WithCount includes the total number of results matching the search in the page.
+	WithCount bool `json:"with_count,omitempty"`
+}
+
+// InvalidPageError is returned by page-searches for invalid PageOptions,
+// such as a malformed page-token. Use errors.Cause to check for it.
+type InvalidPageError struct {
+	Reason string
+}
+
+func (e *InvalidPageError) Error() string {
+	return "Invalid page-options: " + e.Reason
+}
+
+// Validate checks the limit, skip and sort-fields of PageOptions.
+// Limits above the maximum are not an error, and are lowered to the maximum.
+func (opts *PageOptions) Validate() error {
+	if opts.Limit < 0 {
+		return &InvalidPageError{Reason: "limit cannot be negative"}
+	}
+	if opts.Skip < 0 {
+		return &InvalidPageError{Reason: "skip cannot be negative"}
+	}
+	for _, s := range opts.Sort {
+		if s.Field == "" || strings.HasPrefix(s.Field, "$") {
+			return &InvalidPageError{Reason: "invalid sort-field \"" + s.Field + "\""}
+		}
+	}
+	return nil
+}
+
+// ReportPage is a single page of Reports.
+type ReportPage struct {
+	Reports       []Report `json:"reports"`
+	NextPageToken string   `json:"next_page_token,omitempty"`
+	TotalCount    int64    `json:"total_count,omitempty"`
+}
+
+// InventoryPage is a single page of Inventory.
+type InventoryPage struct {
+	Inventory     []Inventory `json:"inventory"`
+	NextPageToken string      `json:"next_page_token,omitempty"`
+	TotalCount    int64       `json:"total_count,omitempty"`
+}
+
+// countResult is the result of a count-aggregation.
+type countResult struct {
+	Count int64 `bson:"count,omitempty"`
+}
+
+// ParsePageOptions reads PageOptions from URL query-parameters.
+// The supported parameters are limit, skip, page_token, count (true/false),
+// sort (comma-separated fields, prefixed with "-" for descending order)
+// and fields (comma-separated fields to return).
+// A nil PageOptions is returned if none of the parameters are present.
+func ParsePageOptions(values url.Values) (*PageOptions, error) {
+	params := []string{"limit", "skip", "page_token", "count", "sort", "fields"}
+	found := false
+	for _, p := range params {
+		if _, ok := values[p]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	opts := &PageOptions{
+		PageToken: values.Get("page_token"),
+	}
+
+	var err error
+	if limit := values.Get("limit"); limit != "" {
+		opts.Limit, err = strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			err = errors.Wrap(err, "Error parsing limit")
+			return nil, err
+		}
+	}
+	if skip := values.Get("skip"); skip != "" {
+		opts.Skip, err = strconv.ParseInt(skip, 10, 64)
+		if err != nil {
+			err = errors.Wrap(err, "Error parsing skip")
+			return nil, err
+		}
+	}
+	if count := values.Get("count"); count != "" {
+		opts.WithCount, err = strconv.ParseBool(count)
+		if err != nil {
+			err = errors.Wrap(err, "Error parsing count")
+			return nil, err
+		}
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			sf := SortField{
+				Field: strings.TrimPrefix(field, "-"),
+				Desc:  strings.HasPrefix(field, "-"),
+			}
+			opts.Sort = append(opts.Sort, sf)
+		}
+	}
+
+	if fields := values.Get("fields"); fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" {
+				opts.Fields = append(opts.Fields, field)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// QueryPage fetches a single page of the Reports matching the specified Filter.
+// Anomaly-reports are not included.
+func (db *DB) QueryPage(filter Filter, opts PageOptions) (*ReportPage, error) {
+	findResults, nextToken, count, err := findPage(db.collection, withoutAnomalies(filter), opts)
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching report-page.")
+		log.Println(err)
+		return nil, err
+	}
+
+	page := &ReportPage{
+		Reports:       []Report{},
+		NextPageToken: nextToken,
+		TotalCount:    count,
+	}
+	for _, v := range findResults {
+		result, ok := v.(*Report)
+		if !ok {
+			err = errors.New("Error asserting search-result to Report")
+			log.Println(err)
+			return nil, err
+		}
+		page.Reports = append(page.Reports, *result)
+	}
+	return page, nil
+}
+
+// QueryInventoryPage fetches a single page of the Inventory matching the specified Filter.
+func (db *DB) QueryInventoryPage(filter Filter, opts PageOptions) (*InventoryPage, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+
+	findResults, nextToken, count, err := findPage(db.inventory, filter, opts)
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching inventory-page.")
+		log.Println(err)
+		return nil, err
+	}
+
+	page := &InventoryPage{
+		Inventory:     []Inventory{},
+		NextPageToken: nextToken,
+		TotalCount:    count,
+	}
+	for _, v := range findResults {
+		result, ok := v.(*Inventory)
+		if !ok {
+			err = errors.New("Error asserting search-result to Inventory")
+			log.Println(err)
+			return nil, err
+		}
+		page.Inventory = append(page.Inventory, *result)
+	}
+	return page, nil
+}
+
+// findPage fetches a page of documents from the collection.
+// The results are fetched in the order specified by opts.Sort, with "_id" as
+// the final tie-breaker so every document has a unique position. The returned
+// token encodes the sort-values of the last document, and the next page
+// begins right after that document.
+// An InvalidPageError is returned for invalid opts.
+func findPage(
+	coll *mongo.Collection,
+	filter Filter,
+	opts PageOptions,
+) ([]interface{}, string, int64, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, "", 0, err
+	}
+	if filter == nil {
+		filter = And()
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	sort := pageSort(opts.Sort)
+
+	var count int64
+	if opts.WithCount {
+		var err error
+		count, err = countDocuments(coll, filter)
+		if err != nil {
+			return nil, "", 0, err
+		}
+	}
+
+	pageFilter := filter
+	findOpts := []findopt.Find{
+		// One extra document is fetched to check if there is a next page
+		findopt.Limit(limit + 1),
+		findopt.Sort(sortDocument(sort)),
+	}
+
+	if opts.PageToken != "" {
+		values, err := decodePageToken(opts.PageToken, sort)
+		if err != nil {
+			return nil, "", 0, &InvalidPageError{Reason: err.Error()}
+		}
+		pageFilter = And(filter, afterFilter(sort, values))
+	} else if opts.Skip > 0 {
+		findOpts = append(findOpts, findopt.Skip(opts.Skip))
+	}
+
+	if len(opts.Fields) > 0 {
+		projection := map[string]interface{}{}
+		for _, f := range opts.Fields {
+			projection[f] = 1
+		}
+		// Sort-fields are required for generating the next page-token
+		for _, s := range sort {
+			projection[s.Field] = 1
+		}
+		findOpts = append(findOpts, findopt.Projection(projection))
+	}
+
+	findResults, err := coll.Find(pageFilter.Compile(), findOpts...)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if int64(len(findResults)) <= limit {
+		return findResults, "", count, nil
+	}
+
+	findResults = findResults[:limit]
+	nextToken, err := encodePageToken(findResults[len(findResults)-1], sort)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return findResults, nextToken, count, nil
+}
+
+// countDocuments returns the number of documents in collection matching the filter.
+func countDocuments(coll *mongo.Collection, filter Filter) (int64, error) {
+	countColl, err := resultCollection(coll, &countResult{})
+	if err != nil {
+		return 0, err
+	}
+
+	pipeline := []interface{}{
+		map[string]interface{}{
+			"$match": filter.Compile(),
+		},
+		map[string]interface{}{
+			"$count": "count",
+		},
+	}
+	aggResults, err := countColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error counting documents")
+		return 0, err
+	}
+	if len(aggResults) == 0 {
+		return 0, nil
+	}
+
+	result, ok := aggResults[0].(*countResult)
+	if !ok {
+		return 0, errors.New("Error asserting count-result")
+	}
+	return result.Count, nil
+}
+
+// pageSort returns the sort-fields with "_id" appended as tie-breaker.
+func pageSort(sort []SortField) []SortField {
+	pageSort := []SortField{}
+	for _, s := range sort {
+		if s.Field == "_id" {
+			return append(pageSort, s)
+		}
+		pageSort = append(pageSort, s)
+	}
+	return append(pageSort, SortField{Field: "_id"})
+}
+
+func sortDocument(sort []SortField) *bson.Document {
+	doc := bson.NewDocument()
+	for _, s := range sort {
+		order := int32(1)
+		if s.Desc {
+			order = -1
+		}
+		doc.Append(bson.EC.Int32(s.Field, order))
+	}
+	return doc
+}
+
+// sortKey is stored in page-token to ensure that the token is
+// used with the same sort-order it was generated with.
+func sortKey(sort []SortField) string {
+	keys := []string{}
+	for _, s := range sort {
+		key := s.Field
+		if s.Desc {
+			key = "-" + key
+		}
+		keys = append(keys, key)
+	}
+	return strings.Join(keys, ",")
+}
+
+// afterFilter matches documents which come after the specified sort-values.
+// For sort-fields a, b: (a > va) OR (a == va AND b > vb).
+func afterFilter(sort []SortField, values []interface{}) Filter {
+	conditions := []Filter{}
+	for i, s := range sort {
+		prefix := []Filter{}
+		for j := 0; j < i; j++ {
+			prefix = append(prefix, Eq(sort[j].Field, values[j]))
+		}
+
+		var after Filter
+		switch {
+		// Missing fields sort first in ascending order, and last in descending order
+		case values[i] == nil && s.Desc:
+			continue
+		case values[i] == nil:
+			after = Ne(s.Field, nil)
+		case s.Desc:
+			after = Lt(s.Field, values[i])
+		default:
+			after = Gt(s.Field, values[i])
+		}
+		conditions = append(conditions, And(append(prefix, after)...))
+	}
+	return Or(conditions...)
+}
+
+func encodePageToken(doc interface{}, sort []SortField) (string, error) {
+	docBytes, err := bson.Marshal(doc)
+	if err != nil {
+		err = errors.Wrap(err, "Error marshalling document for page-token")
+		return "", err
+	}
+	docMap := make(map[string]interface{})
+	err = bson.Unmarshal(docBytes, &docMap)
+	if err != nil {
+		err = errors.Wrap(err, "Error unmarshalling document for page-token")
+		return "", err
+	}
+
+	// Sort-values are stored by their index, along with the sort-key
+	token := map[string]interface{}{
+		"sort": sortKey(sort),
+	}
+	for i, s := range sort {
+		if s.Field == "_id" {
+			token[strconv.Itoa(i)] = documentID(doc)
+			continue
+		}
+		if docMap[s.Field] != nil {
+			token[strconv.Itoa(i)] = docMap[s.Field]
+		}
+	}
+
+	tokenBytes, err := bson.Marshal(token)
+	if err != nil {
+		err = errors.Wrap(err, "Error marshalling page-token")
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+func decodePageToken(pageToken string, sort []SortField) ([]interface{}, error) {
+	tokenBytes, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		err = errors.Wrap(err, "Error decoding page-token")
+		return nil, err
+	}
+	token := make(map[string]interface{})
+	err = bson.Unmarshal(tokenBytes, &token)
+	if err != nil {
+		err = errors.Wrap(err, "Error unmarshalling page-token")
+		return nil, err
+	}
+
+	if token["sort"] != sortKey(sort) {
+		return nil, errors.New("Page-token does not match the sort-order")
+	}
+
+	values := make([]interface{}, len(sort))
+	for i := range sort {
+		values[i] = token[strconv.Itoa(i)]
+	}
+	return values, nil
+}
+
+// documentID returns the ObjectID of document.
+// This is required because not all documents marshal their ID.
+func documentID(doc interface{}) objectid.ObjectID {
+	switch d := doc.(type) {
+	case *Report:
+		return d.ID
+	case *Metric:
+		return d.ID
+	case *Inventory:
+		return d.ID
+	}
+	return objectid.NilObjectID
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/pkg/errors"
+)
+
+func TestParsePageOptions(t *testing.T) {
+	opts, err := ParsePageOptions(url.Values{"location": {"A101"}})
+	if err != nil || opts != nil {
+		t.Errorf("got %+v, %v for no page-params, want nil", opts, err)
+	}
+
+	values, _ := url.ParseQuery("limit=20&skip=40&count=true&sort=location,-expiry_date,&fields=name, sku")
+	opts, err = ParsePageOptions(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PageOptions{
+		Limit:     20,
+		Skip:      40,
+		WithCount: true,
+		Sort: []SortField{
+			{Field: "location"},
+			{Field: "expiry_date", Desc: true},
+		},
+		Fields: []string{"name", "sku"},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("got %+v, want %+v", opts, want)
+	}
+
+	for _, query := range []string{"limit=ten", "skip=-", "count=maybe"} {
+		values, _ := url.ParseQuery(query)
+		_, err := ParsePageOptions(values)
+		if err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
+
+func TestPageSort(t *testing.T) {
+	got := pageSort([]SortField{{Field: "timestamp", Desc: true}})
+	want := []SortField{{Field: "timestamp", Desc: true}, {Field: "_id"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Fields after "_id" cannot affect the order
+	got = pageSort([]SortField{{Field: "_id", Desc: true}, {Field: "timestamp"}})
+	want = []SortField{{Field: "_id", Desc: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	item := &Inventory{
+		ID:         objectid.New(),
+		Location:   "A101",
+		ExpiryDate: 1536600000,
+	}
+	sort := pageSort([]SortField{
+		{Field: "location"},
+		{Field: "expiry_date", Desc: true},
+		{Field: "name"},
+	})
+
+	token, err := encodePageToken(item, sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := decodePageToken(token, sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Name is not set, so it has no sort-value
+	want := []interface{}{"A101", int64(1536600000), nil, item.ID}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+
+	_, err = decodePageToken(token, pageSort([]SortField{{Field: "location"}}))
+	if err == nil {
+		t.Error("expected error for token used with a different sort-order")
+	}
+	_, err = decodePageToken("not a token!", sort)
+	if err == nil {
+		t.Error("expected error for malformed token")
+	}
+}
+
+func TestAfterFilter(t *testing.T) {
+	id := objectid.New()
+	sort := []SortField{
+		{Field: "location"},
+		{Field: "expiry_date", Desc: true},
+		{Field: "_id"},
+	}
+
+	got := afterFilter(sort, []interface{}{"A101", int64(10), id}).Compile()
+	want := doc{"$or": []interface{}{
+		doc{"location": doc{"$gt": "A101"}},
+		doc{"$and": []interface{}{
+			doc{"location": doc{"$eq": "A101"}},
+			doc{"expiry_date": doc{"$lt": int64(10)}},
+		}},
+		doc{"$and": []interface{}{
+			doc{"location": doc{"$eq": "A101"}},
+			doc{"expiry_date": doc{"$eq": int64(10)}},
+			doc{"_id": doc{"$gt": id}},
+		}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Missing values sort last in descending order, so only
+	// documents with the same missing value can come after.
+	got = afterFilter(sort, []interface{}{"A101", nil, id}).Compile()
+	want = doc{"$or": []interface{}{
+		doc{"location": doc{"$gt": "A101"}},
+		doc{"$and": []interface{}{
+			doc{"location": doc{"$eq": "A101"}},
+			doc{"expiry_date": doc{"$eq": nil}},
+			doc{"_id": doc{"$gt": id}},
+		}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestInvalidPageOptions(t *testing.T) {
+	token, err := encodePageToken(&Inventory{ID: objectid.New()}, pageSort(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []PageOptions{
+		{Limit: -1},
+		{Skip: -1},
+		{Sort: []SortField{{Field: ""}}},
+		{Sort: []SortField{{Field: "$where"}}},
+		{PageToken: "not a token!"},
+		// Token of a different sort-order
+		{PageToken: token, Sort: []SortField{{Field: "name"}}},
+	}
+	for _, opts := range testCases {
+		// Invalid options are rejected before searching the collection
+		_, err := (&DB{}).QueryPage(nil, opts)
+		if _, ok := errors.Cause(err).(*InvalidPageError); !ok {
+			t.Errorf("%+v: got error %v, want InvalidPageError", opts, err)
+		}
+	}
+
+	opts := PageOptions{Limit: maxPageLimit + 1, Sort: []SortField{{Field: "name", Desc: true}}}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("%+v: unexpected error %v", opts, err)
+	}
+}
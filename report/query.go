@@ -0,0 +1,197 @@
+package report
+
+import (
+	"github.com/TerrexTech/uuuid"
+)
+
+// Filter is a composable query-condition.
+// Filters can be combined using And, Or and Not, and are compiled
+// to a Mongo filter-document when the query is run.
+type Filter interface {
+	Compile() map[string]interface{}
+}
+
+// fieldFilter applies one or more operators to a single field.
+type fieldFilter struct {
+	field     string
+	operators map[string]interface{}
+}
+
+func (f *fieldFilter) Compile() map[string]interface{} {
+	return map[string]interface{}{
+		f.field: f.operators,
+	}
+}
+
+func newFieldFilter(field string, operator string, value interface{}) *fieldFilter {
+	return &fieldFilter{
+		field: field,
+		operators: map[string]interface{}{
+			operator: filterValue(value),
+		},
+	}
+}
+
+// groupFilter combines multiple Filters using a logical operator.
+type groupFilter struct {
+	operator string
+	filters  []Filter
+}
+
+// Compile compiles the group. Mongo does not accept empty logical-groups, so
+// nil and empty filters, which match everything, are resolved here: they are
+// dropped from an And, make an Or match everything and make a Not match nothing.
+// A group without filters left matches everything, unless it is an Or or Not.
+func (g *groupFilter) Compile() map[string]interface{} {
+	compiled := []interface{}{}
+	for _, f := range g.filters {
+		var c map[string]interface{}
+		if f != nil {
+			c = f.Compile()
+		}
+		if len(c) == 0 {
+			switch g.operator {
+			case "$or":
+				return map[string]interface{}{}
+			case "$nor":
+				return matchNothing()
+			}
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	if len(compiled) == 0 {
+		if g.operator == "$and" {
+			return map[string]interface{}{}
+		}
+		return matchNothing()
+	}
+	if len(compiled) == 1 && g.operator != "$nor" {
+		return compiled[0].(map[string]interface{})
+	}
+	return map[string]interface{}{
+		g.operator: compiled,
+	}
+}
+
+// matchNothing is a filter-document which matches no documents,
+// since every document has an _id.
+func matchNothing() map[string]interface{} {
+	return map[string]interface{}{
+		"_id": map[string]interface{}{
+			"$exists": false,
+		},
+	}
+}
+
+// filterValue converts the values to the format in which they are stored in DB.
+// UUIDs are stored as strings (see MarshalBSON), so they are compared as strings.
+func filterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case uuuid.UUID:
+		return v.String()
+	case *uuuid.UUID:
+		return v.String()
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, val := range v {
+			values[i] = filterValue(val)
+		}
+		return values
+	}
+	return value
+}
+
+// Eq matches documents where field is equal to value.
+func Eq(field string, value interface{}) Filter {
+	return newFieldFilter(field, "$eq", value)
+}
+
+// Ne matches documents where field is not equal to value.
+func Ne(field string, value interface{}) Filter {
+	return newFieldFilter(field, "$ne", value)
+}
+
+// Gt matches documents where field is greater than value.
+func Gt(field string, value interface{}) Filter {
+	return newFieldFilter(field, "$gt", value)
+}
+
+// Gte matches documents where field is greater than or equal to value.
+func Gte(field string, value interface{}) Filter {
+	return newFieldFilter(field, "$gte", value)
+}
+
+// Lt matches documents where field is less than value.
+func Lt(field string, value interface{}) Filter {
+	return newFieldFilter(field, "$lt", value)
+}
+
+// Lte matches documents where field is less than or equal to value.
+func Lte(field string, value interface{}) Filter {
+	return newFieldFilter(field, "$lte", value)
+}
+
+// In matches documents where field is equal to any of the values.
+func In(field string, values ...interface{}) Filter {
+	return newFieldFilter(field, "$in", values)
+}
+
+// Regex matches documents where field matches the regular-expression pattern.
+// options are the Mongo regex-options, such as "i" for case-insensitive matching.
+func Regex(field string, pattern string, options string) Filter {
+	f := newFieldFilter(field, "$regex", pattern)
+	if options != "" {
+		f.operators["$options"] = options
+	}
+	return f
+}
+
+// Exists matches documents which contain (or do not contain) the field.
+func Exists(field string, exists bool) Filter {
+	return newFieldFilter(field, "$exists", exists)
+}
+
+// TimeRange matches documents where field lies between start and end (inclusive).
+// A zero start or end leaves that side of the range unbounded.
+func TimeRange(field string, start int64, end int64) Filter {
+	f := &fieldFilter{
+		field:     field,
+		operators: map[string]interface{}{},
+	}
+	if start != 0 {
+		f.operators["$gte"] = start
+	}
+	if end != 0 {
+		f.operators["$lte"] = end
+	}
+	if len(f.operators) == 0 {
+		return And()
+	}
+	return f
+}
+
+// And matches documents which match all of the filters.
+func And(filters ...Filter) Filter {
+	return &groupFilter{
+		operator: "$and",
+		filters:  filters,
+	}
+}
+
+// Or matches documents which match any of the filters.
+func Or(filters ...Filter) Filter {
+	return &groupFilter{
+		operator: "$or",
+		filters:  filters,
+	}
+}
+
+// Not matches documents which do not match the filter.
+func Not(filter Filter) Filter {
+	return &groupFilter{
+		operator: "$nor",
+		filters:  []Filter{filter},
+	}
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/TerrexTech/uuuid"
+)
+
+type doc = map[string]interface{}
+
+func newUUID(t *testing.T) uuuid.UUID {
+	t.Helper()
+	id, err := uuuid.NewV4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestFilterCompile(t *testing.T) {
+	id := newUUID(t)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   doc
+	}{
+		{
+			name:   "field",
+			filter: Eq("location", "A101"),
+			want:   doc{"location": doc{"$eq": "A101"}},
+		},
+		{
+			name:   "UUIDs are compared as strings",
+			filter: In("item_id", id, "other"),
+			want:   doc{"item_id": doc{"$in": []interface{}{id.String(), "other"}}},
+		},
+		{
+			name:   "regex options",
+			filter: Regex("name", "^ban", "i"),
+			want:   doc{"name": doc{"$regex": "^ban", "$options": "i"}},
+		},
+		{
+			name:   "time-range",
+			filter: TimeRange("timestamp", 10, 20),
+			want:   doc{"timestamp": doc{"$gte": int64(10), "$lte": int64(20)}},
+		},
+		{
+			name:   "half-open time-range",
+			filter: TimeRange("timestamp", 0, 20),
+			want:   doc{"timestamp": doc{"$lte": int64(20)}},
+		},
+		{
+			name:   "unbounded time-range matches everything",
+			filter: TimeRange("timestamp", 0, 0),
+			want:   doc{},
+		},
+		{
+			name:   "single-filter And is flattened",
+			filter: And(Eq("sku", 1)),
+			want:   doc{"sku": doc{"$eq": 1}},
+		},
+		{
+			name:   "And",
+			filter: And(Eq("sku", 1), Gt("price", 2.5)),
+			want: doc{"$and": []interface{}{
+				doc{"sku": doc{"$eq": 1}},
+				doc{"price": doc{"$gt": 2.5}},
+			}},
+		},
+		{
+			name:   "Not",
+			filter: Not(Eq("sku", 1)),
+			want:   doc{"$nor": []interface{}{doc{"sku": doc{"$eq": 1}}}},
+		},
+		{
+			name:   "empty And matches everything",
+			filter: And(),
+			want:   doc{},
+		},
+		{
+			name:   "empty filters are dropped from And",
+			filter: And(nil, And(), Eq("sku", 1)),
+			want:   doc{"sku": doc{"$eq": 1}},
+		},
+		{
+			name:   "empty Or matches nothing",
+			filter: Or(),
+			want:   matchNothing(),
+		},
+		{
+			name:   "Or with an empty filter matches everything",
+			filter: Or(Eq("sku", 1), TimeRange("timestamp", 0, 0)),
+			want:   doc{},
+		},
+		{
+			name:   "Not of an empty filter matches nothing",
+			filter: Not(And()),
+			want:   matchNothing(),
+		},
+		{
+			name:   "Not of nil matches nothing",
+			filter: Not(nil),
+			want:   matchNothing(),
+		},
+		{
+			name:   "Not of a filter matching nothing",
+			filter: Not(Or()),
+			want:   doc{"$nor": []interface{}{matchNothing()}},
+		},
+	}
+
+	for _, test := range tests {
+		got := test.filter.Compile()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ReportType is a kind of report, such as "waste" or "spoilage".
+// ReportTypes are registered by name using RegisterReportType,
+// and are then available to HTTP-handlers and scheduled reports.
+type ReportType interface {
+	// Name uniquely identifies the ReportType.
+	Name() string
+	Description() string
+	// Params describes the report's parameters, which are the fields of its Query.
+	Params() []Param
+	// NewQuery returns a Query with no parameters set,
+	// into which JSON-encoded parameters are parsed.
+	NewQuery() Query
+	// Compute generates the report for query, which was returned by NewQuery.
+	Compute(db DBI, query Query) (interface{}, error)
+	// Render converts a result from Compute to Tables.
+	Render(result interface{}) ([]Table, error)
+}
+
+// Query is the parameters of a report.
+type Query interface {
+	// Validate checks if the parameters are valid.
+	Validate() error
+	// Window returns the report's date-range. Reports calculated
+	// at a point in time only have an EndDate.
+	Window() SearchByDate
+	// SetWindow sets the report's date-range.
+	SetWindow(window SearchByDate)
+}
+
+// Param describes a report-parameter.
+type Param struct {
+	// Name is the parameter's JSON-key.
+	Name string `json:"name"`
+	// Type is the parameter's JSON-type: "string", "integer",
+	// "number", "boolean", "array" or "object".
+	Type string `json:"type"`
+	// Items is the JSON-type of elements for array-parameters,
+	// or of values for object-parameters.
+	Items string `json:"items,omitempty"`
+}
+
+// ReportTypeInfo describes a ReportType to clients.
+type ReportTypeInfo struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Params      []Param `json:"params"`
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]ReportType{}
+)
+
+// RegisterReportType makes a ReportType available by its name.
+// It panics if a ReportType with same name is already registered.
+func RegisterReportType(t ReportType) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	name := t.Name()
+	if name == "" {
+		panic("report: ReportType name cannot be empty")
+	}
+	if _, ok := registry[name]; ok {
+		panic("report: RegisterReportType called twice for " + name)
+	}
+	registry[name] = t
+}
+
+// LookupReportType returns the ReportType registered with name.
+func LookupReportType(name string) (ReportType, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	t, ok := registry[name]
+	return t, ok
+}
+
+// ReportTypes returns the registered ReportTypes, sorted by name.
+func ReportTypes() []ReportType {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	types := make([]ReportType, 0, len(registry))
+	for _, t := range registry {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name() < types[j].Name()
+	})
+	return types
+}
+
+// ListReportTypes describes the registered ReportTypes, sorted by name.
+func ListReportTypes() []ReportTypeInfo {
+	infos := []ReportTypeInfo{}
+	for _, t := range ReportTypes() {
+		infos = append(infos, ReportTypeInfo{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Params:      t.Params(),
+		})
+	}
+	return infos
+}
+
+// ParseQuery parses JSON-encoded params into a new Query for ReportType.
+// Empty params are treated as an empty object.
+func ParseQuery(t ReportType, params json.RawMessage) (Query, error) {
+	query := t.NewQuery()
+	if len(params) == 0 {
+		return query, nil
+	}
+	err := json.Unmarshal(params, query)
+	if err != nil {
+		err = errors.Wrapf(err, "Error parsing params for %s-report", t.Name())
+		return nil, err
+	}
+	return query, nil
+}
+
+// ComputeReport computes the report of reportType for date-range window.
+// params are the report's JSON-encoded query. Reports calculated at a point in
+// time (such as "spoilage") are calculated as of window's EndDate.
+func (db *DB) ComputeReport(
+	reportType string,
+	params json.RawMessage,
+	window SearchByDate,
+) (interface{}, error) {
+	t, ok := LookupReportType(reportType)
+	if !ok {
+		return nil, fmt.Errorf("Unknown report-type: %s", reportType)
+	}
+	query, err := ParseQuery(t, params)
+	if err != nil {
+		return nil, err
+	}
+	query.SetWindow(window)
+	if err = query.Validate(); err != nil {
+		return nil, err
+	}
+	return t.Compute(db, query)
+}
+
+// Validate checks that date-range does not start after it ends.
+func (s *SearchByDate) Validate() error {
+	startDate, endDate := reportWindow(*s)
+	if startDate > endDate {
+		return errors.New("start_date cannot be after end_date")
+	}
+	return nil
+}
+
+// Window returns the date-range.
+func (s *SearchByDate) Window() SearchByDate {
+	return *s
+}
+
+// SetWindow sets the date-range.
+func (s *SearchByDate) SetWindow(window SearchByDate) {
+	*s = window
+}
+
+// queryParams describes the fields of query, which is a pointer to a struct,
+// as Params. Fields of embedded structs, such as SearchByDate, are included.
+func queryParams(query interface{}) []Param {
+	params := []Param{}
+	queryType := reflect.TypeOf(query).Elem()
+	for i := 0; i < queryType.NumField(); i++ {
+		field := queryType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = append(params, queryParams(reflect.New(field.Type).Interface())...)
+			continue
+		}
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		param := Param{
+			Name: name,
+			Type: jsonType(field.Type),
+		}
+		switch field.Type.Kind() {
+		case reflect.Slice, reflect.Map:
+			param.Items = jsonType(field.Type.Elem())
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// jsonName returns the JSON-key for struct-field,
+// or an empty string if field is not encoded.
+func jsonName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return field.Name
+	}
+	return tag
+}
+
+// jsonType returns the JSON-type for Go-type t.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		return "array"
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	}
+	return "object"
+}
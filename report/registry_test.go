@@ -0,0 +1,112 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// windowReport is a ReportType which returns the Query it is computed for.
+type windowReport struct{}
+
+func (windowReport) Name() string                                     { return "test-window" }
+func (windowReport) Description() string                              { return "" }
+func (windowReport) Params() []Param                                  { return queryParams(&WasteQuery{}) }
+func (windowReport) NewQuery() Query                                  { return &WasteQuery{} }
+func (windowReport) Compute(db DBI, query Query) (interface{}, error) { return query, nil }
+func (windowReport) Render(result interface{}) ([]Table, error)       { return nil, nil }
+
+func init() {
+	RegisterReportType(windowReport{})
+}
+
+func TestQueryParams(t *testing.T) {
+	type nested struct {
+		Location string `json:"location,omitempty"`
+	}
+	type query struct {
+		SearchByDate
+		nested
+		Limit      int                `json:"limit"`
+		Threshold  float64            `json:"threshold,omitempty"`
+		Store      *bool              `json:"store"`
+		Fields     []string           `json:"fields"`
+		Thresholds map[string]float64 `json:"thresholds"`
+		Bands      ProductBands       `json:"bands"`
+		Skipped    string             `json:"-"`
+		Untagged   string
+		unexported string
+	}
+
+	got := queryParams(&query{})
+	want := []Param{
+		{Name: "end_date", Type: "integer"},
+		{Name: "start_date", Type: "integer"},
+		{Name: "location", Type: "string"},
+		{Name: "limit", Type: "integer"},
+		{Name: "threshold", Type: "number"},
+		{Name: "store", Type: "boolean"},
+		{Name: "fields", Type: "array", Items: "string"},
+		{Name: "thresholds", Type: "object", Items: "number"},
+		{Name: "bands", Type: "object"},
+		{Name: "Untagged", Type: "string"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestJSONType(t *testing.T) {
+	var s *string
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{true, "boolean"},
+		{int8(1), "integer"},
+		{uint64(1), "integer"},
+		{float32(1), "number"},
+		{"", "string"},
+		{s, "string"},
+		{[]int{}, "array"},
+		{map[string]int{}, "object"},
+		{SearchByDate{}, "object"},
+	}
+	for _, test := range tests {
+		if got := jsonType(reflect.TypeOf(test.value)); got != test.want {
+			t.Errorf("%T: got %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestComputeReport(t *testing.T) {
+	db := &DB{}
+	window := SearchByDate{StartDate: 10, EndDate: 20}
+
+	got, err := db.ComputeReport("test-window", json.RawMessage(`{"trend_by":"location"}`), window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &WasteQuery{SearchByDate: window, TrendBy: "location"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name       string
+		reportType string
+		params     string
+		window     SearchByDate
+	}{
+		{"unknown type", "wastes", "", window},
+		{"unparsable params", "test-window", `{"interval":1}`, window},
+		{"invalid params", "test-window", `{"trend_by":"sku"}`, window},
+		{"invalid window", "test-window", "", SearchByDate{StartDate: 20, EndDate: 10}},
+	}
+	for _, test := range tests {
+		_, err := db.ComputeReport(test.reportType, json.RawMessage(test.params), test.window)
+		if err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
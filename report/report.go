@@ -21,6 +21,13 @@ type Report struct {
 	Version          int               `bson:"version,omitempty" json:"version,omitempty"`
 	AggregateID      int8              `bson:"aggregate_id,omitempty" json:"aggregate_id,omitempty"`
 	AggregateVersion int64             `bson:"aggregate_version,omitempty" json:"aggregate_version,omitempty"`
+	// Name, StartDate, EndDate, Params and Result are set for report-snapshots.
+	// Params and Result are stored as JSON-strings.
+	Name      string          `bson:"name,omitempty" json:"name,omitempty"`
+	StartDate int64           `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	EndDate   int64           `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	Params    json.RawMessage `bson:"params,omitempty" json:"params,omitempty"`
+	Result    json.RawMessage `bson:"result,omitempty" json:"result,omitempty"`
 }
 
 type marshalReport struct {
@@ -33,6 +40,13 @@ type marshalReport struct {
 	Version          int               `bson:"version,omitempty" json:"version,omitempty"`
 	AggregateID      int8              `bson:"aggregate_id,omitempty" json:"aggregate_id,omitempty"`
 	AggregateVersion int64             `bson:"aggregate_version,omitempty" json:"aggregate_version,omitempty"`
+	Name             string            `bson:"name,omitempty" json:"name,omitempty"`
+	StartDate        int64             `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	EndDate          int64             `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	Params           string            `bson:"params,omitempty" json:"-"`
+	Result           string            `bson:"result,omitempty" json:"-"`
+	RawParams        json.RawMessage   `bson:"-" json:"params,omitempty"`
+	RawResult        json.RawMessage   `bson:"-" json:"result,omitempty"`
 }
 
 func (r Report) MarshalBSON() ([]byte, error) {
@@ -43,6 +57,9 @@ func (r Report) MarshalBSON() ([]byte, error) {
 		Version:          r.Version,
 		AggregateID:      r.AggregateID,
 		AggregateVersion: r.AggregateVersion,
+		Name:             r.Name,
+		StartDate:        r.StartDate,
+		EndDate:          r.EndDate,
 	}
 
 	if r.ReportID.String() != (uuuid.UUID{}).String() {
@@ -57,6 +74,9 @@ func (r Report) MarshalBSON() ([]byte, error) {
 		mr.ItemID = r.ItemID.String()
 	}
 
+	mr.Params = string(r.Params)
+	mr.Result = string(r.Result)
+
 	return bson.Marshal(mr)
 }
 
@@ -68,6 +88,9 @@ func (r Report) MarshalJSON() ([]byte, error) {
 		Version:          r.Version,
 		AggregateID:      r.AggregateID,
 		AggregateVersion: r.AggregateVersion,
+		Name:             r.Name,
+		StartDate:        r.StartDate,
+		EndDate:          r.EndDate,
 	}
 
 	if r.ReportID.String() != (uuuid.UUID{}).String() {
@@ -82,14 +105,17 @@ func (r Report) MarshalJSON() ([]byte, error) {
 		mr.ItemID = r.ItemID.String()
 	}
 
-	return bson.Marshal(mr)
+	mr.RawParams = r.Params
+	mr.RawResult = r.Result
+
+	return json.Marshal(mr)
 }
 
 func (r *Report) UnmarshalBSON(in []byte) error {
 	var ok bool
 
 	m := make(map[string]interface{})
-	err := bson.Unmarshal(in, m)
+	err := bson.Unmarshal(in, &m)
 	if err != nil {
 		err = errors.Wrap(err, "Unmarshal Error")
 		return err
@@ -141,26 +167,33 @@ func (r *Report) UnmarshalBSON(in []byte) error {
 	}
 
 	if m["version"] != nil {
-		versionType := reflect.TypeOf(m["version"]).Kind()
-		r.Version, ok = m["version"].(int)
-		if !ok {
-			if versionType == reflect.Float64 {
-				r.Version = int(m["version"].(float64))
-			} else {
-				val, _ := strconv.Atoi((m["version"]).(string))
-				r.Version = int(val)
-			}
+		switch version := m["version"].(type) {
+		case int:
+			r.Version = version
+		case int32:
+			r.Version = int(version)
+		case int64:
+			r.Version = int(version)
+		case float64:
+			r.Version = int(version)
+		case string:
+			r.Version, _ = strconv.Atoi(version)
 		}
 	}
 
 	if m["aggregate_id"] != nil {
-		aggregateIdType := reflect.TypeOf(m["aggregate_id"]).Kind()
-		r.AggregateID, ok = m["aggregate_id"].(int8)
-		if !ok {
-			if aggregateIdType != reflect.Int8 {
-				val, _ := strconv.Atoi((m["aggregate_id"]).(string))
-				r.AggregateID = int8(val)
-			}
+		switch aggregateID := m["aggregate_id"].(type) {
+		case int8:
+			r.AggregateID = aggregateID
+		case int32:
+			r.AggregateID = int8(aggregateID)
+		case int64:
+			r.AggregateID = int8(aggregateID)
+		case float64:
+			r.AggregateID = int8(aggregateID)
+		case string:
+			val, _ := strconv.Atoi(aggregateID)
+			r.AggregateID = int8(val)
 		}
 	}
 
@@ -175,6 +208,26 @@ func (r *Report) UnmarshalBSON(in []byte) error {
 		}
 	}
 
+	if m["name"] != nil {
+		r.Name = m["name"].(string)
+	}
+
+	if m["start_date"] != nil {
+		r.StartDate, _ = m["start_date"].(int64)
+	}
+
+	if m["end_date"] != nil {
+		r.EndDate, _ = m["end_date"].(int64)
+	}
+
+	if m["params"] != nil {
+		r.Params = json.RawMessage(m["params"].(string))
+	}
+
+	if m["result"] != nil {
+		r.Result = json.RawMessage(m["result"].(string))
+	}
+
 	return nil
 }
 
@@ -182,14 +235,17 @@ func (r *Report) UnmarshalJSON(in []byte) error {
 	var ok bool
 
 	m := make(map[string]interface{})
-	err := bson.Unmarshal(in, m)
+	err := json.Unmarshal(in, &m)
 	if err != nil {
 		err = errors.Wrap(err, "Unmarshal Error")
 		return err
 	}
 
 	if m["_id"] != nil {
-		r.ID = m["_id"].(objectid.ObjectID)
+		r.ID, err = jsonObjectID(m["_id"])
+		if err != nil {
+			return err
+		}
 	}
 
 	if m["report_id"] != nil {
@@ -247,27 +303,49 @@ func (r *Report) UnmarshalJSON(in []byte) error {
 	}
 
 	if m["aggregate_id"] != nil {
-		aggregateIdType := reflect.TypeOf(m["aggregate_id"]).Kind()
-		r.AggregateID, ok = m["aggregate_id"].(int8)
-		if !ok {
-			if aggregateIdType != reflect.Int8 {
-				val, _ := strconv.Atoi((m["aggregate_id"]).(string))
-				r.AggregateID = int8(val)
-			}
+		switch aggregateID := m["aggregate_id"].(type) {
+		case float64:
+			r.AggregateID = int8(aggregateID)
+		case string:
+			val, _ := strconv.Atoi(aggregateID)
+			r.AggregateID = int8(val)
 		}
 	}
 
 	if m["aggregate_version"] != nil {
-		aggregateVersionType := reflect.TypeOf(m["aggregate_version"]).Kind()
-		r.AggregateVersion, ok = m["aggregate_version"].(int64)
-		if !ok {
-			if aggregateVersionType != reflect.Int64 {
-				val, _ := strconv.Atoi((m["aggregate_version"]).(string))
-				r.AggregateVersion = int64(val)
-			}
+		switch aggregateVersion := m["aggregate_version"].(type) {
+		case float64:
+			r.AggregateVersion = int64(aggregateVersion)
+		case string:
+			val, _ := strconv.Atoi(aggregateVersion)
+			r.AggregateVersion = int64(val)
 		}
 	}
 
+	if m["name"] != nil {
+		r.Name = m["name"].(string)
+	}
+
+	if m["start_date"] != nil {
+		startDate, _ := m["start_date"].(float64)
+		r.StartDate = int64(startDate)
+	}
+
+	if m["end_date"] != nil {
+		endDate, _ := m["end_date"].(float64)
+		r.EndDate = int64(endDate)
+	}
+
+	// Params and Result are kept as the JSON they were written as
+	raw := &marshalReport{}
+	err = json.Unmarshal(in, raw)
+	if err != nil {
+		err = errors.Wrap(err, "Unmarshal Error")
+		return err
+	}
+	r.Params = raw.RawParams
+	r.Result = raw.RawResult
+
 	return nil
 }
 
@@ -347,11 +425,18 @@ func (m *Metric) MarshalJSON() ([]byte, error) {
 	return json.Marshal(mm)
 }
 
+// MeasuredHumidity returns the Metric's Humidity, and whether the reading
+// includes humidity. Unset values are omitted when Metrics are stored,
+// so Metrics without humidity have a Humidity of 0.
+func (m *Metric) MeasuredHumidity() (float64, bool) {
+	return m.Humidity, m.Humidity != 0
+}
+
 func (r *Metric) UnmarshalBSON(in []byte) error {
 	var ok bool
 
 	m := make(map[string]interface{})
-	err := bson.Unmarshal(in, m)
+	err := bson.Unmarshal(in, &m)
 	if err != nil {
 		err = errors.Wrap(err, "Unmarshal Error")
 		return err
@@ -435,26 +520,33 @@ func (r *Metric) UnmarshalBSON(in []byte) error {
 	}
 
 	if m["version"] != nil {
-		versionType := reflect.TypeOf(m["version"]).Kind()
-		r.Version, ok = m["version"].(int)
-		if !ok {
-			if versionType == reflect.Float64 {
-				r.Version = int(m["version"].(float64))
-			} else {
-				val, _ := strconv.Atoi((m["version"]).(string))
-				r.Version = int(val)
-			}
+		switch version := m["version"].(type) {
+		case int:
+			r.Version = version
+		case int32:
+			r.Version = int(version)
+		case int64:
+			r.Version = int(version)
+		case float64:
+			r.Version = int(version)
+		case string:
+			r.Version, _ = strconv.Atoi(version)
 		}
 	}
 
 	if m["aggregate_id"] != nil {
-		aggregateIdType := reflect.TypeOf(m["aggregate_id"]).Kind()
-		r.AggregateID, ok = m["aggregate_id"].(int8)
-		if !ok {
-			if aggregateIdType != reflect.Int8 {
-				val, _ := strconv.Atoi((m["aggregate_id"]).(string))
-				r.AggregateID = int8(val)
-			}
+		switch aggregateID := m["aggregate_id"].(type) {
+		case int8:
+			r.AggregateID = aggregateID
+		case int32:
+			r.AggregateID = int8(aggregateID)
+		case int64:
+			r.AggregateID = int8(aggregateID)
+		case float64:
+			r.AggregateID = int8(aggregateID)
+		case string:
+			val, _ := strconv.Atoi(aggregateID)
+			r.AggregateID = int8(val)
 		}
 	}
 
@@ -476,14 +568,17 @@ func (r *Metric) UnmarshalJSON(in []byte) error {
 	var ok bool
 
 	m := make(map[string]interface{})
-	err := bson.Unmarshal(in, m)
+	err := json.Unmarshal(in, &m)
 	if err != nil {
 		err = errors.Wrap(err, "Unmarshal Error")
 		return err
 	}
 
 	if m["_id"] != nil {
-		r.ID = m["_id"].(objectid.ObjectID)
+		r.ID, err = jsonObjectID(m["_id"])
+		if err != nil {
+			return err
+		}
 	}
 
 	if m["item_id"] != nil {
@@ -573,24 +668,22 @@ func (r *Metric) UnmarshalJSON(in []byte) error {
 	}
 
 	if m["aggregate_id"] != nil {
-		aggregateIdType := reflect.TypeOf(m["aggregate_id"]).Kind()
-		r.AggregateID, ok = m["aggregate_id"].(int8)
-		if !ok {
-			if aggregateIdType != reflect.Int8 {
-				val, _ := strconv.Atoi((m["aggregate_id"]).(string))
-				r.AggregateID = int8(val)
-			}
+		switch aggregateID := m["aggregate_id"].(type) {
+		case float64:
+			r.AggregateID = int8(aggregateID)
+		case string:
+			val, _ := strconv.Atoi(aggregateID)
+			r.AggregateID = int8(val)
 		}
 	}
 
 	if m["aggregate_version"] != nil {
-		aggregateVersionType := reflect.TypeOf(m["aggregate_version"]).Kind()
-		r.AggregateVersion, ok = m["aggregate_version"].(int64)
-		if !ok {
-			if aggregateVersionType != reflect.Int64 {
-				val, _ := strconv.Atoi((m["aggregate_version"]).(string))
-				r.AggregateVersion = int64(val)
-			}
+		switch aggregateVersion := m["aggregate_version"].(type) {
+		case float64:
+			r.AggregateVersion = int64(aggregateVersion)
+		case string:
+			val, _ := strconv.Atoi(aggregateVersion)
+			r.AggregateVersion = int64(val)
 		}
 	}
 
@@ -649,6 +742,7 @@ type marshalInventory struct {
 
 func (i *Inventory) MarshalJSON() ([]byte, error) {
 	in := &marshalInventory{
+		ID:               i.ID,
 		UPC:              i.UPC,
 		SKU:              i.SKU,
 		Name:             i.Name,
@@ -666,6 +760,7 @@ func (i *Inventory) MarshalJSON() ([]byte, error) {
 		DateSold:         i.DateSold,
 		SalePrice:        i.SalePrice,
 		SoldWeight:       i.SoldWeight,
+		ProdQuantity:     i.ProdQuantity,
 	}
 
 	if i.ItemID.String() != (uuuid.UUID{}).String() {
@@ -700,6 +795,7 @@ func (i Inventory) MarshalBSON() ([]byte, error) {
 		DateSold:         i.DateSold,
 		SalePrice:        i.SalePrice,
 		SoldWeight:       i.SoldWeight,
+		ProdQuantity:     i.ProdQuantity,
 	}
 
 	if i.ItemID.String() != (uuuid.UUID{}).String() {
@@ -720,7 +816,7 @@ func (i *Inventory) UnmarshalBSON(in []byte) error {
 	var ok bool
 
 	m := make(map[string]interface{})
-	err := bson.Unmarshal(in, m)
+	err := bson.Unmarshal(in, &m)
 	if err != nil {
 		err = errors.Wrap(err, "Unmarshal Error")
 		return err
@@ -906,7 +1002,19 @@ func (i *Inventory) UnmarshalBSON(in []byte) error {
 	}
 
 	if m["aggregate_id"] != nil {
-		// i.AggregateID = m["aggregate_id"].(int8)
+		switch aggregateID := m["aggregate_id"].(type) {
+		case int8:
+			i.AggregateID = aggregateID
+		case int32:
+			i.AggregateID = int8(aggregateID)
+		case int64:
+			i.AggregateID = int8(aggregateID)
+		case float64:
+			i.AggregateID = int8(aggregateID)
+		case string:
+			val, _ := strconv.Atoi(aggregateID)
+			i.AggregateID = int8(val)
+		}
 	}
 
 	if m["sale_price"] != nil {
@@ -922,7 +1030,7 @@ func (i *Inventory) UnmarshalBSON(in []byte) error {
 
 	if m["sold_weight"] != nil {
 		soldWeightType := reflect.TypeOf(m["sold_weight"]).Kind()
-		i.SoldWeight = m["sold_weight"].(float64)
+		i.SoldWeight, ok = m["sold_weight"].(float64)
 		if !ok {
 			if soldWeightType != reflect.Float64 {
 				val, _ := strconv.Atoi((m["sold_weight"]).(string))
@@ -931,6 +1039,20 @@ func (i *Inventory) UnmarshalBSON(in []byte) error {
 		}
 	}
 
+	if m["prod_quantity"] != nil {
+		switch prodQuantity := m["prod_quantity"].(type) {
+		case int32:
+			i.ProdQuantity = int64(prodQuantity)
+		case int64:
+			i.ProdQuantity = prodQuantity
+		case float64:
+			i.ProdQuantity = int64(prodQuantity)
+		case string:
+			val, _ := strconv.Atoi(prodQuantity)
+			i.ProdQuantity = int64(val)
+		}
+	}
+
 	return nil
 }
 
@@ -944,7 +1066,10 @@ func (i *Inventory) UnmarshalJSON(in []byte) error {
 	}
 
 	if m["_id"] != nil {
-		i.ID = m["_id"].(objectid.ObjectID)
+		i.ID, err = jsonObjectID(m["_id"])
+		if err != nil {
+			return err
+		}
 	}
 
 	if m["item_id"] != nil {
@@ -1109,18 +1234,23 @@ func (i *Inventory) UnmarshalJSON(in []byte) error {
 	}
 
 	if m["aggregate_version"] != nil {
-		aggregateVersionType := reflect.TypeOf(m["aggregate_version"]).Kind()
-		i.AggregateVersion, ok = m["aggregate_version"].(int64)
-		if !ok {
-			if aggregateVersionType != reflect.Int64 {
-				val, _ := strconv.Atoi((m["aggregate_version"]).(string))
-				i.AggregateVersion = int64(val)
-			}
+		switch aggregateVersion := m["aggregate_version"].(type) {
+		case float64:
+			i.AggregateVersion = int64(aggregateVersion)
+		case string:
+			val, _ := strconv.Atoi(aggregateVersion)
+			i.AggregateVersion = int64(val)
 		}
 	}
 
 	if m["aggregate_id"] != nil {
-		// i.AggregateID = m["aggregate_id"].(int8)
+		switch aggregateID := m["aggregate_id"].(type) {
+		case float64:
+			i.AggregateID = int8(aggregateID)
+		case string:
+			val, _ := strconv.Atoi(aggregateID)
+			i.AggregateID = int8(val)
+		}
 	}
 
 	if m["sale_price"] != nil {
@@ -1136,7 +1266,7 @@ func (i *Inventory) UnmarshalJSON(in []byte) error {
 
 	if m["sold_weight"] != nil {
 		soldWeightType := reflect.TypeOf(m["sold_weight"]).Kind()
-		i.SoldWeight = m["sold_weight"].(float64)
+		i.SoldWeight, ok = m["sold_weight"].(float64)
 		if !ok {
 			if soldWeightType != reflect.Float64 {
 				val, _ := strconv.Atoi((m["sold_weight"]).(string))
@@ -1144,5 +1274,30 @@ func (i *Inventory) UnmarshalJSON(in []byte) error {
 			}
 		}
 	}
+
+	if m["prod_quantity"] != nil {
+		switch prodQuantity := m["prod_quantity"].(type) {
+		case float64:
+			i.ProdQuantity = int64(prodQuantity)
+		case string:
+			val, _ := strconv.Atoi(prodQuantity)
+			i.ProdQuantity = int64(val)
+		}
+	}
 	return nil
 }
+
+// jsonObjectID parses the "_id" of a JSON-document,
+// which is the hex-string of an ObjectID.
+func jsonObjectID(v interface{}) (objectid.ObjectID, error) {
+	hex, ok := v.(string)
+	if !ok {
+		return objectid.ObjectID{}, errors.New("Error parsing _id: expected a hex-string")
+	}
+	id, err := objectid.FromHex(hex)
+	if err != nil {
+		err = errors.Wrap(err, "Error parsing _id")
+		return objectid.ObjectID{}, err
+	}
+	return id, nil
+}
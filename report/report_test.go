@@ -0,0 +1,217 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+func TestReportBSONRoundTrip(t *testing.T) {
+	report := Report{
+		ID:               objectid.New(),
+		ItemID:           newUUID(t),
+		ReportID:         newUUID(t),
+		RsCustomerID:     newUUID(t),
+		Timestamp:        1536000000,
+		ReportType:       "waste",
+		Version:          2,
+		AggregateID:      2,
+		AggregateVersion: 5,
+		Name:             "Weekly waste",
+		StartDate:        1535400000,
+		EndDate:          1536000000,
+		Params:           json.RawMessage(`{"location":"A101"}`),
+		Result:           json.RawMessage(`{"total":12.5}`),
+	}
+
+	in, err := bson.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	err = bson.Unmarshal(in, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, report) {
+		t.Errorf("got %+v, want %+v", decoded, report)
+	}
+}
+
+func TestMetricBSONRoundTrip(t *testing.T) {
+	metric := Metric{
+		ID:               objectid.New(),
+		ItemID:           newUUID(t),
+		DeviceID:         newUUID(t),
+		Timestamp:        1536000000,
+		TempIn:           4.5,
+		Humidity:         91.5,
+		Ethylene:         1.25,
+		CarbonDi:         410.5,
+		Version:          3,
+		AggregateID:      4,
+		AggregateVersion: 7,
+	}
+
+	in, err := bson.Marshal(metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Metric
+	err = bson.Unmarshal(in, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, metric) {
+		t.Errorf("got %+v, want %+v", decoded, metric)
+	}
+}
+
+// Inventory is marshalled without its ID, which is assigned by Mongo.
+func TestInventoryBSONRoundTrip(t *testing.T) {
+	item := Inventory{
+		ItemID:           newUUID(t),
+		UPC:              123456789012,
+		SKU:              4321,
+		Name:             "Banana",
+		Origin:           "Ecuador",
+		DeviceID:         newUUID(t),
+		TotalWeight:      250.5,
+		Price:            1.5,
+		Location:         "A101",
+		DateArrived:      1536000000,
+		ExpiryDate:       1536600000,
+		Timestamp:        1536000100,
+		RsCustomerID:     newUUID(t),
+		WasteWeight:      10.5,
+		DonateWeight:     5.5,
+		AggregateVersion: 2,
+		AggregateID:      2,
+		DateSold:         1536300000,
+		SalePrice:        1.25,
+		SoldWeight:       100.5,
+		ProdQuantity:     40,
+	}
+
+	in, err := bson.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Inventory
+	err = bson.Unmarshal(in, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, item) {
+		t.Errorf("got %+v, want %+v", decoded, item)
+	}
+}
+
+func TestReportJSONRoundTrip(t *testing.T) {
+	report := Report{
+		ID:               objectid.New(),
+		ItemID:           newUUID(t),
+		ReportID:         newUUID(t),
+		RsCustomerID:     newUUID(t),
+		Timestamp:        1536000000,
+		ReportType:       "waste",
+		Version:          2,
+		AggregateID:      2,
+		AggregateVersion: 5,
+		Name:             "Weekly waste",
+		StartDate:        1535400000,
+		EndDate:          1536000000,
+		Params:           json.RawMessage(`{"location":"A101"}`),
+		Result:           json.RawMessage(`{"total":12.5}`),
+	}
+
+	in, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	err = json.Unmarshal(in, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, report) {
+		t.Errorf("got %+v, want %+v", decoded, report)
+	}
+}
+
+func TestMetricJSONRoundTrip(t *testing.T) {
+	metric := Metric{
+		ID:               objectid.New(),
+		ItemID:           newUUID(t),
+		DeviceID:         newUUID(t),
+		Timestamp:        1536000000,
+		TempIn:           4.5,
+		Humidity:         91.5,
+		Ethylene:         1.25,
+		CarbonDi:         410.5,
+		Version:          3,
+		AggregateID:      4,
+		AggregateVersion: 7,
+	}
+
+	in, err := json.Marshal(&metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Metric
+	err = json.Unmarshal(in, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, metric) {
+		t.Errorf("got %+v, want %+v", decoded, metric)
+	}
+}
+
+func TestInventoryJSONRoundTrip(t *testing.T) {
+	item := Inventory{
+		ID:               objectid.New(),
+		ItemID:           newUUID(t),
+		UPC:              123456789012,
+		SKU:              4321,
+		Name:             "Banana",
+		Origin:           "Ecuador",
+		DeviceID:         newUUID(t),
+		TotalWeight:      250.5,
+		Price:            1.5,
+		Location:         "A101",
+		DateArrived:      1536000000,
+		ExpiryDate:       1536600000,
+		Timestamp:        1536000100,
+		RsCustomerID:     newUUID(t),
+		WasteWeight:      10.5,
+		DonateWeight:     5.5,
+		AggregateVersion: 2,
+		AggregateID:      2,
+		DateSold:         1536300000,
+		SalePrice:        1.25,
+		SoldWeight:       100.5,
+		ProdQuantity:     40,
+	}
+
+	in, err := json.Marshal(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Inventory
+	err = json.Unmarshal(in, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, item) {
+		t.Errorf("got %+v, want %+v", decoded, item)
+	}
+
+	err = json.Unmarshal([]byte(`{"_id": "not-an-id"}`), &decoded)
+	if err == nil {
+		t.Error("expected error for invalid _id")
+	}
+}
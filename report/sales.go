@@ -0,0 +1,305 @@
+package report
+
+import (
+	"log"
+
+	"github.com/pkg/errors"
+)
+
+// SalesQuery specifies the parameters for SalesReport.
+// Sales are compared with a previous period using CompareReport,
+// like other report-types.
+type SalesQuery struct {
+	SearchByDate
+}
+
+// SalesFigures are the sales-figures for a group of Inventory.
+// Price is treated as the cost, and SalePrice as the selling-price,
+// per unit-weight. Revenue only includes items sold during the period,
+// while sell-through includes all items on hand during the period.
+type SalesFigures struct {
+	SKU         int64   `json:"sku,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	Location    string  `json:"location,omitempty"`
+	Items       int64   `json:"items"`
+	SoldItems   int64   `json:"sold_items"`
+	TotalWeight float64 `json:"total_weight"`
+	SoldWeight  float64 `json:"sold_weight"`
+	Revenue     float64 `json:"revenue"`
+	Cost        float64 `json:"cost"`
+	GrossMargin float64 `json:"gross_margin"`
+	// MarginRate is GrossMargin as a fraction of Revenue.
+	MarginRate float64 `json:"margin_rate"`
+	// SellThrough is the fraction of TotalWeight sold during the period.
+	SellThrough   float64 `json:"sell_through"`
+	AvgDaysToSell float64 `json:"avg_days_to_sell"`
+}
+
+// SalesPerformance is the result of SalesReport.
+type SalesPerformance struct {
+	StartDate  int64          `json:"start_date"`
+	EndDate    int64          `json:"end_date"`
+	Totals     SalesFigures   `json:"totals"`
+	BySKU      []SalesFigures `json:"by_sku"`
+	ByName     []SalesFigures `json:"by_name"`
+	ByLocation []SalesFigures `json:"by_location"`
+}
+
+// salesGroup is a single group in result of sales-aggregation.
+type salesGroup struct {
+	SKU           int64   `bson:"sku,omitempty"`
+	Name          string  `bson:"name,omitempty"`
+	Location      string  `bson:"location,omitempty"`
+	Items         int64   `bson:"items,omitempty"`
+	SoldItems     int64   `bson:"sold_items,omitempty"`
+	TotalWeight   float64 `bson:"total_weight,omitempty"`
+	SoldWeight    float64 `bson:"sold_weight,omitempty"`
+	Revenue       float64 `bson:"revenue,omitempty"`
+	Cost          float64 `bson:"cost,omitempty"`
+	AvgDaysToSell float64 `bson:"avg_days_to_sell,omitempty"`
+}
+
+// salesFacets is the result of sales-aggregation.
+type salesFacets struct {
+	Total      []salesGroup `bson:"total,omitempty"`
+	BySKU      []salesGroup `bson:"by_sku,omitempty"`
+	ByName     []salesGroup `bson:"by_name,omitempty"`
+	ByLocation []salesGroup `bson:"by_location,omitempty"`
+}
+
+// SalesReport calculates the revenue, margin, sell-through and days-to-sell
+// of Inventory per SKU, product Name and Location.
+func (db *DB) SalesReport(query SalesQuery) (*SalesPerformance, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	startDate, endDate := reportWindow(query.SearchByDate)
+
+	current, err := db.salesFacets(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	result := &SalesPerformance{
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Totals:     SalesFigures{},
+		BySKU:      salesFiguresList(current.BySKU),
+		ByName:     salesFiguresList(current.ByName),
+		ByLocation: salesFiguresList(current.ByLocation),
+	}
+	if len(current.Total) > 0 {
+		result.Totals = salesFigures(current.Total[0])
+	}
+	return result, nil
+}
+
+// salesFacets aggregates the sales-figures for Inventory on hand during date-range.
+func (db *DB) salesFacets(startDate int64, endDate int64) (*salesFacets, error) {
+	soldInPeriod := map[string]interface{}{
+		"$and": []interface{}{
+			map[string]interface{}{"$gte": []interface{}{"$date_sold", startDate}},
+			map[string]interface{}{"$lte": []interface{}{"$date_sold", endDate}},
+		},
+	}
+
+	// Items on hand during period arrived before its end,
+	// and were unsold or sold after its start.
+	filter := And(
+		Lte("date_arrived", endDate),
+		Or(
+			Exists("date_sold", false),
+			Eq("date_sold", 0),
+			Gte("date_sold", startDate),
+		),
+	)
+	pipeline := []interface{}{
+		map[string]interface{}{
+			"$match": filter.Compile(),
+		},
+		map[string]interface{}{
+			"$facet": map[string]interface{}{
+				"total": salesGroupStages(nil, soldInPeriod, nil),
+				"by_sku": salesGroupStages("$sku", soldInPeriod, map[string]interface{}{
+					"sku":  "$_id",
+					"name": 1,
+				}),
+				"by_name": salesGroupStages("$name", soldInPeriod, map[string]interface{}{
+					"name": "$_id",
+				}),
+				"by_location": salesGroupStages("$location", soldInPeriod, map[string]interface{}{
+					"location": "$_id",
+				}),
+			},
+		},
+	}
+
+	salesColl, err := resultCollection(db.inventory, &salesFacets{})
+	if err != nil {
+		return nil, err
+	}
+	aggResults, err := salesColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error aggregating sales-report")
+		log.Println(err)
+		return nil, err
+	}
+	if len(aggResults) == 0 {
+		return &salesFacets{}, nil
+	}
+	result, ok := aggResults[0].(*salesFacets)
+	if !ok {
+		err = errors.New("Error asserting aggregate-result to salesFacets")
+		log.Println(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// salesGroupStages creates the pipeline-stages for sales-figures grouped by id.
+// project specifies the additional fields to be projected, which are also sorted by.
+func salesGroupStages(
+	id interface{},
+	soldInPeriod interface{},
+	project map[string]interface{},
+) []interface{} {
+	ifSold := func(value interface{}, otherwise interface{}) interface{} {
+		return map[string]interface{}{
+			"$cond": []interface{}{soldInPeriod, value, otherwise},
+		}
+	}
+
+	group := map[string]interface{}{
+		"_id":          id,
+		"items":        map[string]interface{}{"$sum": 1},
+		"sold_items":   map[string]interface{}{"$sum": ifSold(1, 0)},
+		"total_weight": map[string]interface{}{"$sum": "$total_weight"},
+		"sold_weight":  map[string]interface{}{"$sum": ifSold("$sold_weight", 0)},
+		"revenue": map[string]interface{}{
+			"$sum": ifSold(map[string]interface{}{
+				"$multiply": []interface{}{"$sold_weight", "$sale_price"},
+			}, 0),
+		},
+		"cost": map[string]interface{}{
+			"$sum": ifSold(map[string]interface{}{
+				"$multiply": []interface{}{"$sold_weight", "$price"},
+			}, 0),
+		},
+		// $avg ignores the nulls for unsold items
+		"avg_days_to_sell": map[string]interface{}{
+			"$avg": ifSold(map[string]interface{}{
+				"$divide": []interface{}{
+					map[string]interface{}{
+						"$subtract": []interface{}{"$date_sold", "$date_arrived"},
+					},
+					86400,
+				},
+			}, nil),
+		},
+	}
+	fields := map[string]interface{}{
+		"_id":              0,
+		"items":            1,
+		"sold_items":       1,
+		"total_weight":     1,
+		"sold_weight":      1,
+		"revenue":          1,
+		"cost":             1,
+		"avg_days_to_sell": 1,
+	}
+
+	sort := []SortField{}
+	for k, v := range project {
+		fields[k] = v
+		if v == 1 {
+			// Non-key fields are taken from first item in group
+			group[k] = map[string]interface{}{"$first": "$" + k}
+		} else {
+			sort = append(sort, SortField{Field: k})
+		}
+	}
+
+	stages := []interface{}{
+		map[string]interface{}{"$group": group},
+		map[string]interface{}{"$project": fields},
+	}
+	if len(sort) > 0 {
+		stages = append(stages, map[string]interface{}{
+			"$sort": sortDocument(sort),
+		})
+	}
+	return stages
+}
+
+func salesFiguresList(groups []salesGroup) []SalesFigures {
+	figures := []SalesFigures{}
+	for _, g := range groups {
+		figures = append(figures, salesFigures(g))
+	}
+	return figures
+}
+
+func salesFigures(group salesGroup) SalesFigures {
+	figures := SalesFigures{
+		SKU:           group.SKU,
+		Name:          group.Name,
+		Location:      group.Location,
+		Items:         group.Items,
+		SoldItems:     group.SoldItems,
+		TotalWeight:   group.TotalWeight,
+		SoldWeight:    group.SoldWeight,
+		Revenue:       group.Revenue,
+		Cost:          group.Cost,
+		GrossMargin:   group.Revenue - group.Cost,
+		AvgDaysToSell: group.AvgDaysToSell,
+	}
+	if figures.Revenue != 0 {
+		figures.MarginRate = figures.GrossMargin / figures.Revenue
+	}
+	if figures.TotalWeight > 0 {
+		figures.SellThrough = figures.SoldWeight / figures.TotalWeight
+	}
+	return figures
+}
+
+// salesReport is the "sales" ReportType.
+type salesReport struct{}
+
+func init() {
+	RegisterReportType(salesReport{})
+}
+
+func (salesReport) Name() string {
+	return "sales"
+}
+
+func (salesReport) Description() string {
+	return "Revenue, gross margin, sell-through and days-to-sell by SKU, product and location."
+}
+
+func (salesReport) Params() []Param {
+	return queryParams(&SalesQuery{})
+}
+
+func (salesReport) NewQuery() Query {
+	return &SalesQuery{}
+}
+
+func (salesReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.SalesReport(*query.(*SalesQuery))
+}
+
+func (salesReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*SalesPerformance)
+	if !ok {
+		return nil, errors.New("Error asserting result to SalesPerformance")
+	}
+	return []Table{
+		structTable("totals", []SalesFigures{r.Totals}),
+		structTable("by_sku", r.BySKU, "sku"),
+		structTable("by_name", r.ByName, "name").withChart("revenue"),
+		structTable("by_location", r.ByLocation, "location").withChart("revenue"),
+	}, nil
+}
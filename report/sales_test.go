@@ -0,0 +1,58 @@
+package report
+
+import (
+	"testing"
+)
+
+func TestSalesFigures(t *testing.T) {
+	figures := salesFigures(salesGroup{
+		Name:        "Banana",
+		Items:       4,
+		SoldItems:   2,
+		TotalWeight: 200,
+		SoldWeight:  50,
+		Revenue:     100,
+		Cost:        60,
+	})
+	if figures.GrossMargin != 40 || figures.MarginRate != 0.4 || figures.SellThrough != 0.25 {
+		t.Errorf("got %+v, want margin 40, margin-rate 0.4 and sell-through 0.25", figures)
+	}
+
+	// Nothing sold
+	figures = salesFigures(salesGroup{Name: "Banana", Items: 1})
+	if figures.MarginRate != 0 || figures.SellThrough != 0 {
+		t.Errorf("got %+v, want zero rates", figures)
+	}
+}
+
+// Compared sales-reports include the previous figures in their Tables.
+func TestSalesComparison(t *testing.T) {
+	render := func(revenue float64) Table {
+		tables, err := salesReport{}.Render(&SalesPerformance{
+			ByName: []SalesFigures{{Name: "Banana", Revenue: revenue}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tables[2]
+	}
+
+	table := compareTables(render(150), render(100), nil)
+	columns := map[string]int{}
+	for i, c := range table.Columns {
+		columns[c.Name] = i
+	}
+	for _, name := range []string{"revenue", "revenue_previous", "revenue_change", "revenue_pct_change"} {
+		if _, ok := columns[name]; !ok {
+			t.Fatalf("missing column %s in %+v", name, table.Columns)
+		}
+	}
+
+	row := table.Rows[0]
+	if row[columns["name"]] != "Banana" ||
+		row[columns["revenue_previous"]] != 100.0 ||
+		row[columns["revenue_change"]] != 50.0 ||
+		row[columns["revenue_pct_change"]] != 50.0 {
+		t.Errorf("got %v", row)
+	}
+}
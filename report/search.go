@@ -0,0 +1,186 @@
+package report
+
+import (
+	"log"
+
+	"github.com/pkg/errors"
+)
+
+// MetricPage is a single page of Metrics.
+type MetricPage struct {
+	Metrics       []Metric `json:"metrics"`
+	NextPageToken string   `json:"next_page_token,omitempty"`
+	TotalCount    int64    `json:"total_count,omitempty"`
+}
+
+// QueryMetrics fetches the Metrics matching the specified Filter.
+// A nil Filter matches all Metrics.
+func (db *DB) QueryMetrics(filter Filter) (*[]Metric, error) {
+	if db.metric == nil {
+		return nil, errors.New("Metric collection is not configured")
+	}
+	if filter == nil {
+		filter = And()
+	}
+
+	findResults, err := db.metric.Find(filter.Compile())
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching metric.")
+		log.Println(err)
+		return nil, err
+	}
+
+	metrics := []Metric{}
+
+	for _, v := range findResults {
+		result, ok := v.(*Metric)
+		if !ok {
+			err = errors.New("Error asserting search-result to Metric")
+			log.Println(err)
+			return nil, err
+		}
+		metrics = append(metrics, *result)
+	}
+	return &metrics, nil
+}
+
+// QueryMetricPage fetches a single page of the Metrics matching the specified Filter.
+func (db *DB) QueryMetricPage(filter Filter, opts PageOptions) (*MetricPage, error) {
+	if db.metric == nil {
+		return nil, errors.New("Metric collection is not configured")
+	}
+
+	findResults, nextToken, count, err := findPage(db.metric, filter, opts)
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching metric-page.")
+		log.Println(err)
+		return nil, err
+	}
+
+	page := &MetricPage{
+		Metrics:       []Metric{},
+		NextPageToken: nextToken,
+		TotalCount:    count,
+	}
+	for _, v := range findResults {
+		result, ok := v.(*Metric)
+		if !ok {
+			err = errors.New("Error asserting search-result to Metric")
+			log.Println(err)
+			return nil, err
+		}
+		page.Metrics = append(page.Metrics, *result)
+	}
+	return page, nil
+}
+
+// SearchMetricsByTimestamp fetches the Metrics whose timestamp lies in any of the specified date-ranges.
+func (db *DB) SearchMetricsByTimestamp(search []SearchByDate) (*[]Metric, error) {
+	filter, err := TimestampFilter(search)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := db.QueryMetrics(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	//length
+	if len(*metrics) == 0 {
+		msg := "No results found - SearchMetricsByTimestamp"
+		return nil, errors.New(msg)
+	}
+	return metrics, nil
+}
+
+// SearchMetricsByFieldVal fetches the Metrics matching all of the specified field-values.
+func (db *DB) SearchMetricsByFieldVal(search []SearchByFieldVal) (*[]Metric, error) {
+	filter, err := FieldValFilter(search)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := db.QueryMetrics(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	//length
+	if len(*metrics) == 0 {
+		msg := "No results found - SearchMetricsByFieldVal"
+		return nil, errors.New(msg)
+	}
+	return metrics, nil
+}
+
+// QueryInventory fetches the Inventory matching the specified Filter.
+// A nil Filter matches all Inventory.
+func (db *DB) QueryInventory(filter Filter) (*[]Inventory, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+	if filter == nil {
+		filter = And()
+	}
+
+	findResults, err := db.inventory.Find(filter.Compile())
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching inventory.")
+		log.Println(err)
+		return nil, err
+	}
+
+	inventory := []Inventory{}
+
+	for _, v := range findResults {
+		result, ok := v.(*Inventory)
+		if !ok {
+			err = errors.New("Error asserting search-result to Inventory")
+			log.Println(err)
+			return nil, err
+		}
+		inventory = append(inventory, *result)
+	}
+	return &inventory, nil
+}
+
+// SearchInventoryByTimestamp fetches the Inventory whose timestamp lies in any of the specified date-ranges.
+func (db *DB) SearchInventoryByTimestamp(search []SearchByDate) (*[]Inventory, error) {
+	filter, err := TimestampFilter(search)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := db.QueryInventory(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	//length
+	if len(*inventory) == 0 {
+		msg := "No results found - SearchInventoryByTimestamp"
+		return nil, errors.New(msg)
+	}
+	return inventory, nil
+}
+
+// SearchInventoryByFieldVal fetches the Inventory matching all of the specified field-values.
+func (db *DB) SearchInventoryByFieldVal(search []SearchByFieldVal) (*[]Inventory, error) {
+	filter, err := FieldValFilter(search)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := db.QueryInventory(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	//length
+	if len(*inventory) == 0 {
+		msg := "No results found - SearchInventoryByFieldVal"
+		return nil, errors.New(msg)
+	}
+	return inventory, nil
+}
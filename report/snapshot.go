@@ -0,0 +1,150 @@
+package report
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	mgo "github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"github.com/pkg/errors"
+)
+
+// maxSnapshotAttempts is the number of times a snapshot is saved
+// when concurrent writers take the same version.
+const maxSnapshotAttempts = 5
+
+// duplicateKeyCode is the Mongo error-code for unique-index violations.
+const duplicateKeyCode = 11000
+
+// GenerateSnapshot computes the report of reportType for window, and saves
+// it as the next version of snapshot with ReportID reportID.
+func (db *DB) GenerateSnapshot(
+	reportID uuuid.UUID,
+	name string,
+	reportType string,
+	params json.RawMessage,
+	window SearchByDate,
+) (*Report, error) {
+	result, err := db.ComputeReport(reportType, params, window)
+	if err != nil {
+		return nil, err
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		err = errors.Wrap(err, "Error marshalling report-result")
+		return nil, err
+	}
+
+	snapshot := &Report{
+		ReportID:   reportID,
+		ReportType: reportType,
+		Timestamp:  time.Now().Unix(),
+		Name:       name,
+		StartDate:  window.StartDate,
+		EndDate:    window.EndDate,
+		Params:     params,
+		Result:     resultJSON,
+	}
+	err = db.SaveSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot saves the report as the next version for its ReportID.
+// The report's Version is set to the saved version. Versions are unique
+// per ReportID, so if another snapshot takes the same version first, the
+// report is saved again as the version after that.
+func (db *DB) SaveSnapshot(report *Report) error {
+	for attempt := 1; ; attempt++ {
+		latest, err := db.ReportSnapshot(report.ReportID, 0)
+		if err != nil {
+			return err
+		}
+		report.Version = 1
+		if latest != nil {
+			report.Version = latest.Version + 1
+		}
+
+		_, err = db.collection.InsertOne(report)
+		if err == nil {
+			return nil
+		}
+		if !isDuplicateKey(err) || attempt == maxSnapshotAttempts {
+			err = errors.Wrap(err, "Unable to insert report-snapshot")
+			log.Println(err)
+			return err
+		}
+	}
+}
+
+// isDuplicateKey checks if err is caused by a unique-index violation.
+func isDuplicateKey(err error) bool {
+	writeErrors, ok := errors.Cause(err).(mgo.WriteErrors)
+	if !ok {
+		return false
+	}
+	for _, e := range writeErrors {
+		if e.Code == duplicateKeyCode {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportSnapshots fetches all versions of the snapshot with reportID,
+// with the latest version first.
+func (db *DB) ReportSnapshots(reportID uuuid.UUID) (*[]Report, error) {
+	return db.findSnapshots(Eq("report_id", reportID), 0)
+}
+
+// ReportSnapshot fetches the specified version of the snapshot with reportID,
+// or its latest version if version is 0. nil is returned if no such snapshot exists.
+func (db *DB) ReportSnapshot(reportID uuuid.UUID, version int) (*Report, error) {
+	filter := Eq("report_id", reportID)
+	if version > 0 {
+		filter = And(filter, Eq("version", version))
+	}
+
+	snapshots, err := db.findSnapshots(filter, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(*snapshots) == 0 {
+		return nil, nil
+	}
+	return &(*snapshots)[0], nil
+}
+
+// findSnapshots fetches the snapshots matching filter, with the latest
+// version first. All matching snapshots are fetched if limit is 0.
+func (db *DB) findSnapshots(filter Filter, limit int64) (*[]Report, error) {
+	findOpts := []findopt.Find{
+		findopt.Sort(sortDocument([]SortField{{Field: "version", Desc: true}})),
+	}
+	if limit > 0 {
+		findOpts = append(findOpts, findopt.Limit(limit))
+	}
+
+	findResults, err := db.collection.Find(filter.Compile(), findOpts...)
+	if err != nil {
+		err = errors.Wrap(err, "Error while fetching report-snapshots.")
+		log.Println(err)
+		return nil, err
+	}
+
+	snapshots := []Report{}
+	for _, v := range findResults {
+		result, ok := v.(*Report)
+		if !ok {
+			err = errors.New("Error asserting search-result to Report")
+			log.Println(err)
+			return nil, err
+		}
+		snapshots = append(snapshots, *result)
+	}
+	return &snapshots, nil
+}
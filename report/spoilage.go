@@ -0,0 +1,367 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/pkg/errors"
+)
+
+// defaultQ10 is the factor by which spoilage-rate increases for every
+// 10°C outside a product's temperature-band.
+const defaultQ10 = 3
+
+// defaultEthyleneSensitivity is the ethylene-concentration (ppm)
+// at which spoilage-rate is doubled.
+const defaultEthyleneSensitivity = 10
+
+// humidityDeficitDoubling is the humidity-deficit (%RH below band)
+// at which spoilage-rate is doubled.
+const humidityDeficitDoubling = 20
+
+// Spoilage-risk levels.
+const (
+	RiskExpired  = "expired"
+	RiskCritical = "critical"
+	RiskHigh     = "high"
+	RiskMedium   = "medium"
+	RiskLow      = "low"
+)
+
+// Actions recommended for items based on their spoilage-risk.
+const (
+	ActionRemove   = "remove"
+	ActionDonate   = "donate"
+	ActionDiscount = "discount"
+	ActionMove     = "move"
+	ActionNone     = "none"
+)
+
+// SpoilageQuery specifies the parameters for SpoilageReport.
+type SpoilageQuery struct {
+	// AsOf is the Unix-timestamp to estimate shelf-life at. Defaults to current time.
+	AsOf int64 `json:"as_of,omitempty"`
+	// Location and Name optionally restrict the items included.
+	Location string `json:"location,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// Bands overrides the default storage-bands by product Name.
+	Bands map[string]ProductBands `json:"bands,omitempty"`
+	// Q10 is the factor by which spoilage-rate increases for every 10°C
+	// outside temperature-band. Defaults to defaultQ10.
+	Q10 float64 `json:"q10,omitempty"`
+	// EthyleneSensitivity is the ethylene-concentration (ppm) at which
+	// spoilage-rate is doubled. Defaults to defaultEthyleneSensitivity.
+	EthyleneSensitivity float64 `json:"ethylene_sensitivity,omitempty"`
+	// MaxReadingGapSeconds is the maximum duration a reading is held for
+	// if no newer reading is available. Defaults to one hour.
+	MaxReadingGapSeconds int64 `json:"max_reading_gap_seconds,omitempty"`
+	// Limit restricts the number of ranked items returned. All items are returned if 0.
+	Limit int `json:"limit,omitempty"`
+}
+
+// Validate checks the storage-bands. Other parameters have defaults.
+func (q *SpoilageQuery) Validate() error {
+	return validateBands(q.Bands)
+}
+
+// Window returns the point in time the report is calculated at as EndDate.
+func (q *SpoilageQuery) Window() SearchByDate {
+	return SearchByDate{
+		EndDate: q.AsOf,
+	}
+}
+
+// SetWindow calculates the report as of window's EndDate.
+func (q *SpoilageQuery) SetWindow(window SearchByDate) {
+	q.AsOf = window.EndDate
+}
+
+// ItemSpoilage is the estimated shelf-life and spoilage-risk of an Inventory item.
+type ItemSpoilage struct {
+	ItemID          uuuid.UUID `json:"item_id"`
+	SKU             int64      `json:"sku,omitempty"`
+	Name            string     `json:"name,omitempty"`
+	Location        string     `json:"location,omitempty"`
+	RemainingWeight float64    `json:"remaining_weight"`
+	DateArrived     int64      `json:"date_arrived"`
+	ExpiryDate      int64      `json:"expiry_date"`
+	// EstimatedExpiry is the expiry-date adjusted for storage-conditions.
+	EstimatedExpiry int64 `json:"estimated_expiry"`
+	// RemainingShelfLifeSeconds is negative if item has spoiled.
+	RemainingShelfLifeSeconds int64 `json:"remaining_shelf_life_seconds"`
+	// ConsumedShelfLife is the fraction of shelf-life used up, adjusted for storage-conditions.
+	ConsumedShelfLife float64 `json:"consumed_shelf_life"`
+	// SpoilageRate is the current rate at which shelf-life is used up,
+	// relative to storage in ideal conditions.
+	SpoilageRate float64 `json:"spoilage_rate"`
+	// RiskScore is in range [0, 100].
+	RiskScore float64 `json:"risk_score"`
+	RiskLevel string  `json:"risk_level"`
+	Action    string  `json:"action"`
+}
+
+// SpoilageRisk is the result of SpoilageReport.
+type SpoilageRisk struct {
+	AsOf  int64          `json:"as_of"`
+	Items []ItemSpoilage `json:"items"`
+}
+
+// SpoilageReport estimates the remaining shelf-life and spoilage-risk for
+// unsold Inventory items, using their nominal shelf-life (from DateArrived
+// to ExpiryDate) and the storage-conditions recorded in their Metrics.
+// Items are ranked by urgency, with the least remaining shelf-life first.
+func (db *DB) SpoilageReport(query SpoilageQuery) (*SpoilageRisk, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	asOf := query.AsOf
+	if asOf == 0 {
+		asOf = time.Now().Unix()
+	}
+	q10 := query.Q10
+	if q10 <= 1 {
+		q10 = defaultQ10
+	}
+	sensitivity := query.EthyleneSensitivity
+	if sensitivity <= 0 {
+		sensitivity = defaultEthyleneSensitivity
+	}
+	maxGap := defaultMaxReadingGap
+	if query.MaxReadingGapSeconds > 0 {
+		maxGap = time.Duration(query.MaxReadingGapSeconds) * time.Second
+	}
+
+	filters := []Filter{
+		Lte("date_arrived", asOf),
+		inStockFilter(),
+	}
+	if query.Location != "" {
+		filters = append(filters, Eq("location", query.Location))
+	}
+	if query.Name != "" {
+		filters = append(filters, Eq("name", query.Name))
+	}
+	items, err := db.inventoryItems(And(filters...))
+	if err != nil {
+		err = errors.Wrap(err, "Error loading inventory for spoilage-report")
+		return nil, err
+	}
+
+	// Metrics are only needed since the earliest arrival of the items estimated
+	var startDate int64
+	for itemID, item := range items.byItemID {
+		if remainingWeight(item) <= 0 || item.ExpiryDate <= item.DateArrived {
+			delete(items.byItemID, itemID)
+			continue
+		}
+		if startDate == 0 || item.DateArrived < startDate {
+			startDate = item.DateArrived
+		}
+	}
+	metrics, err := db.itemMetrics(items, startDate, asOf)
+	if err != nil {
+		err = errors.Wrap(err, "Error loading metrics for spoilage-report")
+		return nil, err
+	}
+
+	result := &SpoilageRisk{
+		AsOf:  asOf,
+		Items: []ItemSpoilage{},
+	}
+	for itemID, item := range items.byItemID {
+		bands, ok := query.Bands[item.Name]
+		if !ok {
+			bands, ok = defaultProductBands[item.Name]
+		}
+		rate := func(m *Metric) float64 {
+			r := 1 + m.Ethylene/sensitivity
+			if !ok {
+				return r
+			}
+			if m.TempIn > bands.TempIn.Max {
+				r *= math.Pow(q10, (m.TempIn-bands.TempIn.Max)/10)
+			} else if m.TempIn < bands.TempIn.Min {
+				r *= math.Pow(q10, (bands.TempIn.Min-m.TempIn)/10)
+			}
+			if m.Humidity > 0 && m.Humidity < bands.Humidity.Min {
+				r *= 1 + (bands.Humidity.Min-m.Humidity)/humidityDeficitDoubling
+			}
+			return r
+		}
+
+		var itemMetrics []Metric
+		for _, m := range metrics[itemID] {
+			if m.Timestamp >= item.DateArrived {
+				itemMetrics = append(itemMetrics, m)
+			}
+		}
+		result.Items = append(
+			result.Items,
+			estimateSpoilage(item, metricValues(itemMetrics, rate), asOf, maxGap),
+		)
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		a, b := result.Items[i], result.Items[j]
+		if a.RemainingShelfLifeSeconds != b.RemainingShelfLifeSeconds {
+			return a.RemainingShelfLifeSeconds < b.RemainingShelfLifeSeconds
+		}
+		return a.RiskScore > b.RiskScore
+	})
+	if query.Limit > 0 && len(result.Items) > query.Limit {
+		result.Items = result.Items[:query.Limit]
+	}
+	return result, nil
+}
+
+// estimateSpoilage estimates the shelf-life of item from its spoilage-rates.
+// Periods without a valid reading are assumed to be at nominal rate (1).
+// rates must be sorted by timestamp.
+func estimateSpoilage(
+	item *Inventory,
+	rates []timedReading,
+	asOf int64,
+	maxGap time.Duration,
+) ItemSpoilage {
+	shelfLife := float64(item.ExpiryDate - item.DateArrived)
+
+	// Effective age is the time elapsed at nominal rate,
+	// plus the additional time used up at elevated rates.
+	excess := make([]timedReading, len(rates))
+	for i, r := range rates {
+		excess[i] = timedReading{
+			Timestamp: r.Timestamp,
+			Value:     r.Value - 1,
+		}
+	}
+	elapsed := float64(asOf - item.DateArrived)
+	effectiveAge := elapsed + integrateReadings(excess, asOf, maxGap)*3600
+
+	currentRate := 1.0
+	if n := len(rates); n > 0 && asOf-rates[n-1].Timestamp <= int64(maxGap/time.Second) {
+		currentRate = rates[n-1].Value
+	}
+	if currentRate <= 0 {
+		currentRate = 1
+	}
+	remaining := int64((shelfLife - effectiveAge) / currentRate)
+
+	spoilage := ItemSpoilage{
+		ItemID:                    item.ItemID,
+		SKU:                       item.SKU,
+		Name:                      item.Name,
+		Location:                  item.Location,
+		RemainingWeight:           remainingWeight(item),
+		DateArrived:               item.DateArrived,
+		ExpiryDate:                item.ExpiryDate,
+		EstimatedExpiry:           asOf + remaining,
+		RemainingShelfLifeSeconds: remaining,
+		ConsumedShelfLife:         effectiveAge / shelfLife,
+		SpoilageRate:              currentRate,
+		RiskScore:                 100 * math.Min(1, math.Max(0, effectiveAge/shelfLife)),
+	}
+
+	day := int64(24 * time.Hour / time.Second)
+	switch {
+	case remaining <= 0:
+		spoilage.RiskLevel = RiskExpired
+		spoilage.Action = ActionRemove
+	case remaining < day || spoilage.ConsumedShelfLife >= 0.9:
+		spoilage.RiskLevel = RiskCritical
+		spoilage.Action = ActionDonate
+	case spoilage.ConsumedShelfLife >= 0.75:
+		spoilage.RiskLevel = RiskHigh
+		spoilage.Action = ActionDiscount
+	default:
+		spoilage.RiskLevel = RiskLow
+		if spoilage.ConsumedShelfLife >= 0.5 {
+			spoilage.RiskLevel = RiskMedium
+		}
+		spoilage.Action = ActionNone
+		if currentRate > 1 {
+			// Storage-conditions are shortening shelf-life
+			spoilage.Action = ActionMove
+		}
+	}
+	return spoilage
+}
+
+// remainingWeight is the weight of item which is still on hand.
+func remainingWeight(item *Inventory) float64 {
+	return item.TotalWeight - item.SoldWeight - item.WasteWeight
+}
+
+// remainingWeightExpression is the aggregation-expression for remainingWeight.
+func remainingWeightExpression() map[string]interface{} {
+	return map[string]interface{}{
+		"$subtract": []interface{}{
+			"$total_weight",
+			map[string]interface{}{
+				"$add": []interface{}{
+					map[string]interface{}{"$ifNull": []interface{}{"$sold_weight", 0}},
+					map[string]interface{}{"$ifNull": []interface{}{"$waste_weight", 0}},
+				},
+			},
+		},
+	}
+}
+
+// exprFilter matches documents using an aggregation-expression.
+type exprFilter struct {
+	expression map[string]interface{}
+}
+
+func (f *exprFilter) Compile() map[string]interface{} {
+	return map[string]interface{}{
+		"$expr": f.expression,
+	}
+}
+
+// inStockFilter matches the Inventory with weight remaining,
+// which has not been fully sold or wasted.
+func inStockFilter() Filter {
+	return &exprFilter{
+		expression: map[string]interface{}{
+			"$gt": []interface{}{remainingWeightExpression(), 0},
+		},
+	}
+}
+
+// spoilageReport is the "spoilage" ReportType.
+type spoilageReport struct{}
+
+func init() {
+	RegisterReportType(spoilageReport{})
+}
+
+func (spoilageReport) Name() string {
+	return "spoilage"
+}
+
+func (spoilageReport) Description() string {
+	return "Estimated remaining shelf-life and spoilage-risk of items from their storage-conditions, with suggested actions."
+}
+
+func (spoilageReport) Params() []Param {
+	return queryParams(&SpoilageQuery{})
+}
+
+func (spoilageReport) NewQuery() Query {
+	return &SpoilageQuery{}
+}
+
+func (spoilageReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.SpoilageReport(*query.(*SpoilageQuery))
+}
+
+func (spoilageReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*SpoilageRisk)
+	if !ok {
+		return nil, errors.New("Error asserting result to SpoilageRisk")
+	}
+	return []Table{
+		structTable("items", r.Items, "item_id"),
+	}, nil
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEstimateSpoilage(t *testing.T) {
+	day := int64(24 * time.Hour / time.Second)
+	week := 7 * 24 * time.Hour
+	item := &Inventory{
+		ItemID:      newUUID(t),
+		Name:        "Banana",
+		TotalWeight: 10,
+		SoldWeight:  4,
+		DateArrived: 0,
+		ExpiryDate:  10 * day,
+	}
+
+	tests := []struct {
+		name      string
+		rates     []timedReading
+		asOf      int64
+		maxGap    time.Duration
+		remaining int64
+		consumed  float64
+		level     string
+		action    string
+	}{
+		{"fresh", nil, day, week, 9 * day, 0.1, RiskLow, ActionNone},
+		{"half-life", nil, 6 * day, week, 4 * day, 0.6, RiskMedium, ActionNone},
+		{"near expiry", nil, 8 * day, week, 2 * day, 0.8, RiskHigh, ActionDiscount},
+		{"last day", nil, 9*day + day/2, week, day / 2, 0.95, RiskCritical, ActionDonate},
+		{"expired", nil, 11 * day, week, -day, 1.1, RiskExpired, ActionRemove},
+		{
+			// Stored at twice the nominal rate since arrival
+			name:      "elevated rate",
+			rates:     []timedReading{{0, 2}},
+			asOf:      2 * day,
+			maxGap:    week,
+			remaining: 3 * day,
+			consumed:  0.4,
+			level:     RiskLow,
+			action:    ActionMove,
+		},
+		{
+			// Less than a day left at the current rate
+			name:      "rate shortens shelf-life",
+			rates:     []timedReading{{5 * day, 10}},
+			asOf:      5 * day,
+			maxGap:    week,
+			remaining: day / 2,
+			consumed:  0.5,
+			level:     RiskCritical,
+			action:    ActionDonate,
+		},
+		{
+			// Readings older than maxGap do not affect the current rate
+			name:      "stale reading",
+			rates:     []timedReading{{0, 2}},
+			asOf:      3 * day,
+			maxGap:    time.Hour,
+			remaining: 7*day - 3600,
+			consumed:  float64(3*day+3600) / float64(10*day),
+			level:     RiskLow,
+			action:    ActionNone,
+		},
+	}
+	for _, test := range tests {
+		got := estimateSpoilage(item, test.rates, test.asOf, test.maxGap)
+		if got.RemainingShelfLifeSeconds != test.remaining {
+			t.Errorf("%s: got remaining %d, want %d", test.name, got.RemainingShelfLifeSeconds, test.remaining)
+		}
+		if got.EstimatedExpiry != test.asOf+test.remaining {
+			t.Errorf("%s: got estimated expiry %d, want %d", test.name, got.EstimatedExpiry, test.asOf+test.remaining)
+		}
+		if got.ConsumedShelfLife != test.consumed {
+			t.Errorf("%s: got consumed %v, want %v", test.name, got.ConsumedShelfLife, test.consumed)
+		}
+		if got.RiskLevel != test.level || got.Action != test.action {
+			t.Errorf(
+				"%s: got %s/%s, want %s/%s",
+				test.name, got.RiskLevel, got.Action, test.level, test.action,
+			)
+		}
+		if got.RemainingWeight != 6 {
+			t.Errorf("%s: got remaining weight %v, want 6", test.name, got.RemainingWeight)
+		}
+		if got.RiskScore < 0 || got.RiskScore > 100 {
+			t.Errorf("%s: risk score %v out of range", test.name, got.RiskScore)
+		}
+	}
+}
+
+func TestInStockFilter(t *testing.T) {
+	got := inStockFilter().Compile()
+	want := doc{"$expr": doc{"$gt": []interface{}{
+		doc{"$subtract": []interface{}{
+			"$total_weight",
+			doc{"$add": []interface{}{
+				doc{"$ifNull": []interface{}{"$sold_weight", 0}},
+				doc{"$ifNull": []interface{}{"$waste_weight", 0}},
+			}},
+		}},
+		0,
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
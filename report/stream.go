@@ -0,0 +1,180 @@
+package report
+
+import (
+	"context"
+
+	"github.com/TerrexTech/go-mongoutils/mongo"
+	"github.com/pkg/errors"
+)
+
+// defaultStreamBatchSize is the number of documents fetched from DB
+// at a time while streaming if no batch-size is specified.
+const defaultStreamBatchSize = 500
+
+// StreamReports streams the Reports matching the specified Filter,
+// excluding Anomaly-reports.
+// The documents are fetched from DB in batches of batchSize, so only a single
+// batch is held in memory at a time. The Report-channel is closed once all
+// documents have been streamed, or if an error occurs or ctx is cancelled.
+// The error-channel receives at most one error, and is closed after the
+// Report-channel.
+func (db *DB) StreamReports(
+	ctx context.Context,
+	filter Filter,
+	batchSize int64,
+) (<-chan Report, <-chan error) {
+	reports := make(chan Report)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(errChan)
+		defer close(reports)
+
+		err := streamDocuments(ctx, collectionPages(db.collection, withoutAnomalies(filter)), batchSize, func(doc interface{}) error {
+			result, ok := doc.(*Report)
+			if !ok {
+				return errors.New("Error asserting stream-result to Report")
+			}
+			select {
+			case reports <- *result:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errChan <- errors.Wrap(err, "Error streaming reports")
+		}
+	}()
+
+	return reports, errChan
+}
+
+// StreamMetrics streams the Metrics matching the specified Filter.
+// See StreamReports for details.
+func (db *DB) StreamMetrics(
+	ctx context.Context,
+	filter Filter,
+	batchSize int64,
+) (<-chan Metric, <-chan error) {
+	metrics := make(chan Metric)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(errChan)
+		defer close(metrics)
+
+		if db.metric == nil {
+			errChan <- errors.New("Metric collection is not configured")
+			return
+		}
+		err := streamDocuments(ctx, collectionPages(db.metric, filter), batchSize, func(doc interface{}) error {
+			result, ok := doc.(*Metric)
+			if !ok {
+				return errors.New("Error asserting stream-result to Metric")
+			}
+			select {
+			case metrics <- *result:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errChan <- errors.Wrap(err, "Error streaming metrics")
+		}
+	}()
+
+	return metrics, errChan
+}
+
+// StreamInventory streams the Inventory matching the specified Filter.
+// See StreamReports for details.
+func (db *DB) StreamInventory(
+	ctx context.Context,
+	filter Filter,
+	batchSize int64,
+) (<-chan Inventory, <-chan error) {
+	inventory := make(chan Inventory)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(errChan)
+		defer close(inventory)
+
+		if db.inventory == nil {
+			errChan <- errors.New("Inventory collection is not configured")
+			return
+		}
+		err := streamDocuments(ctx, collectionPages(db.inventory, filter), batchSize, func(doc interface{}) error {
+			result, ok := doc.(*Inventory)
+			if !ok {
+				return errors.New("Error asserting stream-result to Inventory")
+			}
+			select {
+			case inventory <- *result:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errChan <- errors.Wrap(err, "Error streaming inventory")
+		}
+	}()
+
+	return inventory, errChan
+}
+
+// pageSource fetches the batch of documents for opts,
+// along with the page-token for the next batch.
+type pageSource func(opts PageOptions) ([]interface{}, string, error)
+
+// collectionPages fetches the batches of documents in coll matching filter.
+func collectionPages(coll *mongo.Collection, filter Filter) pageSource {
+	return func(opts PageOptions) ([]interface{}, string, error) {
+		batch, nextToken, _, err := findPage(coll, filter, opts)
+		return batch, nextToken, err
+	}
+}
+
+// streamDocuments fetches the documents from source in batches, and
+// calls emit for each document. Batches are fetched in "_id" order using
+// page-tokens, so the position in collection is not lost between batches.
+func streamDocuments(
+	ctx context.Context,
+	source pageSource,
+	batchSize int64,
+	emit func(doc interface{}) error,
+) error {
+	if batchSize <= 0 {
+		batchSize = defaultStreamBatchSize
+	}
+
+	opts := PageOptions{
+		Limit: batchSize,
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		batch, nextToken, err := source(opts)
+		if err != nil {
+			return err
+		}
+		for _, doc := range batch {
+			err = emit(doc)
+			if err != nil {
+				return err
+			}
+		}
+
+		if nextToken == "" {
+			return nil
+		}
+		opts.PageToken = nextToken
+	}
+}
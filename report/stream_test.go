@@ -0,0 +1,126 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakePages serves pages of documents, keyed by the page-token of each page.
+type fakePages struct {
+	pages map[string][]interface{}
+	next  map[string]string
+	opts  []PageOptions
+	err   error
+}
+
+func (f *fakePages) source(opts PageOptions) ([]interface{}, string, error) {
+	f.opts = append(f.opts, opts)
+	if f.err != nil {
+		return nil, "", f.err
+	}
+	return f.pages[opts.PageToken], f.next[opts.PageToken], nil
+}
+
+func newFakePages() *fakePages {
+	return &fakePages{
+		pages: map[string][]interface{}{
+			"":   []interface{}{1, 2},
+			"p2": []interface{}{3},
+		},
+		next: map[string]string{
+			"": "p2",
+		},
+	}
+}
+
+func TestStreamDocuments(t *testing.T) {
+	pages := newFakePages()
+	emitted := []interface{}{}
+	err := streamDocuments(context.Background(), pages.source, 0, func(doc interface{}) error {
+		emitted = append(emitted, doc)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []interface{}{1, 2, 3}
+	if !reflect.DeepEqual(emitted, want) {
+		t.Errorf("emitted %v, want %v", emitted, want)
+	}
+	wantOpts := []PageOptions{
+		PageOptions{Limit: defaultStreamBatchSize},
+		PageOptions{Limit: defaultStreamBatchSize, PageToken: "p2"},
+	}
+	if !reflect.DeepEqual(pages.opts, wantOpts) {
+		t.Errorf("fetched %+v, want %+v", pages.opts, wantOpts)
+	}
+}
+
+func TestStreamDocumentsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pages := newFakePages()
+	emitted := []interface{}{}
+	err := streamDocuments(ctx, pages.source, 2, func(doc interface{}) error {
+		emitted = append(emitted, doc)
+		cancel()
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	// The batch being emitted is finished, but no further batches are fetched.
+	if len(pages.opts) != 1 {
+		t.Errorf("fetched %d batches, want 1", len(pages.opts))
+	}
+	if len(emitted) != 2 {
+		t.Errorf("emitted %d documents, want 2", len(emitted))
+	}
+}
+
+func TestStreamDocumentsErrors(t *testing.T) {
+	fetchErr := errors.New("fetch failed")
+	pages := newFakePages()
+	pages.err = fetchErr
+	err := streamDocuments(context.Background(), pages.source, 0, func(doc interface{}) error {
+		t.Errorf("emitted %v after a fetch-error", doc)
+		return nil
+	})
+	if err != fetchErr {
+		t.Errorf("got error %v, want %v", err, fetchErr)
+	}
+
+	emitErr := errors.New("emit failed")
+	pages = newFakePages()
+	emitted := 0
+	err = streamDocuments(context.Background(), pages.source, 0, func(doc interface{}) error {
+		emitted++
+		return emitErr
+	})
+	if err != emitErr {
+		t.Errorf("got error %v, want %v", err, emitErr)
+	}
+	if emitted != 1 || len(pages.opts) != 1 {
+		t.Errorf("emitted %d documents from %d batches, want 1 from 1", emitted, len(pages.opts))
+	}
+}
+
+func TestStreamNotConfigured(t *testing.T) {
+	db := &DB{}
+	metrics, errChan := db.StreamMetrics(context.Background(), nil, 0)
+
+	for m := range metrics {
+		t.Errorf("streamed %+v without a Metric collection", m)
+	}
+	err, ok := <-errChan
+	if !ok || err == nil {
+		t.Fatal("expected an error on errChan")
+	}
+	if _, ok = <-errChan; ok {
+		t.Error("expected errChan to be closed after the error")
+	}
+}
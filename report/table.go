@@ -0,0 +1,169 @@
+package report
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Column is a column of a Table.
+type Column struct {
+	Name string `json:"name"`
+	// Type is the JSON-type of column's values: "string", "integer", "number" or
+	// "boolean", or "time" for Unix-timestamps (in seconds), such as ExpiryDate.
+	Type string `json:"type"`
+	// Key is true if column identifies the row, such as a Name or SKU.
+	Key bool `json:"key,omitempty"`
+}
+
+// Table is a section of a report's result in tabular form,
+// such as the per-product totals of a waste-report.
+type Table struct {
+	Name    string          `json:"name"`
+	Columns []Column        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+	// Chart optionally names the numeric column to chart by the
+	// first key-column in rendered documents, such as "waste_weight".
+	Chart string `json:"chart,omitempty"`
+}
+
+// withChart returns the Table with its values of column charted.
+func (t Table) withChart(column string) Table {
+	t.Chart = column
+	return t
+}
+
+// hexer is implemented by IDs such as objectid.ObjectID.
+type hexer interface {
+	Hex() string
+}
+
+var (
+	hexerType    = reflect.TypeOf((*hexer)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// structTable creates a Table from rows, which is a slice of structs.
+// Each JSON-encoded field is a column. Fields of nested structs are prefixed
+// with the struct's name, such as "suggested_price", while slices, maps and
+// pointers are skipped. IDs are converted to strings.
+// keys are the names of columns which identify a row.
+func structTable(name string, rows interface{}, keys ...string) Table {
+	value := reflect.ValueOf(rows)
+	columns, fields := structColumns(value.Type().Elem(), "", nil)
+	for i := range columns {
+		for _, key := range keys {
+			if columns[i].Name == key {
+				columns[i].Key = true
+			}
+		}
+	}
+
+	table := Table{
+		Name:    name,
+		Columns: columns,
+		Rows:    make([][]interface{}, 0, value.Len()),
+	}
+	for i := 0; i < value.Len(); i++ {
+		row := make([]interface{}, len(fields))
+		for j, field := range fields {
+			row[j] = cellValue(value.Index(i).FieldByIndex(field))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// InventoryTable creates a Table of Inventory-items, such as search-results.
+func InventoryTable(items []Inventory) Table {
+	return structTable("inventory", items, "item_id")
+}
+
+// structColumns returns the columns of structType, and the index of field for each column.
+func structColumns(structType reflect.Type, prefix string, index []int) ([]Column, [][]int) {
+	columns := []Column{}
+	fields := [][]int{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+
+		// Embedded structs are flattened, as in JSON
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			c, f := structColumns(field.Type, prefix, fieldIndex)
+			columns = append(columns, c...)
+			fields = append(fields, f...)
+			continue
+		}
+
+		switch {
+		case field.Type.Implements(hexerType) || field.Type.Implements(stringerType):
+			columns = append(columns, Column{Name: prefix + name, Type: "string"})
+		case field.Type.Kind() == reflect.Struct:
+			c, f := structColumns(field.Type, prefix+name+"_", fieldIndex)
+			columns = append(columns, c...)
+			fields = append(fields, f...)
+			continue
+		case field.Type.Kind() == reflect.Slice,
+			field.Type.Kind() == reflect.Map,
+			field.Type.Kind() == reflect.Ptr:
+			continue
+		case jsonType(field.Type) == "integer" && isTimeField(name):
+			columns = append(columns, Column{Name: prefix + name, Type: "time"})
+		default:
+			columns = append(columns, Column{Name: prefix + name, Type: jsonType(field.Type)})
+		}
+		fields = append(fields, fieldIndex)
+	}
+	return columns, fields
+}
+
+// timeFields are the JSON-keys of Unix-timestamp fields
+// which do not end with "_date" or "_timestamp".
+var timeFields = map[string]bool{
+	"timestamp":        true,
+	"bucket":           true,
+	"as_of":            true,
+	"start_time":       true,
+	"end_time":         true,
+	"date_arrived":     true,
+	"date_sold":        true,
+	"estimated_expiry": true,
+}
+
+// isTimeField checks if the field with JSON-key name is a Unix-timestamp.
+func isTimeField(name string) bool {
+	return timeFields[name] ||
+		strings.HasSuffix(name, "_date") ||
+		strings.HasSuffix(name, "_timestamp")
+}
+
+// cellValue converts a struct-field to a Table-value.
+func cellValue(v reflect.Value) interface{} {
+	switch value := v.Interface().(type) {
+	case hexer:
+		// IDs which are not set, such as ObjectIDs of new items, are empty
+		if id, ok := value.(interface{ IsZero() bool }); ok && id.IsZero() {
+			return ""
+		}
+		return value.Hex()
+	case fmt.Stringer:
+		return value.String()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	}
+	return v.Interface()
+}
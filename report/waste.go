@@ -0,0 +1,312 @@
+package report
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WasteQuery specifies the parameters for WasteReport.
+type WasteQuery struct {
+	SearchByDate
+	// Interval is the time-period for trend. Defaults to IntervalDay.
+	Interval Interval `json:"interval,omitempty"`
+	// TrendBy optionally splits the trend by an Inventory field:
+	// "name", "origin", "location" or "rs_customer_id".
+	TrendBy string `json:"trend_by,omitempty"`
+}
+
+// WasteTotals are the weight-totals and ratios for a group of Inventory.
+type WasteTotals struct {
+	// Key is the value of the field the totals are grouped by.
+	Key string `json:"key,omitempty"`
+	// Bucket is the Unix-timestamp for start of trend's time-bucket.
+	Bucket      int64   `json:"bucket,omitempty"`
+	Items       int64   `json:"items"`
+	TotalWeight float64 `json:"total_weight"`
+	SoldWeight  float64 `json:"sold_weight"`
+	WasteWeight float64 `json:"waste_weight"`
+	// DonateWeight is the weight donated instead of being wasted,
+	// which is the "waste prevented through donation".
+	DonateWeight float64 `json:"donate_weight"`
+	SoldRatio    float64 `json:"sold_ratio"`
+	WasteRatio   float64 `json:"waste_ratio"`
+	DonateRatio  float64 `json:"donate_ratio"`
+	// DiversionRate is the share of unsold surplus (waste + donation)
+	// which was donated instead of being wasted.
+	DiversionRate float64 `json:"diversion_rate"`
+}
+
+// WasteDonation is the result of WasteReport.
+type WasteDonation struct {
+	StartDate  int64         `json:"start_date"`
+	EndDate    int64         `json:"end_date"`
+	Totals     WasteTotals   `json:"totals"`
+	ByName     []WasteTotals `json:"by_name"`
+	ByOrigin   []WasteTotals `json:"by_origin"`
+	ByLocation []WasteTotals `json:"by_location"`
+	ByCustomer []WasteTotals `json:"by_customer"`
+	Trend      []WasteTotals `json:"trend"`
+}
+
+// wasteGroup is a single group in result of waste-aggregation.
+type wasteGroup struct {
+	Key          string    `bson:"key,omitempty"`
+	Bucket       time.Time `bson:"bucket,omitempty"`
+	Items        int64     `bson:"items,omitempty"`
+	TotalWeight  float64   `bson:"total_weight,omitempty"`
+	SoldWeight   float64   `bson:"sold_weight,omitempty"`
+	WasteWeight  float64   `bson:"waste_weight,omitempty"`
+	DonateWeight float64   `bson:"donate_weight,omitempty"`
+}
+
+// wasteFacets is the result of waste-aggregation.
+type wasteFacets struct {
+	Total      []wasteGroup `bson:"total,omitempty"`
+	ByName     []wasteGroup `bson:"by_name,omitempty"`
+	ByOrigin   []wasteGroup `bson:"by_origin,omitempty"`
+	ByLocation []wasteGroup `bson:"by_location,omitempty"`
+	ByCustomer []wasteGroup `bson:"by_customer,omitempty"`
+	Trend      []wasteGroup `bson:"trend,omitempty"`
+}
+
+// Validate checks the date-range, interval and trend_by field.
+func (q *WasteQuery) Validate() error {
+	if err := q.SearchByDate.Validate(); err != nil {
+		return err
+	}
+	if q.Interval != "" {
+		if _, err := bucketExpression(q.Interval, "$timestamp"); err != nil {
+			return err
+		}
+	}
+	if q.TrendBy != "" {
+		for _, field := range wasteDimensions {
+			if q.TrendBy == field {
+				return nil
+			}
+		}
+		return errors.New("Invalid trend_by field: " + q.TrendBy)
+	}
+	return nil
+}
+
+// wasteDimensions are the Inventory fields that waste can be grouped by.
+var wasteDimensions = map[string]string{
+	"by_name":     "name",
+	"by_origin":   "origin",
+	"by_location": "location",
+	"by_customer": "rs_customer_id",
+}
+
+// WasteReport summarizes the waste, donation and sold weights of
+// Inventory in date-range, grouped by product, origin, location and customer.
+func (db *DB) WasteReport(query WasteQuery) (*WasteDonation, error) {
+	if db.inventory == nil {
+		return nil, errors.New("Inventory collection is not configured")
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	startDate, endDate := reportWindow(query.SearchByDate)
+
+	interval := query.Interval
+	if interval == "" {
+		interval = IntervalDay
+	}
+	bucket, err := bucketExpression(interval, "$timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	trendID := map[string]interface{}{
+		"bucket": bucket,
+	}
+	trendProject := map[string]interface{}{
+		"bucket": "$_id.bucket",
+	}
+	if query.TrendBy != "" {
+		trendID["key"] = "$" + query.TrendBy
+		trendProject["key"] = "$_id.key"
+	}
+
+	facets := map[string]interface{}{
+		"total": wasteGroupStages(nil, nil),
+		"trend": wasteGroupStages(trendID, trendProject),
+	}
+	for facet, field := range wasteDimensions {
+		facets[facet] = wasteGroupStages("$"+field, map[string]interface{}{
+			"key": "$_id",
+		})
+	}
+
+	pipeline := []interface{}{
+		map[string]interface{}{
+			"$match": TimeRange("timestamp", startDate, endDate).Compile(),
+		},
+		map[string]interface{}{
+			"$facet": facets,
+		},
+	}
+
+	wasteColl, err := resultCollection(db.inventory, &wasteFacets{})
+	if err != nil {
+		return nil, err
+	}
+	aggResults, err := wasteColl.Aggregate(pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "Error aggregating waste-report")
+		log.Println(err)
+		return nil, err
+	}
+
+	aggResult := &wasteFacets{}
+	if len(aggResults) > 0 {
+		var ok bool
+		aggResult, ok = aggResults[0].(*wasteFacets)
+		if !ok {
+			err = errors.New("Error asserting aggregate-result to wasteFacets")
+			log.Println(err)
+			return nil, err
+		}
+	}
+	return wasteDonation(startDate, endDate, aggResult), nil
+}
+
+// wasteDonation maps the result of waste-aggregation to WasteDonation.
+// Facets without groups are returned as empty lists.
+func wasteDonation(startDate int64, endDate int64, facets *wasteFacets) *WasteDonation {
+	result := &WasteDonation{
+		StartDate:  startDate,
+		EndDate:    endDate,
+		ByName:     wasteTotalsList(facets.ByName),
+		ByOrigin:   wasteTotalsList(facets.ByOrigin),
+		ByLocation: wasteTotalsList(facets.ByLocation),
+		ByCustomer: wasteTotalsList(facets.ByCustomer),
+		Trend:      wasteTotalsList(facets.Trend),
+	}
+	if len(facets.Total) > 0 {
+		result.Totals = wasteTotals(facets.Total[0])
+	}
+	return result
+}
+
+// wasteGroupStages creates the pipeline-stages for summing weights grouped by id.
+// project specifies the additional fields to be projected from group's _id.
+func wasteGroupStages(id interface{}, project map[string]interface{}) []interface{} {
+	fields := map[string]interface{}{
+		"_id":           0,
+		"items":         1,
+		"total_weight":  1,
+		"sold_weight":   1,
+		"waste_weight":  1,
+		"donate_weight": 1,
+	}
+	for k, v := range project {
+		fields[k] = v
+	}
+
+	sort := []SortField{}
+	if _, ok := project["bucket"]; ok {
+		sort = append(sort, SortField{Field: "bucket"})
+	}
+	if _, ok := project["key"]; ok {
+		sort = append(sort, SortField{Field: "key"})
+	}
+
+	stages := []interface{}{
+		map[string]interface{}{
+			"$group": map[string]interface{}{
+				"_id":           id,
+				"items":         map[string]interface{}{"$sum": 1},
+				"total_weight":  map[string]interface{}{"$sum": "$total_weight"},
+				"sold_weight":   map[string]interface{}{"$sum": "$sold_weight"},
+				"waste_weight":  map[string]interface{}{"$sum": "$waste_weight"},
+				"donate_weight": map[string]interface{}{"$sum": "$donate_weight"},
+			},
+		},
+		map[string]interface{}{
+			"$project": fields,
+		},
+	}
+	if len(sort) > 0 {
+		stages = append(stages, map[string]interface{}{
+			"$sort": sortDocument(sort),
+		})
+	}
+	return stages
+}
+
+func wasteTotalsList(groups []wasteGroup) []WasteTotals {
+	totals := []WasteTotals{}
+	for _, g := range groups {
+		totals = append(totals, wasteTotals(g))
+	}
+	return totals
+}
+
+func wasteTotals(group wasteGroup) WasteTotals {
+	totals := WasteTotals{
+		Key:          group.Key,
+		Items:        group.Items,
+		TotalWeight:  group.TotalWeight,
+		SoldWeight:   group.SoldWeight,
+		WasteWeight:  group.WasteWeight,
+		DonateWeight: group.DonateWeight,
+	}
+	if !group.Bucket.IsZero() {
+		totals.Bucket = group.Bucket.Unix()
+	}
+	if group.TotalWeight > 0 {
+		totals.SoldRatio = group.SoldWeight / group.TotalWeight
+		totals.WasteRatio = group.WasteWeight / group.TotalWeight
+		totals.DonateRatio = group.DonateWeight / group.TotalWeight
+	}
+	if surplus := group.WasteWeight + group.DonateWeight; surplus > 0 {
+		totals.DiversionRate = group.DonateWeight / surplus
+	}
+	return totals
+}
+
+// wasteReport is the "waste" ReportType.
+type wasteReport struct{}
+
+func init() {
+	RegisterReportType(wasteReport{})
+}
+
+func (wasteReport) Name() string {
+	return "waste"
+}
+
+func (wasteReport) Description() string {
+	return "Sold, wasted and donated weights by product, origin, location and customer, with a trend over time."
+}
+
+func (wasteReport) Params() []Param {
+	return queryParams(&WasteQuery{})
+}
+
+func (wasteReport) NewQuery() Query {
+	return &WasteQuery{}
+}
+
+func (wasteReport) Compute(db DBI, query Query) (interface{}, error) {
+	return db.WasteReport(*query.(*WasteQuery))
+}
+
+func (wasteReport) Render(result interface{}) ([]Table, error) {
+	r, ok := result.(*WasteDonation)
+	if !ok {
+		return nil, errors.New("Error asserting result to WasteDonation")
+	}
+	return []Table{
+		structTable("totals", []WasteTotals{r.Totals}),
+		structTable("by_name", r.ByName, "key").withChart("waste_weight"),
+		structTable("by_origin", r.ByOrigin, "key"),
+		structTable("by_location", r.ByLocation, "key").withChart("waste_weight"),
+		structTable("by_customer", r.ByCustomer, "key"),
+		structTable("trend", r.Trend, "bucket", "key").withChart("waste_weight"),
+	}, nil
+}
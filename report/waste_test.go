@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWasteDonation(t *testing.T) {
+	day := time.Unix(1536537600, 0).UTC()
+	facets := &wasteFacets{
+		Total: []wasteGroup{
+			{Items: 3, TotalWeight: 100, SoldWeight: 60, WasteWeight: 10, DonateWeight: 30},
+		},
+		ByName: []wasteGroup{
+			{Key: "Apple", Items: 1, TotalWeight: 40, WasteWeight: 10},
+			{Key: "Banana", Items: 2, TotalWeight: 60, SoldWeight: 60},
+		},
+		Trend: []wasteGroup{
+			{Bucket: day, Items: 3, TotalWeight: 100, SoldWeight: 60, WasteWeight: 10, DonateWeight: 30},
+		},
+	}
+	got := wasteDonation(10, 20, facets)
+
+	want := &WasteDonation{
+		StartDate: 10,
+		EndDate:   20,
+		Totals: WasteTotals{
+			Items:         3,
+			TotalWeight:   100,
+			SoldWeight:    60,
+			WasteWeight:   10,
+			DonateWeight:  30,
+			SoldRatio:     0.6,
+			WasteRatio:    0.1,
+			DonateRatio:   0.3,
+			DiversionRate: 0.75,
+		},
+		ByName: []WasteTotals{
+			{Key: "Apple", Items: 1, TotalWeight: 40, WasteWeight: 10, WasteRatio: 0.25},
+			{Key: "Banana", Items: 2, TotalWeight: 60, SoldWeight: 60, SoldRatio: 1},
+		},
+		ByOrigin:   []WasteTotals{},
+		ByLocation: []WasteTotals{},
+		ByCustomer: []WasteTotals{},
+		Trend: []WasteTotals{
+			{
+				Bucket:        day.Unix(),
+				Items:         3,
+				TotalWeight:   100,
+				SoldWeight:    60,
+				WasteWeight:   10,
+				DonateWeight:  30,
+				SoldRatio:     0.6,
+				WasteRatio:    0.1,
+				DonateRatio:   0.3,
+				DiversionRate: 0.75,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestWasteDonationEmpty(t *testing.T) {
+	got, err := json.Marshal(wasteDonation(10, 20, &wasteFacets{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"by_name", "by_origin", "by_location", "by_customer", "trend"} {
+		if list, ok := decoded[key].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("%s: got %v, want []", key, decoded[key])
+		}
+	}
+}
@@ -0,0 +1,1199 @@
+// Protobuf-definitions of the ReportQuery gRPC-service, which serves the
+// searches of the HTTP-endpoints to gRPC-clients.
+//
+// The Go-code in this directory is generated from this file, and is
+// regenerated from the repository-root using:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//     reportpb/report_query.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: reportpb/report_query.proto
+
+package reportpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Report mirrors report.Report. IDs are strings, and timestamps are
+// Unix-timestamps in seconds. Params and Result are JSON-strings.
+type Report struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ItemId           string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	ReportId         string                 `protobuf:"bytes,3,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
+	RsCustomerId     string                 `protobuf:"bytes,4,opt,name=rs_customer_id,json=rsCustomerId,proto3" json:"rs_customer_id,omitempty"`
+	Timestamp        int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ReportType       string                 `protobuf:"bytes,6,opt,name=report_type,json=reportType,proto3" json:"report_type,omitempty"`
+	Version          int64                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	AggregateId      int32                  `protobuf:"varint,8,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	AggregateVersion int64                  `protobuf:"varint,9,opt,name=aggregate_version,json=aggregateVersion,proto3" json:"aggregate_version,omitempty"`
+	Name             string                 `protobuf:"bytes,10,opt,name=name,proto3" json:"name,omitempty"`
+	StartDate        int64                  `protobuf:"varint,11,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate          int64                  `protobuf:"varint,12,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Params           string                 `protobuf:"bytes,13,opt,name=params,proto3" json:"params,omitempty"`
+	Result           string                 `protobuf:"bytes,14,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_reportpb_report_query_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Report) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Report) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *Report) GetReportId() string {
+	if x != nil {
+		return x.ReportId
+	}
+	return ""
+}
+
+func (x *Report) GetRsCustomerId() string {
+	if x != nil {
+		return x.RsCustomerId
+	}
+	return ""
+}
+
+func (x *Report) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Report) GetReportType() string {
+	if x != nil {
+		return x.ReportType
+	}
+	return ""
+}
+
+func (x *Report) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Report) GetAggregateId() int32 {
+	if x != nil {
+		return x.AggregateId
+	}
+	return 0
+}
+
+func (x *Report) GetAggregateVersion() int64 {
+	if x != nil {
+		return x.AggregateVersion
+	}
+	return 0
+}
+
+func (x *Report) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Report) GetStartDate() int64 {
+	if x != nil {
+		return x.StartDate
+	}
+	return 0
+}
+
+func (x *Report) GetEndDate() int64 {
+	if x != nil {
+		return x.EndDate
+	}
+	return 0
+}
+
+func (x *Report) GetParams() string {
+	if x != nil {
+		return x.Params
+	}
+	return ""
+}
+
+func (x *Report) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+// Metric mirrors report.Metric.
+type Metric struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ItemId           string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	DeviceId         string                 `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Timestamp        int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	TempIn           float64                `protobuf:"fixed64,5,opt,name=temp_in,json=tempIn,proto3" json:"temp_in,omitempty"`
+	Humidity         float64                `protobuf:"fixed64,6,opt,name=humidity,proto3" json:"humidity,omitempty"`
+	Ethylene         float64                `protobuf:"fixed64,7,opt,name=ethylene,proto3" json:"ethylene,omitempty"`
+	CarbonDi         float64                `protobuf:"fixed64,8,opt,name=carbon_di,json=carbonDi,proto3" json:"carbon_di,omitempty"`
+	Version          int64                  `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	AggregateId      int32                  `protobuf:"varint,10,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	AggregateVersion int64                  `protobuf:"varint,11,opt,name=aggregate_version,json=aggregateVersion,proto3" json:"aggregate_version,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Metric) Reset() {
+	*x = Metric{}
+	mi := &file_reportpb_report_query_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metric) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metric) ProtoMessage() {}
+
+func (x *Metric) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metric.ProtoReflect.Descriptor instead.
+func (*Metric) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Metric) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Metric) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *Metric) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *Metric) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Metric) GetTempIn() float64 {
+	if x != nil {
+		return x.TempIn
+	}
+	return 0
+}
+
+func (x *Metric) GetHumidity() float64 {
+	if x != nil {
+		return x.Humidity
+	}
+	return 0
+}
+
+func (x *Metric) GetEthylene() float64 {
+	if x != nil {
+		return x.Ethylene
+	}
+	return 0
+}
+
+func (x *Metric) GetCarbonDi() float64 {
+	if x != nil {
+		return x.CarbonDi
+	}
+	return 0
+}
+
+func (x *Metric) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Metric) GetAggregateId() int32 {
+	if x != nil {
+		return x.AggregateId
+	}
+	return 0
+}
+
+func (x *Metric) GetAggregateVersion() int64 {
+	if x != nil {
+		return x.AggregateVersion
+	}
+	return 0
+}
+
+// Inventory mirrors report.Inventory.
+type Inventory struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ItemId           string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	Upc              int64                  `protobuf:"varint,3,opt,name=upc,proto3" json:"upc,omitempty"`
+	Sku              int64                  `protobuf:"varint,4,opt,name=sku,proto3" json:"sku,omitempty"`
+	Name             string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Origin           string                 `protobuf:"bytes,6,opt,name=origin,proto3" json:"origin,omitempty"`
+	DeviceId         string                 `protobuf:"bytes,7,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	TotalWeight      float64                `protobuf:"fixed64,8,opt,name=total_weight,json=totalWeight,proto3" json:"total_weight,omitempty"`
+	Price            float64                `protobuf:"fixed64,9,opt,name=price,proto3" json:"price,omitempty"`
+	Location         string                 `protobuf:"bytes,10,opt,name=location,proto3" json:"location,omitempty"`
+	DateArrived      int64                  `protobuf:"varint,11,opt,name=date_arrived,json=dateArrived,proto3" json:"date_arrived,omitempty"`
+	ExpiryDate       int64                  `protobuf:"varint,12,opt,name=expiry_date,json=expiryDate,proto3" json:"expiry_date,omitempty"`
+	Timestamp        int64                  `protobuf:"varint,13,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	RsCustomerId     string                 `protobuf:"bytes,14,opt,name=rs_customer_id,json=rsCustomerId,proto3" json:"rs_customer_id,omitempty"`
+	WasteWeight      float64                `protobuf:"fixed64,15,opt,name=waste_weight,json=wasteWeight,proto3" json:"waste_weight,omitempty"`
+	DonateWeight     float64                `protobuf:"fixed64,16,opt,name=donate_weight,json=donateWeight,proto3" json:"donate_weight,omitempty"`
+	AggregateVersion int64                  `protobuf:"varint,17,opt,name=aggregate_version,json=aggregateVersion,proto3" json:"aggregate_version,omitempty"`
+	AggregateId      int32                  `protobuf:"varint,18,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	DateSold         int64                  `protobuf:"varint,19,opt,name=date_sold,json=dateSold,proto3" json:"date_sold,omitempty"`
+	SalePrice        float64                `protobuf:"fixed64,20,opt,name=sale_price,json=salePrice,proto3" json:"sale_price,omitempty"`
+	SoldWeight       float64                `protobuf:"fixed64,21,opt,name=sold_weight,json=soldWeight,proto3" json:"sold_weight,omitempty"`
+	ProdQuantity     int64                  `protobuf:"varint,22,opt,name=prod_quantity,json=prodQuantity,proto3" json:"prod_quantity,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Inventory) Reset() {
+	*x = Inventory{}
+	mi := &file_reportpb_report_query_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Inventory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Inventory) ProtoMessage() {}
+
+func (x *Inventory) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Inventory.ProtoReflect.Descriptor instead.
+func (*Inventory) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Inventory) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Inventory) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *Inventory) GetUpc() int64 {
+	if x != nil {
+		return x.Upc
+	}
+	return 0
+}
+
+func (x *Inventory) GetSku() int64 {
+	if x != nil {
+		return x.Sku
+	}
+	return 0
+}
+
+func (x *Inventory) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Inventory) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *Inventory) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *Inventory) GetTotalWeight() float64 {
+	if x != nil {
+		return x.TotalWeight
+	}
+	return 0
+}
+
+func (x *Inventory) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Inventory) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Inventory) GetDateArrived() int64 {
+	if x != nil {
+		return x.DateArrived
+	}
+	return 0
+}
+
+func (x *Inventory) GetExpiryDate() int64 {
+	if x != nil {
+		return x.ExpiryDate
+	}
+	return 0
+}
+
+func (x *Inventory) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Inventory) GetRsCustomerId() string {
+	if x != nil {
+		return x.RsCustomerId
+	}
+	return ""
+}
+
+func (x *Inventory) GetWasteWeight() float64 {
+	if x != nil {
+		return x.WasteWeight
+	}
+	return 0
+}
+
+func (x *Inventory) GetDonateWeight() float64 {
+	if x != nil {
+		return x.DonateWeight
+	}
+	return 0
+}
+
+func (x *Inventory) GetAggregateVersion() int64 {
+	if x != nil {
+		return x.AggregateVersion
+	}
+	return 0
+}
+
+func (x *Inventory) GetAggregateId() int32 {
+	if x != nil {
+		return x.AggregateId
+	}
+	return 0
+}
+
+func (x *Inventory) GetDateSold() int64 {
+	if x != nil {
+		return x.DateSold
+	}
+	return 0
+}
+
+func (x *Inventory) GetSalePrice() float64 {
+	if x != nil {
+		return x.SalePrice
+	}
+	return 0
+}
+
+func (x *Inventory) GetSoldWeight() float64 {
+	if x != nil {
+		return x.SoldWeight
+	}
+	return 0
+}
+
+func (x *Inventory) GetProdQuantity() int64 {
+	if x != nil {
+		return x.ProdQuantity
+	}
+	return 0
+}
+
+// SearchByDate mirrors report.SearchByDate.
+type SearchByDate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartDate     int64                  `protobuf:"varint,1,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       int64                  `protobuf:"varint,2,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchByDate) Reset() {
+	*x = SearchByDate{}
+	mi := &file_reportpb_report_query_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchByDate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchByDate) ProtoMessage() {}
+
+func (x *SearchByDate) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchByDate.ProtoReflect.Descriptor instead.
+func (*SearchByDate) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchByDate) GetStartDate() int64 {
+	if x != nil {
+		return x.StartDate
+	}
+	return 0
+}
+
+func (x *SearchByDate) GetEndDate() int64 {
+	if x != nil {
+		return x.EndDate
+	}
+	return 0
+}
+
+// SearchByFieldVal mirrors report.SearchByFieldVal.
+type SearchByFieldVal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SearchField   string                 `protobuf:"bytes,1,opt,name=search_field,json=searchField,proto3" json:"search_field,omitempty"`
+	SearchVal     *structpb.Value        `protobuf:"bytes,2,opt,name=search_val,json=searchVal,proto3" json:"search_val,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchByFieldVal) Reset() {
+	*x = SearchByFieldVal{}
+	mi := &file_reportpb_report_query_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchByFieldVal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchByFieldVal) ProtoMessage() {}
+
+func (x *SearchByFieldVal) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchByFieldVal.ProtoReflect.Descriptor instead.
+func (*SearchByFieldVal) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchByFieldVal) GetSearchField() string {
+	if x != nil {
+		return x.SearchField
+	}
+	return ""
+}
+
+func (x *SearchByFieldVal) GetSearchVal() *structpb.Value {
+	if x != nil {
+		return x.SearchVal
+	}
+	return nil
+}
+
+type SortField struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Desc          bool                   `protobuf:"varint,2,opt,name=desc,proto3" json:"desc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortField) Reset() {
+	*x = SortField{}
+	mi := &file_reportpb_report_query_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortField) ProtoMessage() {}
+
+func (x *SortField) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortField.ProtoReflect.Descriptor instead.
+func (*SortField) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SortField) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *SortField) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+// PageOptions mirrors report.PageOptions.
+type PageOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int64                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Skip          int64                  `protobuf:"varint,2,opt,name=skip,proto3" json:"skip,omitempty"`
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	Sort          []*SortField           `protobuf:"bytes,4,rep,name=sort,proto3" json:"sort,omitempty"`
+	Fields        []string               `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty"`
+	WithCount     bool                   `protobuf:"varint,6,opt,name=with_count,json=withCount,proto3" json:"with_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PageOptions) Reset() {
+	*x = PageOptions{}
+	mi := &file_reportpb_report_query_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PageOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PageOptions) ProtoMessage() {}
+
+func (x *PageOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PageOptions.ProtoReflect.Descriptor instead.
+func (*PageOptions) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PageOptions) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *PageOptions) GetSkip() int64 {
+	if x != nil {
+		return x.Skip
+	}
+	return 0
+}
+
+func (x *PageOptions) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *PageOptions) GetSort() []*SortField {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+func (x *PageOptions) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *PageOptions) GetWithCount() bool {
+	if x != nil {
+		return x.WithCount
+	}
+	return false
+}
+
+// SearchRequest matches documents whose timestamp lies in any of the
+// date-ranges, and which match all of the field-values. At least one
+// date-range or field-value is required. Page is ignored by Stream-RPCs.
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ByDate        []*SearchByDate        `protobuf:"bytes,1,rep,name=by_date,json=byDate,proto3" json:"by_date,omitempty"`
+	ByFieldVal    []*SearchByFieldVal    `protobuf:"bytes,2,rep,name=by_field_val,json=byFieldVal,proto3" json:"by_field_val,omitempty"`
+	Page          *PageOptions           `protobuf:"bytes,3,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_reportpb_report_query_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchRequest) GetByDate() []*SearchByDate {
+	if x != nil {
+		return x.ByDate
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetByFieldVal() []*SearchByFieldVal {
+	if x != nil {
+		return x.ByFieldVal
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetPage() *PageOptions {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ReportPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reports       []*Report              `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportPage) Reset() {
+	*x = ReportPage{}
+	mi := &file_reportpb_report_query_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportPage) ProtoMessage() {}
+
+func (x *ReportPage) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportPage.ProtoReflect.Descriptor instead.
+func (*ReportPage) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReportPage) GetReports() []*Report {
+	if x != nil {
+		return x.Reports
+	}
+	return nil
+}
+
+func (x *ReportPage) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ReportPage) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+type MetricPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metrics       []*Metric              `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricPage) Reset() {
+	*x = MetricPage{}
+	mi := &file_reportpb_report_query_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricPage) ProtoMessage() {}
+
+func (x *MetricPage) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricPage.ProtoReflect.Descriptor instead.
+func (*MetricPage) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MetricPage) GetMetrics() []*Metric {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *MetricPage) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *MetricPage) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+type InventoryPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inventory     []*Inventory           `protobuf:"bytes,1,rep,name=inventory,proto3" json:"inventory,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryPage) Reset() {
+	*x = InventoryPage{}
+	mi := &file_reportpb_report_query_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryPage) ProtoMessage() {}
+
+func (x *InventoryPage) ProtoReflect() protoreflect.Message {
+	mi := &file_reportpb_report_query_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryPage.ProtoReflect.Descriptor instead.
+func (*InventoryPage) Descriptor() ([]byte, []int) {
+	return file_reportpb_report_query_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *InventoryPage) GetInventory() []*Inventory {
+	if x != nil {
+		return x.Inventory
+	}
+	return nil
+}
+
+func (x *InventoryPage) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *InventoryPage) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+var File_reportpb_report_query_proto protoreflect.FileDescriptor
+
+const file_reportpb_report_query_proto_rawDesc = "" +
+	"\n" +
+	"\x1breportpb/report_query.proto\x12\vreportquery\x1a\x1cgoogle/protobuf/struct.proto\"\x9b\x03\n" +
+	"\x06Report\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\x12\x1b\n" +
+	"\treport_id\x18\x03 \x01(\tR\breportId\x12$\n" +
+	"\x0ers_customer_id\x18\x04 \x01(\tR\frsCustomerId\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12\x1f\n" +
+	"\vreport_type\x18\x06 \x01(\tR\n" +
+	"reportType\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\x12!\n" +
+	"\faggregate_id\x18\b \x01(\x05R\vaggregateId\x12+\n" +
+	"\x11aggregate_version\x18\t \x01(\x03R\x10aggregateVersion\x12\x12\n" +
+	"\x04name\x18\n" +
+	" \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\v \x01(\x03R\tstartDate\x12\x19\n" +
+	"\bend_date\x18\f \x01(\x03R\aendDate\x12\x16\n" +
+	"\x06params\x18\r \x01(\tR\x06params\x12\x16\n" +
+	"\x06result\x18\x0e \x01(\tR\x06result\"\xc4\x02\n" +
+	"\x06Metric\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\x12\x1b\n" +
+	"\tdevice_id\x18\x03 \x01(\tR\bdeviceId\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x17\n" +
+	"\atemp_in\x18\x05 \x01(\x01R\x06tempIn\x12\x1a\n" +
+	"\bhumidity\x18\x06 \x01(\x01R\bhumidity\x12\x1a\n" +
+	"\bethylene\x18\a \x01(\x01R\bethylene\x12\x1b\n" +
+	"\tcarbon_di\x18\b \x01(\x01R\bcarbonDi\x12\x18\n" +
+	"\aversion\x18\t \x01(\x03R\aversion\x12!\n" +
+	"\faggregate_id\x18\n" +
+	" \x01(\x05R\vaggregateId\x12+\n" +
+	"\x11aggregate_version\x18\v \x01(\x03R\x10aggregateVersion\"\x98\x05\n" +
+	"\tInventory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\x12\x10\n" +
+	"\x03upc\x18\x03 \x01(\x03R\x03upc\x12\x10\n" +
+	"\x03sku\x18\x04 \x01(\x03R\x03sku\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12\x16\n" +
+	"\x06origin\x18\x06 \x01(\tR\x06origin\x12\x1b\n" +
+	"\tdevice_id\x18\a \x01(\tR\bdeviceId\x12!\n" +
+	"\ftotal_weight\x18\b \x01(\x01R\vtotalWeight\x12\x14\n" +
+	"\x05price\x18\t \x01(\x01R\x05price\x12\x1a\n" +
+	"\blocation\x18\n" +
+	" \x01(\tR\blocation\x12!\n" +
+	"\fdate_arrived\x18\v \x01(\x03R\vdateArrived\x12\x1f\n" +
+	"\vexpiry_date\x18\f \x01(\x03R\n" +
+	"expiryDate\x12\x1c\n" +
+	"\ttimestamp\x18\r \x01(\x03R\ttimestamp\x12$\n" +
+	"\x0ers_customer_id\x18\x0e \x01(\tR\frsCustomerId\x12!\n" +
+	"\fwaste_weight\x18\x0f \x01(\x01R\vwasteWeight\x12#\n" +
+	"\rdonate_weight\x18\x10 \x01(\x01R\fdonateWeight\x12+\n" +
+	"\x11aggregate_version\x18\x11 \x01(\x03R\x10aggregateVersion\x12!\n" +
+	"\faggregate_id\x18\x12 \x01(\x05R\vaggregateId\x12\x1b\n" +
+	"\tdate_sold\x18\x13 \x01(\x03R\bdateSold\x12\x1d\n" +
+	"\n" +
+	"sale_price\x18\x14 \x01(\x01R\tsalePrice\x12\x1f\n" +
+	"\vsold_weight\x18\x15 \x01(\x01R\n" +
+	"soldWeight\x12#\n" +
+	"\rprod_quantity\x18\x16 \x01(\x03R\fprodQuantity\"H\n" +
+	"\fSearchByDate\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\x01 \x01(\x03R\tstartDate\x12\x19\n" +
+	"\bend_date\x18\x02 \x01(\x03R\aendDate\"l\n" +
+	"\x10SearchByFieldVal\x12!\n" +
+	"\fsearch_field\x18\x01 \x01(\tR\vsearchField\x125\n" +
+	"\n" +
+	"search_val\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\tsearchVal\"5\n" +
+	"\tSortField\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x12\n" +
+	"\x04desc\x18\x02 \x01(\bR\x04desc\"\xb9\x01\n" +
+	"\vPageOptions\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x03R\x05limit\x12\x12\n" +
+	"\x04skip\x18\x02 \x01(\x03R\x04skip\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\x12*\n" +
+	"\x04sort\x18\x04 \x03(\v2\x16.reportquery.SortFieldR\x04sort\x12\x16\n" +
+	"\x06fields\x18\x05 \x03(\tR\x06fields\x12\x1d\n" +
+	"\n" +
+	"with_count\x18\x06 \x01(\bR\twithCount\"\xb2\x01\n" +
+	"\rSearchRequest\x122\n" +
+	"\aby_date\x18\x01 \x03(\v2\x19.reportquery.SearchByDateR\x06byDate\x12?\n" +
+	"\fby_field_val\x18\x02 \x03(\v2\x1d.reportquery.SearchByFieldValR\n" +
+	"byFieldVal\x12,\n" +
+	"\x04page\x18\x03 \x01(\v2\x18.reportquery.PageOptionsR\x04page\"\x84\x01\n" +
+	"\n" +
+	"ReportPage\x12-\n" +
+	"\areports\x18\x01 \x03(\v2\x13.reportquery.ReportR\areports\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount\"\x84\x01\n" +
+	"\n" +
+	"MetricPage\x12-\n" +
+	"\ametrics\x18\x01 \x03(\v2\x13.reportquery.MetricR\ametrics\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount\"\x8e\x01\n" +
+	"\rInventoryPage\x124\n" +
+	"\tinventory\x18\x01 \x03(\v2\x16.reportquery.InventoryR\tinventory\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount2\xb5\x03\n" +
+	"\vReportQuery\x12D\n" +
+	"\rSearchReports\x12\x1a.reportquery.SearchRequest\x1a\x17.reportquery.ReportPage\x12B\n" +
+	"\rStreamReports\x12\x1a.reportquery.SearchRequest\x1a\x13.reportquery.Report0\x01\x12D\n" +
+	"\rSearchMetrics\x12\x1a.reportquery.SearchRequest\x1a\x17.reportquery.MetricPage\x12B\n" +
+	"\rStreamMetrics\x12\x1a.reportquery.SearchRequest\x1a\x13.reportquery.Metric0\x01\x12I\n" +
+	"\x0fSearchInventory\x12\x1a.reportquery.SearchRequest\x1a\x1a.reportquery.InventoryPage\x12G\n" +
+	"\x0fStreamInventory\x12\x1a.reportquery.SearchRequest\x1a\x16.reportquery.Inventory0\x01B3Z1github.com/bhupeshbhatia/go-report-query/reportpbb\x06proto3"
+
+var (
+	file_reportpb_report_query_proto_rawDescOnce sync.Once
+	file_reportpb_report_query_proto_rawDescData []byte
+)
+
+func file_reportpb_report_query_proto_rawDescGZIP() []byte {
+	file_reportpb_report_query_proto_rawDescOnce.Do(func() {
+		file_reportpb_report_query_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_reportpb_report_query_proto_rawDesc), len(file_reportpb_report_query_proto_rawDesc)))
+	})
+	return file_reportpb_report_query_proto_rawDescData
+}
+
+var file_reportpb_report_query_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_reportpb_report_query_proto_goTypes = []any{
+	(*Report)(nil),           // 0: reportquery.Report
+	(*Metric)(nil),           // 1: reportquery.Metric
+	(*Inventory)(nil),        // 2: reportquery.Inventory
+	(*SearchByDate)(nil),     // 3: reportquery.SearchByDate
+	(*SearchByFieldVal)(nil), // 4: reportquery.SearchByFieldVal
+	(*SortField)(nil),        // 5: reportquery.SortField
+	(*PageOptions)(nil),      // 6: reportquery.PageOptions
+	(*SearchRequest)(nil),    // 7: reportquery.SearchRequest
+	(*ReportPage)(nil),       // 8: reportquery.ReportPage
+	(*MetricPage)(nil),       // 9: reportquery.MetricPage
+	(*InventoryPage)(nil),    // 10: reportquery.InventoryPage
+	(*structpb.Value)(nil),   // 11: google.protobuf.Value
+}
+var file_reportpb_report_query_proto_depIdxs = []int32{
+	11, // 0: reportquery.SearchByFieldVal.search_val:type_name -> google.protobuf.Value
+	5,  // 1: reportquery.PageOptions.sort:type_name -> reportquery.SortField
+	3,  // 2: reportquery.SearchRequest.by_date:type_name -> reportquery.SearchByDate
+	4,  // 3: reportquery.SearchRequest.by_field_val:type_name -> reportquery.SearchByFieldVal
+	6,  // 4: reportquery.SearchRequest.page:type_name -> reportquery.PageOptions
+	0,  // 5: reportquery.ReportPage.reports:type_name -> reportquery.Report
+	1,  // 6: reportquery.MetricPage.metrics:type_name -> reportquery.Metric
+	2,  // 7: reportquery.InventoryPage.inventory:type_name -> reportquery.Inventory
+	7,  // 8: reportquery.ReportQuery.SearchReports:input_type -> reportquery.SearchRequest
+	7,  // 9: reportquery.ReportQuery.StreamReports:input_type -> reportquery.SearchRequest
+	7,  // 10: reportquery.ReportQuery.SearchMetrics:input_type -> reportquery.SearchRequest
+	7,  // 11: reportquery.ReportQuery.StreamMetrics:input_type -> reportquery.SearchRequest
+	7,  // 12: reportquery.ReportQuery.SearchInventory:input_type -> reportquery.SearchRequest
+	7,  // 13: reportquery.ReportQuery.StreamInventory:input_type -> reportquery.SearchRequest
+	8,  // 14: reportquery.ReportQuery.SearchReports:output_type -> reportquery.ReportPage
+	0,  // 15: reportquery.ReportQuery.StreamReports:output_type -> reportquery.Report
+	9,  // 16: reportquery.ReportQuery.SearchMetrics:output_type -> reportquery.MetricPage
+	1,  // 17: reportquery.ReportQuery.StreamMetrics:output_type -> reportquery.Metric
+	10, // 18: reportquery.ReportQuery.SearchInventory:output_type -> reportquery.InventoryPage
+	2,  // 19: reportquery.ReportQuery.StreamInventory:output_type -> reportquery.Inventory
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_reportpb_report_query_proto_init() }
+func file_reportpb_report_query_proto_init() {
+	if File_reportpb_report_query_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_reportpb_report_query_proto_rawDesc), len(file_reportpb_report_query_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_reportpb_report_query_proto_goTypes,
+		DependencyIndexes: file_reportpb_report_query_proto_depIdxs,
+		MessageInfos:      file_reportpb_report_query_proto_msgTypes,
+	}.Build()
+	File_reportpb_report_query_proto = out.File
+	file_reportpb_report_query_proto_goTypes = nil
+	file_reportpb_report_query_proto_depIdxs = nil
+}
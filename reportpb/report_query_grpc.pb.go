@@ -0,0 +1,339 @@
+// Protobuf-definitions of the ReportQuery gRPC-service, which serves the
+// searches of the HTTP-endpoints to gRPC-clients.
+//
+// The Go-code in this directory is generated from this file, and is
+// regenerated from the repository-root using:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//     reportpb/report_query.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: reportpb/report_query.proto
+
+package reportpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ReportQuery_SearchReports_FullMethodName   = "/reportquery.ReportQuery/SearchReports"
+	ReportQuery_StreamReports_FullMethodName   = "/reportquery.ReportQuery/StreamReports"
+	ReportQuery_SearchMetrics_FullMethodName   = "/reportquery.ReportQuery/SearchMetrics"
+	ReportQuery_StreamMetrics_FullMethodName   = "/reportquery.ReportQuery/StreamMetrics"
+	ReportQuery_SearchInventory_FullMethodName = "/reportquery.ReportQuery/SearchInventory"
+	ReportQuery_StreamInventory_FullMethodName = "/reportquery.ReportQuery/StreamInventory"
+)
+
+// ReportQueryClient is the client API for ReportQuery service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ReportQuery searches Reports, Metrics and Inventory.
+// Search-RPCs return a single page of results, while Stream-RPCs
+// stream all results as they are read from DB.
+type ReportQueryClient interface {
+	SearchReports(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ReportPage, error)
+	StreamReports(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Report], error)
+	SearchMetrics(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*MetricPage, error)
+	StreamMetrics(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Metric], error)
+	SearchInventory(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*InventoryPage, error)
+	StreamInventory(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Inventory], error)
+}
+
+type reportQueryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReportQueryClient(cc grpc.ClientConnInterface) ReportQueryClient {
+	return &reportQueryClient{cc}
+}
+
+func (c *reportQueryClient) SearchReports(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ReportPage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportPage)
+	err := c.cc.Invoke(ctx, ReportQuery_SearchReports_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reportQueryClient) StreamReports(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Report], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReportQuery_ServiceDesc.Streams[0], ReportQuery_StreamReports_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, Report]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReportQuery_StreamReportsClient = grpc.ServerStreamingClient[Report]
+
+func (c *reportQueryClient) SearchMetrics(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*MetricPage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetricPage)
+	err := c.cc.Invoke(ctx, ReportQuery_SearchMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reportQueryClient) StreamMetrics(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Metric], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReportQuery_ServiceDesc.Streams[1], ReportQuery_StreamMetrics_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, Metric]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReportQuery_StreamMetricsClient = grpc.ServerStreamingClient[Metric]
+
+func (c *reportQueryClient) SearchInventory(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*InventoryPage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InventoryPage)
+	err := c.cc.Invoke(ctx, ReportQuery_SearchInventory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reportQueryClient) StreamInventory(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Inventory], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReportQuery_ServiceDesc.Streams[2], ReportQuery_StreamInventory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, Inventory]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReportQuery_StreamInventoryClient = grpc.ServerStreamingClient[Inventory]
+
+// ReportQueryServer is the server API for ReportQuery service.
+// All implementations must embed UnimplementedReportQueryServer
+// for forward compatibility.
+//
+// ReportQuery searches Reports, Metrics and Inventory.
+// Search-RPCs return a single page of results, while Stream-RPCs
+// stream all results as they are read from DB.
+type ReportQueryServer interface {
+	SearchReports(context.Context, *SearchRequest) (*ReportPage, error)
+	StreamReports(*SearchRequest, grpc.ServerStreamingServer[Report]) error
+	SearchMetrics(context.Context, *SearchRequest) (*MetricPage, error)
+	StreamMetrics(*SearchRequest, grpc.ServerStreamingServer[Metric]) error
+	SearchInventory(context.Context, *SearchRequest) (*InventoryPage, error)
+	StreamInventory(*SearchRequest, grpc.ServerStreamingServer[Inventory]) error
+	mustEmbedUnimplementedReportQueryServer()
+}
+
+// UnimplementedReportQueryServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReportQueryServer struct{}
+
+func (UnimplementedReportQueryServer) SearchReports(context.Context, *SearchRequest) (*ReportPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchReports not implemented")
+}
+func (UnimplementedReportQueryServer) StreamReports(*SearchRequest, grpc.ServerStreamingServer[Report]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamReports not implemented")
+}
+func (UnimplementedReportQueryServer) SearchMetrics(context.Context, *SearchRequest) (*MetricPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMetrics not implemented")
+}
+func (UnimplementedReportQueryServer) StreamMetrics(*SearchRequest, grpc.ServerStreamingServer[Metric]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMetrics not implemented")
+}
+func (UnimplementedReportQueryServer) SearchInventory(context.Context, *SearchRequest) (*InventoryPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchInventory not implemented")
+}
+func (UnimplementedReportQueryServer) StreamInventory(*SearchRequest, grpc.ServerStreamingServer[Inventory]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamInventory not implemented")
+}
+func (UnimplementedReportQueryServer) mustEmbedUnimplementedReportQueryServer() {}
+func (UnimplementedReportQueryServer) testEmbeddedByValue()                     {}
+
+// UnsafeReportQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReportQueryServer will
+// result in compilation errors.
+type UnsafeReportQueryServer interface {
+	mustEmbedUnimplementedReportQueryServer()
+}
+
+func RegisterReportQueryServer(s grpc.ServiceRegistrar, srv ReportQueryServer) {
+	// If the following call pancis, it indicates UnimplementedReportQueryServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ReportQuery_ServiceDesc, srv)
+}
+
+func _ReportQuery_SearchReports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReportQueryServer).SearchReports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReportQuery_SearchReports_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReportQueryServer).SearchReports(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReportQuery_StreamReports_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReportQueryServer).StreamReports(m, &grpc.GenericServerStream[SearchRequest, Report]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReportQuery_StreamReportsServer = grpc.ServerStreamingServer[Report]
+
+func _ReportQuery_SearchMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReportQueryServer).SearchMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReportQuery_SearchMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReportQueryServer).SearchMetrics(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReportQuery_StreamMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReportQueryServer).StreamMetrics(m, &grpc.GenericServerStream[SearchRequest, Metric]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReportQuery_StreamMetricsServer = grpc.ServerStreamingServer[Metric]
+
+func _ReportQuery_SearchInventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReportQueryServer).SearchInventory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReportQuery_SearchInventory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReportQueryServer).SearchInventory(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReportQuery_StreamInventory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReportQueryServer).StreamInventory(m, &grpc.GenericServerStream[SearchRequest, Inventory]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReportQuery_StreamInventoryServer = grpc.ServerStreamingServer[Inventory]
+
+// ReportQuery_ServiceDesc is the grpc.ServiceDesc for ReportQuery service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReportQuery_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "reportquery.ReportQuery",
+	HandlerType: (*ReportQueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchReports",
+			Handler:    _ReportQuery_SearchReports_Handler,
+		},
+		{
+			MethodName: "SearchMetrics",
+			Handler:    _ReportQuery_SearchMetrics_Handler,
+		},
+		{
+			MethodName: "SearchInventory",
+			Handler:    _ReportQuery_SearchInventory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamReports",
+			Handler:       _ReportQuery_StreamReports_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamMetrics",
+			Handler:       _ReportQuery_StreamMetrics_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamInventory",
+			Handler:       _ReportQuery_StreamInventory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "reportpb/report_query.proto",
+}
@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		expr  string
+		field cronField
+		want  uint64
+	}{
+		{"*", hourField, 1<<24 - 1},
+		{"5", minuteField, 1 << 5},
+		{"1-3,10", minuteField, 1<<1 | 1<<2 | 1<<3 | 1<<10},
+		{"*/20", minuteField, 1<<0 | 1<<20 | 1<<40},
+		{"0-30/15", minuteField, 1<<0 | 1<<15 | 1<<30},
+		{"50/5", minuteField, 1<<50 | 1<<55},
+		{"jan,MAR-apr", monthField, 1<<1 | 1<<3 | 1<<4},
+		{"mon-fri", dowField, 1<<1 | 1<<2 | 1<<3 | 1<<4 | 1<<5},
+	}
+	for _, test := range tests {
+		got, err := parseCronField(test.expr, test.field)
+		if err != nil {
+			t.Errorf("%s: %v", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %b, want %b", test.expr, got, test.want)
+		}
+	}
+
+	for _, expr := range []string{"60", "5-1", "*/0", "a", "1-", "*/x"} {
+		if _, err := parseCronField(expr, minuteField); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "* * * * * *", "@every 10ms", "@every soon", "@fortnightly"} {
+		if _, err := ParseSchedule(spec, nil); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Monday
+	from := time.Date(2018, 9, 10, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		location *time.Location
+		want     time.Time
+	}{
+		{"* * * * *", nil, time.Date(2018, 9, 10, 10, 31, 0, 0, time.UTC)},
+		{"@hourly", nil, time.Date(2018, 9, 10, 11, 0, 0, 0, time.UTC)},
+		{"@daily", nil, time.Date(2018, 9, 11, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", nil, time.Date(2018, 9, 16, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", nil, time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"15 8 * * mon", nil, time.Date(2018, 9, 17, 8, 15, 0, 0, time.UTC)},
+		// Sunday as 7
+		{"0 0 * * 7", nil, time.Date(2018, 9, 16, 0, 0, 0, 0, time.UTC)},
+		// Day matches if either day-of-month or day-of-week matches
+		{"0 9 15 * fri", nil, time.Date(2018, 9, 14, 9, 0, 0, 0, time.UTC)},
+		{"0 9 11 * sat", nil, time.Date(2018, 9, 11, 9, 0, 0, 0, time.UTC)},
+		// 31st is skipped in months without it
+		{"0 0 31 * *", nil, time.Date(2018, 10, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", nil, time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 6:00 in Toronto is 10:00 UTC during daylight-saving
+		{"0 6 * * *", toronto, time.Date(2018, 9, 11, 10, 0, 0, 0, time.UTC)},
+		{"@every 15m", nil, time.Date(2018, 9, 10, 10, 45, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		s, err := ParseSchedule(test.spec, test.location)
+		if err != nil {
+			t.Errorf("%s: %v", test.spec, err)
+			continue
+		}
+		got := s.Next(from)
+		if !got.Equal(test.want) {
+			t.Errorf("%s: got %s, want %s", test.spec, got, test.want)
+		}
+	}
+
+	// No 30th of February
+	s, err := ParseSchedule("0 0 30 2 *", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(from); !got.IsZero() {
+		t.Errorf("got %s, want zero time", got)
+	}
+}
@@ -0,0 +1,237 @@
+package schedule
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+	"github.com/pkg/errors"
+)
+
+// defaultWindowSeconds is the length of date-range for reports
+// generated by a Definition.
+const defaultWindowSeconds = 24 * 60 * 60
+
+// Definition is a report which is generated on a Schedule.
+type Definition struct {
+	// Name uniquely identifies the Definition.
+	Name string `json:"name"`
+	// ReportID is the ReportID of snapshots generated by Definition.
+	// Defaults to an ID derived from Name, so it is stable across restarts.
+	ReportID   string `json:"report_id,omitempty"`
+	ReportType string `json:"report_type"`
+	// Schedule is a cron-expression or descriptor, see ParseSchedule.
+	Schedule string `json:"schedule"`
+	// Timezone is the IANA time-zone for Schedule, such as "America/Toronto".
+	// Defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// WindowSeconds is the length of the report's date-range, which ends at
+	// the scheduled run-time. Defaults to one day.
+	WindowSeconds int64 `json:"window_seconds,omitempty"`
+	// Params is the report's query, without the date-range.
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// job is a Definition with its parsed Schedule.
+type job struct {
+	def      Definition
+	reportID uuuid.UUID
+	schedule Schedule
+	next     time.Time
+}
+
+// Scheduler generates report-snapshots for Definitions on their Schedules.
+type Scheduler struct {
+	db   report.DBI
+	jobs []*job
+}
+
+// LoadDefinitions reads the Definitions from a JSON-file containing an array of Definitions.
+func LoadDefinitions(path string) ([]Definition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = errors.Wrap(err, "Error reading schedules-file")
+		return nil, err
+	}
+
+	defs := []Definition{}
+	err = json.Unmarshal(data, &defs)
+	if err != nil {
+		err = errors.Wrap(err, "Error parsing schedules-file")
+		return nil, err
+	}
+	return defs, nil
+}
+
+// NewScheduler creates a Scheduler for the specified Definitions.
+// An error is returned if any Definition is invalid, including
+// unknown report-types and invalid params.
+func NewScheduler(db report.DBI, defs []Definition) (*Scheduler, error) {
+	s := &Scheduler{
+		db: db,
+	}
+	now := time.Now().Unix()
+
+	names := map[string]bool{}
+	for _, def := range defs {
+		if def.Name == "" {
+			return nil, errors.New("Definition name is required")
+		}
+		if names[def.Name] {
+			return nil, errors.New("Duplicate Definition name: " + def.Name)
+		}
+		names[def.Name] = true
+		if def.ReportType == "" {
+			return nil, fmt.Errorf("Definition %s: report_type is required", def.Name)
+		}
+		if def.WindowSeconds < 0 {
+			return nil, fmt.Errorf("Definition %s: window_seconds cannot be negative", def.Name)
+		}
+		if def.WindowSeconds == 0 {
+			def.WindowSeconds = defaultWindowSeconds
+		}
+
+		reportType, ok := report.LookupReportType(def.ReportType)
+		if !ok {
+			return nil, fmt.Errorf("Definition %s: unknown report_type: %s", def.Name, def.ReportType)
+		}
+		query, err := report.ParseQuery(reportType, def.Params)
+		if err != nil {
+			err = errors.Wrapf(err, "Definition %s: invalid params", def.Name)
+			return nil, err
+		}
+		// The date-range is set on each run, so params are validated with the next one
+		query.SetWindow(report.SearchByDate{
+			StartDate: now - def.WindowSeconds,
+			EndDate:   now,
+		})
+		if err = query.Validate(); err != nil {
+			err = errors.Wrapf(err, "Definition %s: invalid params", def.Name)
+			return nil, err
+		}
+
+		location := time.UTC
+		if def.Timezone != "" {
+			location, err = time.LoadLocation(def.Timezone)
+			if err != nil {
+				err = errors.Wrapf(err, "Definition %s: invalid timezone", def.Name)
+				return nil, err
+			}
+		}
+		schedule, err := ParseSchedule(def.Schedule, location)
+		if err != nil {
+			err = errors.Wrapf(err, "Definition %s: invalid schedule", def.Name)
+			return nil, err
+		}
+
+		reportID, err := definitionReportID(def)
+		if err != nil {
+			err = errors.Wrapf(err, "Definition %s: invalid report_id", def.Name)
+			return nil, err
+		}
+		s.jobs = append(s.jobs, &job{
+			def:      def,
+			reportID: reportID,
+			schedule: schedule,
+		})
+	}
+	return s, nil
+}
+
+// Run generates the reports on their Schedules until ctx is done.
+// Reports are generated one at a time, so a report which runs past
+// the next scheduled time delays the reports due after it. Scheduled
+// times missed while a report runs are caught up afterwards.
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.jobs) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, j := range s.jobs {
+		j.next = j.schedule.Next(now)
+	}
+
+	for {
+		var next time.Time
+		for _, j := range s.jobs {
+			if !j.next.IsZero() && (next.IsZero() || j.next.Before(next)) {
+				next = j.next
+			}
+		}
+		if next.IsZero() {
+			log.Println("No further report-schedules to run")
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runDue()
+	}
+}
+
+// runDue generates the reports of jobs whose scheduled time has passed.
+// Each job is then scheduled after the time it just ran for, rather than
+// after the current time, so no scheduled times are skipped.
+func (s *Scheduler) runDue() {
+	for _, j := range s.jobs {
+		if j.next.IsZero() || j.next.After(time.Now()) {
+			continue
+		}
+		// Date-range ends at scheduled time, so snapshots are contiguous
+		runTime := j.next
+		_, err := s.generate(j, runTime)
+		if err != nil {
+			log.Println(err)
+		}
+		j.next = j.schedule.Next(runTime)
+	}
+}
+
+// generate generates the snapshot for job with date-range ending at runTime.
+func (s *Scheduler) generate(j *job, runTime time.Time) (*report.Report, error) {
+	window := report.SearchByDate{
+		StartDate: runTime.Unix() - j.def.WindowSeconds,
+		EndDate:   runTime.Unix(),
+	}
+	snapshot, err := s.db.GenerateSnapshot(
+		j.reportID,
+		j.def.Name,
+		j.def.ReportType,
+		j.def.Params,
+		window,
+	)
+	if err != nil {
+		err = errors.Wrapf(err, "Error generating scheduled report %s", j.def.Name)
+		return nil, err
+	}
+	log.Printf(
+		"Generated scheduled report %s, version %d", j.def.Name, snapshot.Version,
+	)
+	return snapshot, nil
+}
+
+// definitionReportID returns the Definition's ReportID, or derives a
+// name-based (version 5) UUID from its Name if it has no ReportID.
+func definitionReportID(def Definition) (uuuid.UUID, error) {
+	if def.ReportID != "" {
+		return uuuid.FromString(def.ReportID)
+	}
+
+	hash := sha1.Sum([]byte("report-schedule/" + def.Name))
+	id := hash[:16]
+	id[6] = (id[6] & 0x0f) | 0x50
+	id[8] = (id[8] & 0x3f) | 0x80
+	return uuuid.FromBytes(id)
+}
@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TerrexTech/uuuid"
+	"github.com/bhupeshbhatia/go-report-query/report"
+)
+
+// fakeDB records the windows of generated snapshots.
+type fakeDB struct {
+	report.DBI
+	windows []report.SearchByDate
+}
+
+func (db *fakeDB) GenerateSnapshot(
+	reportID uuuid.UUID,
+	name string,
+	reportType string,
+	params json.RawMessage,
+	window report.SearchByDate,
+) (*report.Report, error) {
+	db.windows = append(db.windows, window)
+	return &report.Report{
+		ReportID:  reportID,
+		Name:      name,
+		StartDate: window.StartDate,
+		EndDate:   window.EndDate,
+		Version:   len(db.windows),
+	}, nil
+}
+
+// Scheduled times missed while reports run are caught up,
+// so the snapshots remain contiguous.
+func TestSchedulerCatchesUp(t *testing.T) {
+	db := &fakeDB{}
+	s, err := NewScheduler(db, []Definition{
+		{
+			Name:          "hourly-waste",
+			ReportType:    "waste",
+			Schedule:      "@hourly",
+			WindowSeconds: 3600,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := time.Now().Truncate(time.Hour)
+	first := last.Add(-2 * time.Hour)
+	s.jobs[0].next = first
+	for i := 0; i < 5 && !s.jobs[0].next.After(time.Now()); i++ {
+		s.runDue()
+	}
+
+	want := []report.SearchByDate{}
+	for runTime := first; !runTime.After(last); runTime = runTime.Add(time.Hour) {
+		want = append(want, report.SearchByDate{
+			StartDate: runTime.Unix() - 3600,
+			EndDate:   runTime.Unix(),
+		})
+	}
+	if !reflect.DeepEqual(db.windows, want) {
+		t.Errorf("got %+v, want %+v", db.windows, want)
+	}
+	if next := last.Add(time.Hour); !s.jobs[0].next.Equal(next) {
+		t.Errorf("got next %s, want %s", s.jobs[0].next, next)
+	}
+}
+
+func TestNewSchedulerValidates(t *testing.T) {
+	valid := Definition{
+		Name:       "daily-waste",
+		ReportType: "waste",
+		Schedule:   "@daily",
+		Params:     json.RawMessage(`{"interval":"week","trend_by":"location"}`),
+	}
+	if _, err := NewScheduler(&fakeDB{}, []Definition{valid}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(def *Definition)
+	}{
+		{"unknown report-type", func(def *Definition) { def.ReportType = "wastes" }},
+		{"unparsable params", func(def *Definition) { def.Params = json.RawMessage(`{"interval":1}`) }},
+		{"invalid params", func(def *Definition) { def.Params = json.RawMessage(`{"trend_by":"sku"}`) }},
+		{"invalid schedule", func(def *Definition) { def.Schedule = "@fortnightly" }},
+		{"duplicate name", nil},
+	}
+	for _, test := range tests {
+		defs := []Definition{valid, valid}
+		if test.modify != nil {
+			defs = []Definition{valid}
+			test.modify(&defs[0])
+		}
+		if _, err := NewScheduler(&fakeDB{}, defs); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestDefinitionReportID(t *testing.T) {
+	a, err := definitionReportID(Definition{Name: "daily-sales"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := definitionReportID(Definition{Name: "daily-sales"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("got %s and %s for the same name", a, b)
+	}
+
+	c, err := definitionReportID(Definition{Name: "daily-waste"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == c {
+		t.Errorf("got %s for different names", a)
+	}
+
+	if _, err := definitionReportID(Definition{Name: "daily-sales", ReportID: "not-a-uuid"}); err == nil {
+		t.Error("expected error for invalid report_id")
+	}
+}